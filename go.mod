@@ -7,6 +7,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/kamalyes/go-argus v0.3.1
 	github.com/stretchr/testify v1.11.1
+	golang.org/x/net v0.21.0
 	google.golang.org/protobuf v1.36.10
 )
 
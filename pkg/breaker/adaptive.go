@@ -0,0 +1,359 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-25 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-25 14:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\adaptive.go
+ * @Description: 自适应并发限流器(AIMD / TCP-Vegas / Gradient)
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+)
+
+// LimitAlgorithm 并发上限调整算法
+// Update 在限流器锁内调用,同一个算法实例不要在多个限流器间共享
+type LimitAlgorithm interface {
+	// Update 根据一次调用的耗时、结果和释放前的在途数,返回新的并发上限
+	Update(limit float64, rtt time.Duration, inflight int, success bool) float64
+}
+
+// AIMDLimit 加性增/乘性减算法
+// 成功时上限+Increase,失败或超时时上限乘以 BackoffRatio
+type AIMDLimit struct {
+	Increase     float64       // 每次成功增加的上限,默认1
+	BackoffRatio float64       // 失败时的回退系数,默认0.9
+	Timeout      time.Duration // 耗时超过该值视为失败,0表示不按耗时判定
+}
+
+// NewAIMDLimit 创建AIMD算法
+func NewAIMDLimit() *AIMDLimit {
+	return &AIMDLimit{Increase: 1, BackoffRatio: 0.9}
+}
+
+// Update 计算新的并发上限
+func (a *AIMDLimit) Update(limit float64, rtt time.Duration, inflight int, success bool) float64 {
+	increase := mathx.IF(a.Increase <= 0, 1.0, a.Increase)
+	backoff := mathx.IF(a.BackoffRatio <= 0 || a.BackoffRatio >= 1, 0.9, a.BackoffRatio)
+
+	if !success || (a.Timeout > 0 && rtt > a.Timeout) {
+		return limit * backoff
+	}
+	// 只有并发被充分利用时才增加上限,避免空闲时上限无限增长
+	if float64(inflight)*2 >= limit {
+		return limit + increase
+	}
+	return limit
+}
+
+// VegasLimit TCP-Vegas 算法
+// 以观测到的最小耗时作为无负载耗时,估算排队长度: queue = limit * (1 - rttNoLoad/rtt)
+// 排队小于 Alpha 时增大上限,大于 Beta 时减小上限
+type VegasLimit struct {
+	Alpha         float64 // 排队下限,默认3
+	Beta          float64 // 排队上限,默认6
+	ProbeInterval int     // 每隔多少次采样重置无负载耗时以重新探测,默认1000,负数表示不重置
+	rttNoLoad     time.Duration
+	samples       int
+}
+
+// NewVegasLimit 创建Vegas算法
+func NewVegasLimit() *VegasLimit {
+	return &VegasLimit{Alpha: 3, Beta: 6, ProbeInterval: 1000}
+}
+
+// Update 计算新的并发上限
+func (v *VegasLimit) Update(limit float64, rtt time.Duration, inflight int, success bool) float64 {
+	alpha := mathx.IF(v.Alpha <= 0, 3.0, v.Alpha)
+	beta := mathx.IF(v.Beta <= alpha, alpha*2, v.Beta)
+	step := math.Max(1, math.Log10(limit))
+
+	v.samples++
+	if v.ProbeInterval >= 0 && v.samples >= mathx.IF(v.ProbeInterval == 0, 1000, v.ProbeInterval) {
+		v.samples = 0
+		v.rttNoLoad = 0
+	}
+	if rtt > 0 && (v.rttNoLoad == 0 || rtt < v.rttNoLoad) {
+		v.rttNoLoad = rtt
+	}
+
+	if !success {
+		return limit - step
+	}
+	if rtt <= 0 || v.rttNoLoad == 0 {
+		return limit
+	}
+
+	queue := limit * (1 - float64(v.rttNoLoad)/float64(rtt))
+	switch {
+	case queue > beta:
+		return limit - step
+	case queue < alpha && float64(inflight)*2 >= limit:
+		return limit + step
+	default:
+		return limit
+	}
+}
+
+// GradientLimit 梯度算法
+// 比较长期平均耗时与当前耗时的比值(梯度),耗时上升时按比例收缩上限,并预留 sqrt(limit) 的排队空间
+type GradientLimit struct {
+	Tolerance float64 // 允许耗时上升的容忍倍数,默认1.5
+	Smoothing float64 // 上限平滑系数(0-1),默认0.2
+	Window    int     // 长期平均耗时的采样窗口,默认600
+	longRtt   float64
+}
+
+// NewGradientLimit 创建梯度算法
+func NewGradientLimit() *GradientLimit {
+	return &GradientLimit{Tolerance: 1.5, Smoothing: 0.2, Window: 600}
+}
+
+// Update 计算新的并发上限
+func (g *GradientLimit) Update(limit float64, rtt time.Duration, inflight int, success bool) float64 {
+	tolerance := mathx.IF(g.Tolerance < 1, 1.5, g.Tolerance)
+	smoothing := mathx.IF(g.Smoothing <= 0 || g.Smoothing > 1, 0.2, g.Smoothing)
+	window := float64(mathx.IF(g.Window <= 0, 600, g.Window))
+
+	shortRtt := float64(rtt)
+	if shortRtt <= 0 {
+		return limit
+	}
+	if g.longRtt == 0 {
+		g.longRtt = shortRtt
+	} else {
+		g.longRtt += (shortRtt - g.longRtt) / window
+	}
+	// 长期耗时明显偏高时加速回落,避免负载恢复后仍保持过大的基准
+	if g.longRtt/shortRtt > 2 {
+		g.longRtt *= 0.95
+	}
+
+	if !success {
+		return limit * 0.9
+	}
+	// 并发未被充分利用时不调整
+	if float64(inflight)*2 < limit {
+		return limit
+	}
+
+	gradient := math.Max(0.5, math.Min(1, tolerance*g.longRtt/shortRtt))
+	newLimit := limit*gradient + math.Sqrt(limit)
+	return limit*(1-smoothing) + newLimit*smoothing
+}
+
+// AdaptiveConfig 自适应并发限流器配置
+type AdaptiveConfig struct {
+	InitialLimit  int                          // 初始并发上限,默认20
+	MinLimit      int                          // 最小并发上限,默认1
+	MaxLimit      int                          // 最大并发上限,默认1000
+	Algorithm     LimitAlgorithm               // 调整算法,默认AIMD
+	Collector     *MetricsCollector            // 指标收集器,可选
+	OnLimitChange func(oldLimit, newLimit int) // 并发上限变化回调
+}
+
+// AdaptiveStats 自适应并发限流器统计
+type AdaptiveStats struct {
+	Name     string
+	Limit    int   // 当前并发上限
+	InFlight int   // 当前在途请求数
+	Waiting  int   // 当前等待数
+	Rejected int64 // 累计拒绝数(TryAcquire 失败或等待被取消)
+}
+
+// AdaptiveLimiter 自适应并发限流器
+// 根据在途请求的耗时与结果动态调整并发上限,下游变慢时自动收缩
+type AdaptiveLimiter struct {
+	name          string
+	limit         float64
+	minLimit      float64
+	maxLimit      float64
+	inflight      int
+	rejected      int64
+	waiters       []chan struct{}
+	algorithm     LimitAlgorithm
+	collector     *MetricsCollector
+	onLimitChange func(oldLimit, newLimit int)
+	mu            sync.Mutex
+}
+
+// NewAdaptiveLimiter 创建自适应并发限流器
+func NewAdaptiveLimiter(name string, config AdaptiveConfig) *AdaptiveLimiter {
+	config.MinLimit = mathx.IF(config.MinLimit <= 0, 1, config.MinLimit)
+	config.MaxLimit = mathx.IF(config.MaxLimit <= 0, 1000, config.MaxLimit)
+	config.MaxLimit = mathx.IF(config.MaxLimit < config.MinLimit, config.MinLimit, config.MaxLimit)
+	config.InitialLimit = mathx.IF(config.InitialLimit <= 0, 20, config.InitialLimit)
+	config.InitialLimit = max(config.MinLimit, min(config.MaxLimit, config.InitialLimit))
+
+	algorithm := config.Algorithm
+	if algorithm == nil {
+		algorithm = NewAIMDLimit()
+	}
+
+	l := &AdaptiveLimiter{
+		name:          name,
+		limit:         float64(config.InitialLimit),
+		minLimit:      float64(config.MinLimit),
+		maxLimit:      float64(config.MaxLimit),
+		algorithm:     algorithm,
+		collector:     config.Collector,
+		onLimitChange: config.OnLimitChange,
+	}
+	l.mu.Lock()
+	l.reportLocked()
+	l.mu.Unlock()
+	return l
+}
+
+// Acquire 申请一个并发许可,达到上限时排队等待直到有许可或 ctx 结束
+// 调用方完成后必须调用 release 并告知本次调用是否成功
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) (release func(success bool), err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	if len(l.waiters) == 0 && l.inflight < l.currentLimit() {
+		release = l.grantLocked()
+		l.mu.Unlock()
+		return release, nil
+	}
+
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.reportLocked()
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return l.newRelease(time.Now()), nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		select {
+		case <-ready:
+			// 已被唤醒但 ctx 同时结束,归还许可
+			l.inflight--
+			l.wakeLocked()
+		default:
+			l.removeWaiterLocked(ready)
+		}
+		l.rejected++
+		l.reportLocked()
+		return nil, ctx.Err()
+	}
+}
+
+// TryAcquire 尝试申请一个并发许可,达到上限时立即返回 false
+func (l *AdaptiveLimiter) TryAcquire() (release func(success bool), ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.waiters) > 0 || l.inflight >= l.currentLimit() {
+		l.rejected++
+		return nil, false
+	}
+	return l.grantLocked(), true
+}
+
+// Limit 当前并发上限
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.currentLimit()
+}
+
+// Stats 获取统计信息
+func (l *AdaptiveLimiter) Stats() AdaptiveStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return AdaptiveStats{
+		Name:     l.name,
+		Limit:    l.currentLimit(),
+		InFlight: l.inflight,
+		Waiting:  len(l.waiters),
+		Rejected: l.rejected,
+	}
+}
+
+// currentLimit 当前整数并发上限(调用方需持有锁)
+func (l *AdaptiveLimiter) currentLimit() int {
+	return int(l.limit)
+}
+
+// grantLocked 发放许可(调用方需持有锁)
+func (l *AdaptiveLimiter) grantLocked() func(success bool) {
+	l.inflight++
+	l.reportLocked()
+	return l.newRelease(time.Now())
+}
+
+// newRelease 创建只能调用一次的释放函数
+func (l *AdaptiveLimiter) newRelease(start time.Time) func(success bool) {
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() {
+			l.onRelease(time.Since(start), success)
+		})
+	}
+}
+
+// onRelease 归还许可并调整并发上限
+func (l *AdaptiveLimiter) onRelease(rtt time.Duration, success bool) {
+	l.mu.Lock()
+	inflight := l.inflight
+	l.inflight--
+
+	oldLimit := l.currentLimit()
+	newLimit := l.algorithm.Update(l.limit, rtt, inflight, success)
+	l.limit = math.Max(l.minLimit, math.Min(l.maxLimit, newLimit))
+	current := l.currentLimit()
+
+	l.wakeLocked()
+	l.reportLocked()
+	l.mu.Unlock()
+
+	// 回调在锁外执行,允许回调中读取限流器状态
+	if current != oldLimit && l.onLimitChange != nil {
+		l.onLimitChange(oldLimit, current)
+	}
+}
+
+// wakeLocked 按先进先出顺序唤醒等待者(调用方需持有锁)
+func (l *AdaptiveLimiter) wakeLocked() {
+	for len(l.waiters) > 0 && l.inflight < l.currentLimit() {
+		ready := l.waiters[0]
+		l.waiters = l.waiters[1:]
+		l.inflight++
+		close(ready)
+	}
+}
+
+// removeWaiterLocked 移除等待者(调用方需持有锁)
+func (l *AdaptiveLimiter) removeWaiterLocked(ready chan struct{}) {
+	for i, w := range l.waiters {
+		if w == ready {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// reportLocked 上报指标(调用方需持有锁)
+func (l *AdaptiveLimiter) reportLocked() {
+	if l.collector == nil {
+		return
+	}
+	l.collector.SetGauge(l.name, "concurrency_limit", float64(l.currentLimit()))
+	l.collector.SetGauge(l.name, "concurrency_inflight", float64(l.inflight))
+	l.collector.SetGauge(l.name, "concurrency_waiting", float64(len(l.waiters)))
+}
@@ -0,0 +1,205 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-25 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-25 14:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\adaptive_test.go
+ * @Description: 自适应并发限流器测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveLimiterDefaults(t *testing.T) {
+	l := NewAdaptiveLimiter("test", AdaptiveConfig{})
+
+	assert.Equal(t, 20, l.Limit())
+	assert.Equal(t, 1.0, l.minLimit)
+	assert.Equal(t, 1000.0, l.maxLimit)
+	assert.IsType(t, &AIMDLimit{}, l.algorithm)
+
+	l = NewAdaptiveLimiter("test", AdaptiveConfig{InitialLimit: 50, MaxLimit: 10})
+	assert.Equal(t, 10, l.Limit())
+}
+
+func TestAdaptiveLimiterTryAcquire(t *testing.T) {
+	l := NewAdaptiveLimiter("test", AdaptiveConfig{InitialLimit: 2, Algorithm: &AIMDLimit{Increase: 0.01}})
+
+	r1, ok := l.TryAcquire()
+	assert.True(t, ok)
+	_, ok = l.TryAcquire()
+	assert.True(t, ok)
+	_, ok = l.TryAcquire()
+	assert.False(t, ok)
+
+	stats := l.Stats()
+	assert.Equal(t, 2, stats.InFlight)
+	assert.Equal(t, int64(1), stats.Rejected)
+
+	r1(true)
+	// 重复释放无效
+	r1(true)
+	assert.Equal(t, 1, l.Stats().InFlight)
+}
+
+func TestAdaptiveLimiterAcquireWaits(t *testing.T) {
+	l := NewAdaptiveLimiter("test", AdaptiveConfig{InitialLimit: 1, MaxLimit: 1})
+
+	release, err := l.Acquire(context.Background())
+	assert.NoError(t, err)
+
+	acquired := make(chan struct{})
+	go func() {
+		r, err := l.Acquire(context.Background())
+		assert.NoError(t, err)
+		close(acquired)
+		r(true)
+	}()
+
+	assert.Eventually(t, func() bool { return l.Stats().Waiting == 1 }, time.Second, time.Millisecond)
+	release(true)
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("waiter should be woken up after release")
+	}
+}
+
+func TestAdaptiveLimiterAcquireCanceled(t *testing.T) {
+	l := NewAdaptiveLimiter("test", AdaptiveConfig{InitialLimit: 1, MaxLimit: 1})
+	release, _ := l.Acquire(context.Background())
+	defer release(true)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := l.Acquire(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	stats := l.Stats()
+	assert.Equal(t, 0, stats.Waiting)
+	assert.Equal(t, 1, stats.InFlight)
+	assert.Equal(t, int64(1), stats.Rejected)
+}
+
+func TestAIMDLimit(t *testing.T) {
+	a := NewAIMDLimit()
+
+	// 充分利用时成功增加
+	assert.Equal(t, 11.0, a.Update(10, time.Millisecond, 10, true))
+	// 未充分利用时不变
+	assert.Equal(t, 10.0, a.Update(10, time.Millisecond, 2, true))
+	// 失败时乘性减少
+	assert.Equal(t, 9.0, a.Update(10, time.Millisecond, 10, false))
+
+	a.Timeout = 50 * time.Millisecond
+	assert.Equal(t, 9.0, a.Update(10, 100*time.Millisecond, 10, true))
+}
+
+func TestVegasLimit(t *testing.T) {
+	v := NewVegasLimit()
+
+	// 首次采样确定无负载耗时,无排队时增加
+	assert.InDelta(t, 21.301, v.Update(20, 10*time.Millisecond, 20, true), 0.001)
+	limit := v.Update(100, 10*time.Millisecond, 100, true)
+	assert.Equal(t, 102.0, limit)
+
+	// 耗时翻倍,排队长度 100*(1-0.5)=50 > beta,减少
+	assert.Equal(t, 98.0, v.Update(100, 20*time.Millisecond, 100, true))
+
+	// 失败时减少
+	assert.Equal(t, 98.0, v.Update(100, 10*time.Millisecond, 100, false))
+}
+
+func TestGradientLimit(t *testing.T) {
+	g := NewGradientLimit()
+
+	// 耗时稳定时增长
+	limit := 100.0
+	for i := 0; i < 10; i++ {
+		limit = g.Update(limit, 10*time.Millisecond, int(limit), true)
+	}
+	assert.Greater(t, limit, 100.0)
+
+	// 耗时突增时收缩
+	grown := limit
+	for i := 0; i < 10; i++ {
+		limit = g.Update(limit, 100*time.Millisecond, int(limit), true)
+	}
+	assert.Less(t, limit, grown)
+
+	// 未充分利用时不调整
+	assert.Equal(t, limit, g.Update(limit, 10*time.Millisecond, 1, true))
+}
+
+func TestAdaptiveLimiterShrinksOnFailure(t *testing.T) {
+	mc := NewMetricsCollector()
+	var changes [][2]int
+	l := NewAdaptiveLimiter("downstream", AdaptiveConfig{
+		InitialLimit: 10,
+		MinLimit:     2,
+		Collector:    mc,
+		OnLimitChange: func(oldLimit, newLimit int) {
+			changes = append(changes, [2]int{oldLimit, newLimit})
+		},
+	})
+
+	for i := 0; i < 20; i++ {
+		release, ok := l.TryAcquire()
+		assert.True(t, ok)
+		release(false)
+	}
+
+	assert.Equal(t, 2, l.Limit())
+	assert.NotEmpty(t, changes)
+	assert.Equal(t, 10, changes[0][0])
+	assert.Equal(t, 2.0, mc.GetGauge("downstream", "concurrency_limit"))
+	assert.Equal(t, 0.0, mc.GetGauge("downstream", "concurrency_inflight"))
+	assert.Contains(t, NewPrometheusExporter(mc, "app", "svc").Export(), `app_svc_concurrency_limit{service="svc",resource="downstream"} 2`)
+}
+
+func TestAdaptiveLimiterConcurrent(t *testing.T) {
+	l := NewAdaptiveLimiter("test", AdaptiveConfig{InitialLimit: 5, MaxLimit: 5})
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		current int
+		peak    int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := l.Acquire(context.Background())
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			current++
+			peak = max(peak, current)
+			mu.Unlock()
+
+			time.Sleep(time.Millisecond)
+
+			mu.Lock()
+			current--
+			mu.Unlock()
+			release(true)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, peak, 5)
+	assert.Equal(t, 0, l.Stats().InFlight)
+}
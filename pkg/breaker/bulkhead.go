@@ -0,0 +1,182 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-25 18:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-25 18:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\bulkhead.go
+ * @Description: 舱壁隔离(最大并发数 + 有界等待队列)
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+)
+
+// BulkheadConfig 舱壁配置
+type BulkheadConfig struct {
+	MaxConcurrent int               // 最大并发数,默认10
+	MaxQueue      int               // 最大排队数,0表示不排队(并发满时立即拒绝)
+	MaxWait       time.Duration     // 排队最长等待时间,0表示等待直到 ctx 结束
+	Collector     *MetricsCollector // 指标收集器,可选
+}
+
+// BulkheadStats 舱壁统计
+type BulkheadStats struct {
+	Name          string
+	MaxConcurrent int
+	MaxQueue      int
+	Active        int   // 当前执行数
+	Queued        int   // 当前排队数
+	Rejected      int64 // 累计拒绝数
+}
+
+// Bulkhead 舱壁
+// 限制单个资源的最大并发数,避免某个慢资源耗尽全部协程/连接
+type Bulkhead struct {
+	name      string
+	maxWait   time.Duration
+	slots     chan struct{} // 执行名额
+	queue     chan struct{} // 排队名额
+	rejected  int64
+	collector *MetricsCollector
+}
+
+// NewBulkhead 创建舱壁
+func NewBulkhead(name string, config BulkheadConfig) *Bulkhead {
+	config.MaxConcurrent = mathx.IF(config.MaxConcurrent <= 0, 10, config.MaxConcurrent)
+	config.MaxQueue = mathx.IF(config.MaxQueue < 0, 0, config.MaxQueue)
+
+	b := &Bulkhead{
+		name:      name,
+		maxWait:   config.MaxWait,
+		slots:     make(chan struct{}, config.MaxConcurrent),
+		queue:     make(chan struct{}, config.MaxQueue),
+		collector: config.Collector,
+	}
+	b.report()
+	return b
+}
+
+// TryAcquire 尝试获取执行名额,不排队,已有调用方排队时不抢占
+func (b *Bulkhead) TryAcquire() (release func(), ok bool) {
+	if len(b.queue) > 0 {
+		b.reject()
+		return nil, false
+	}
+	select {
+	case b.slots <- struct{}{}:
+		b.report()
+		return b.newRelease(), true
+	default:
+		b.reject()
+		return nil, false
+	}
+}
+
+// Acquire 获取执行名额,并发已满或已有调用方排队时进入等待队列,排队的调用方按到达顺序获取名额
+// 队列已满或等待超时返回 *BulkheadError,ctx 结束返回 ctx.Err()
+func (b *Bulkhead) Acquire(ctx context.Context) (release func(), err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// 没有排队的调用方时直接获取空闲名额,否则排到队尾,避免新调用方抢占
+	if len(b.queue) == 0 {
+		select {
+		case b.slots <- struct{}{}:
+			b.report()
+			return b.newRelease(), nil
+		default:
+		}
+	}
+
+	// 进入等待队列
+	select {
+	case b.queue <- struct{}{}:
+	default:
+		b.reject()
+		return nil, &BulkheadError{Name: b.name, Reason: "max concurrent calls and wait queue are full"}
+	}
+	b.report()
+	defer func() {
+		<-b.queue
+		b.report()
+	}()
+
+	var timeout <-chan time.Time
+	if b.maxWait > 0 {
+		timer := time.NewTimer(b.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	// 阻塞在 slots 上的发送方按先后顺序被唤醒
+	select {
+	case b.slots <- struct{}{}:
+		return b.newRelease(), nil
+	case <-timeout:
+		b.reject()
+		return nil, &BulkheadError{Name: b.name, Reason: "max wait time exceeded"}
+	case <-ctx.Done():
+		b.reject()
+		return nil, ctx.Err()
+	}
+}
+
+// Execute 在舱壁保护下执行操作
+func (b *Bulkhead) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	release, err := b.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn(ctx)
+}
+
+// Stats 获取统计信息
+func (b *Bulkhead) Stats() BulkheadStats {
+	return BulkheadStats{
+		Name:          b.name,
+		MaxConcurrent: cap(b.slots),
+		MaxQueue:      cap(b.queue),
+		Active:        len(b.slots),
+		Queued:        len(b.queue),
+		Rejected:      atomic.LoadInt64(&b.rejected),
+	}
+}
+
+// newRelease 创建只能调用一次的释放函数
+func (b *Bulkhead) newRelease() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			<-b.slots
+			b.report()
+		})
+	}
+}
+
+// reject 记录一次拒绝
+func (b *Bulkhead) reject() {
+	atomic.AddInt64(&b.rejected, 1)
+	if b.collector != nil {
+		b.collector.RecordRejected(b.name)
+	}
+}
+
+// report 上报当前执行数与排队数
+func (b *Bulkhead) report() {
+	if b.collector == nil {
+		return
+	}
+	b.collector.SetGauge(b.name, "bulkhead_active", float64(len(b.slots)))
+	b.collector.SetGauge(b.name, "bulkhead_queued", float64(len(b.queue)))
+}
@@ -0,0 +1,216 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-25 18:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-25 18:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\bulkhead_test.go
+ * @Description: 舱壁隔离测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBulkheadDefaults(t *testing.T) {
+	b := NewBulkhead("test", BulkheadConfig{MaxQueue: -1})
+	stats := b.Stats()
+
+	assert.Equal(t, "test", stats.Name)
+	assert.Equal(t, 10, stats.MaxConcurrent)
+	assert.Equal(t, 0, stats.MaxQueue)
+}
+
+func TestBulkheadTryAcquire(t *testing.T) {
+	b := NewBulkhead("test", BulkheadConfig{MaxConcurrent: 1})
+
+	release, ok := b.TryAcquire()
+	assert.True(t, ok)
+	_, ok = b.TryAcquire()
+	assert.False(t, ok)
+
+	release()
+	release() // 重复释放无效
+	assert.Equal(t, 0, b.Stats().Active)
+
+	_, ok = b.TryAcquire()
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), b.Stats().Rejected)
+}
+
+func TestBulkheadRejectWithoutQueue(t *testing.T) {
+	b := NewBulkhead("db", BulkheadConfig{MaxConcurrent: 1})
+	release, err := b.Acquire(context.Background())
+	assert.NoError(t, err)
+	defer release()
+
+	_, err = b.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+
+	var bulkheadErr *BulkheadError
+	assert.True(t, errors.As(err, &bulkheadErr))
+	assert.Equal(t, "db", bulkheadErr.Name)
+	assert.Contains(t, err.Error(), "bulkhead db rejected")
+}
+
+func TestBulkheadQueueWait(t *testing.T) {
+	b := NewBulkhead("test", BulkheadConfig{MaxConcurrent: 1, MaxQueue: 1})
+	release, _ := b.Acquire(context.Background())
+
+	done := make(chan error, 1)
+	go func() {
+		r, err := b.Acquire(context.Background())
+		if err == nil {
+			r()
+		}
+		done <- err
+	}()
+
+	assert.Eventually(t, func() bool { return b.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+	// 队列已满,第三个请求被拒绝
+	_, err := b.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+
+	release()
+	assert.NoError(t, <-done)
+	assert.Equal(t, 0, b.Stats().Queued)
+}
+
+func TestBulkheadQueueFIFO(t *testing.T) {
+	b := NewBulkhead("test", BulkheadConfig{MaxConcurrent: 1, MaxQueue: 5})
+	release, _ := b.Acquire(context.Background())
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := b.Acquire(context.Background())
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			r()
+		}()
+		// 等待前一个调用方进入队列后再启动下一个
+		assert.Eventually(t, func() bool { return b.Stats().Queued == i+1 }, time.Second, time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// 有调用方排队时 TryAcquire 不抢占
+	_, ok := b.TryAcquire()
+	assert.False(t, ok)
+
+	release()
+	wg.Wait()
+	assert.Equal(t, []int{0, 1, 2, 3}, order)
+}
+
+func TestBulkheadTryAcquireWhileQueued(t *testing.T) {
+	b := NewBulkhead("test", BulkheadConfig{MaxConcurrent: 1, MaxQueue: 2})
+	b.queue <- struct{}{} // 模拟已取得排队名额、尚未等待执行名额的调用方
+
+	// 存在空闲名额但已有调用方排队时,新调用方不抢占
+	_, ok := b.TryAcquire()
+	assert.False(t, ok)
+	assert.Equal(t, 0, b.Stats().Active)
+	<-b.queue
+
+	release, ok := b.TryAcquire()
+	assert.True(t, ok)
+	release()
+}
+
+func TestBulkheadMaxWait(t *testing.T) {
+	b := NewBulkhead("test", BulkheadConfig{MaxConcurrent: 1, MaxQueue: 1, MaxWait: 20 * time.Millisecond})
+	release, _ := b.Acquire(context.Background())
+	defer release()
+
+	start := time.Now()
+	_, err := b.Acquire(context.Background())
+	assert.ErrorIs(t, err, ErrBulkheadFull)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Contains(t, err.Error(), "max wait time exceeded")
+}
+
+func TestBulkheadContextCanceled(t *testing.T) {
+	b := NewBulkhead("test", BulkheadConfig{MaxConcurrent: 1, MaxQueue: 1})
+	release, _ := b.Acquire(context.Background())
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := b.Acquire(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	_, err = b.Acquire(canceled)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBulkheadExecuteLimitsConcurrency(t *testing.T) {
+	mc := NewMetricsCollector()
+	b := NewBulkhead("svc", BulkheadConfig{MaxConcurrent: 3, MaxQueue: 100, Collector: mc})
+
+	var current, peak int32
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := b.Execute(context.Background(), func(ctx context.Context) error {
+				n := atomic.AddInt32(&current, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&current, -1)
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, peak, int32(3))
+	assert.Equal(t, 0.0, mc.GetGauge("svc", "bulkhead_active"))
+	assert.Equal(t, 0.0, mc.GetGauge("svc", "bulkhead_queued"))
+}
+
+func TestBulkheadMetricsExport(t *testing.T) {
+	mc := NewMetricsCollector()
+	b := NewBulkhead("svc", BulkheadConfig{MaxConcurrent: 1, Collector: mc})
+
+	release, _ := b.TryAcquire()
+	_, ok := b.TryAcquire()
+	assert.False(t, ok)
+
+	assert.Equal(t, 1.0, mc.GetGauge("svc", "bulkhead_active"))
+	assert.Equal(t, int64(1), mc.GetRejectedCount("svc"))
+
+	output := NewPrometheusExporter(mc, "app", "api").Export()
+	assert.Contains(t, output, `app_api_bulkhead_active{service="api",resource="svc"} 1`)
+	assert.Contains(t, output, `app_api_bulkhead_queued{service="api",resource="svc"} 0`)
+	assert.Contains(t, output, `app_api_rejected_count{service="api",resource="svc"} 1`)
+
+	release()
+}
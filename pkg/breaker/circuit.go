@@ -54,6 +54,17 @@ type Circuit struct {
 	lastFailureTime   int64         // 最后失败时间
 	mu                sync.RWMutex
 	onStateChange     func(from, to State)
+
+	// 滑动窗口模式
+	window                *slidingWindow // 滑动窗口(nil表示按连续失败次数熔断)
+	failureRateThreshold  float64        // 失败率阈值(百分比)
+	slowCallRateThreshold float64        // 慢调用率阈值(百分比)
+	slowCallDuration      time.Duration  // 慢调用判定时长
+	minimumCalls          int64          // 窗口内最少调用次数,达到后才评估
+
+	maxHalfOpenRequests int32                // 半开状态最大并发探测数(0表示不限制)
+	halfOpenInFlight    int32                // 半开状态当前探测数
+	isFailure           func(err error) bool // 自定义失败判定，未设置时为 nil
 }
 
 // Config 熔断器配置
@@ -62,6 +73,26 @@ type Config struct {
 	ResetTimeout      time.Duration
 	HalfOpenSuccesses int32
 	OnStateChange     func(from, to State)
+
+	// WindowType 滑动窗口类型,默认 WindowNone 即按连续 MaxFailures 次失败熔断
+	WindowType WindowType
+	// WindowSize 窗口大小: 计数窗口为调用次数,时间窗口为秒数
+	WindowSize int
+	// FailureRateThreshold 失败率阈值(百分比,0-100),达到后熔断
+	FailureRateThreshold float64
+	// SlowCallRateThreshold 慢调用率阈值(百分比,0-100),达到后熔断,0表示不启用
+	SlowCallRateThreshold float64
+	// SlowCallDuration 调用耗时超过该值视为慢调用
+	SlowCallDuration time.Duration
+	// MinimumCalls 窗口内至少有多少次调用后才评估失败率/慢调用率
+	MinimumCalls int64
+
+	// MaxHalfOpenRequests 半开状态下允许同时进行的探测请求数,0表示不限制
+	// 超出时 Execute/ExecuteCtx 返回 ErrTooManyHalfOpenRequests
+	MaxHalfOpenRequests int32
+	// IsFailure 判定错误是否计为失败,返回 false 的错误既不计为失败也不计为成功
+	// 未设置时 ExecuteCtx/ExecuteWithFallback 使用 DefaultIsFailure,Execute 将所有错误计为失败
+	IsFailure func(err error) bool
 }
 
 // New 创建熔断器
@@ -70,30 +101,75 @@ func New(name string, config Config) *Circuit {
 	config.ResetTimeout = mathx.IF(config.ResetTimeout == 0, 30*time.Second, config.ResetTimeout)
 	config.HalfOpenSuccesses = mathx.IF(config.HalfOpenSuccesses == 0, 2, config.HalfOpenSuccesses)
 
-	return &Circuit{
+	c := &Circuit{
 		name:              name,
 		maxFailures:       config.MaxFailures,
 		resetTimeout:      config.ResetTimeout,
 		halfOpenSuccesses: config.HalfOpenSuccesses,
 		state:             int32(StateClosed),
 		onStateChange:     config.OnStateChange,
+
+		maxHalfOpenRequests: config.MaxHalfOpenRequests,
+		isFailure:           config.IsFailure,
+	}
+
+	if config.WindowType == WindowCount || config.WindowType == WindowTime {
+		config.WindowSize = mathx.IF(config.WindowSize <= 0, mathx.IF(config.WindowType == WindowCount, 100, 60), config.WindowSize)
+		config.FailureRateThreshold = mathx.IF(config.FailureRateThreshold <= 0, 50.0, config.FailureRateThreshold)
+		config.SlowCallDuration = mathx.IF(config.SlowCallDuration <= 0, 5*time.Second, config.SlowCallDuration)
+		config.MinimumCalls = mathx.IF(config.MinimumCalls <= 0, 10, config.MinimumCalls)
+
+		c.window = newSlidingWindow(config.WindowType, config.WindowSize)
+		c.failureRateThreshold = config.FailureRateThreshold
+		c.slowCallRateThreshold = config.SlowCallRateThreshold
+		c.slowCallDuration = config.SlowCallDuration
+		c.minimumCalls = config.MinimumCalls
 	}
+
+	return c
 }
 
 // Execute 执行带熔断保护的操作
+// 未设置 Config.IsFailure 时 fn 返回的所有错误都计为失败
 func (c *Circuit) Execute(fn func() error) error {
+	release, err := c.acquire()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	start := time.Now()
+	err = fn()
+	c.recordError(err, time.Since(start), c.isFailure)
+	return err
+}
+
+// acquire 申请执行许可,返回的 release 用于归还半开状态的探测名额
+func (c *Circuit) acquire() (release func(), err error) {
 	if !c.AllowRequest() {
-		return ErrOpen
+		return nil, ErrCircuitOpen
 	}
 
-	err := fn()
-	if err != nil {
-		c.RecordFailure()
-		return err
+	if c.maxHalfOpenRequests <= 0 || c.GetState() != StateHalfOpen {
+		return func() {}, nil
+	}
+
+	if atomic.AddInt32(&c.halfOpenInFlight, 1) > c.maxHalfOpenRequests {
+		atomic.AddInt32(&c.halfOpenInFlight, -1)
+		return nil, ErrTooManyHalfOpenRequests
 	}
+	return func() { atomic.AddInt32(&c.halfOpenInFlight, -1) }, nil
+}
 
-	c.RecordSuccess()
-	return nil
+// recordError 按失败判定规则记录执行结果，isFailure 为 nil 时所有错误计为失败
+func (c *Circuit) recordError(err error, duration time.Duration, isFailure func(err error) bool) {
+	if err == nil {
+		c.RecordResult(true, duration)
+		return
+	}
+	if isFailure == nil || isFailure(err) {
+		c.RecordResult(false, duration)
+	}
 }
 
 // AllowRequest 是否允许请求
@@ -118,14 +194,42 @@ func (c *Circuit) AllowRequest() bool {
 	}
 }
 
+// RecordResult 记录一次调用结果及耗时,滑动窗口模式下用于统计失败率和慢调用率
+func (c *Circuit) RecordResult(success bool, duration time.Duration) {
+	slow := c.window != nil && c.slowCallRateThreshold > 0 && duration >= c.slowCallDuration
+	if success {
+		c.onSuccess(slow)
+		return
+	}
+	c.onFailure(slow)
+}
+
 // RecordSuccess 记录成功
 func (c *Circuit) RecordSuccess() {
+	c.onSuccess(false)
+}
+
+// RecordFailure 记录失败
+func (c *Circuit) RecordFailure() {
+	c.onFailure(false)
+}
+
+// onSuccess 处理成功结果
+func (c *Circuit) onSuccess(slow bool) {
 	state := State(atomic.LoadInt32(&c.state))
 
 	switch state {
 	case StateClosed:
 		atomic.StoreInt32(&c.failures, 0)
+		if c.window != nil {
+			c.evaluate(c.window.record(false, slow))
+		}
 	case StateHalfOpen:
+		// 半开状态下的慢调用视为探测失败
+		if slow {
+			c.onFailure(true)
+			return
+		}
 		successes := atomic.AddInt32(&c.successes, 1)
 		if successes >= c.halfOpenSuccesses {
 			c.setState(StateClosed)
@@ -135,14 +239,18 @@ func (c *Circuit) RecordSuccess() {
 	}
 }
 
-// RecordFailure 记录失败
-func (c *Circuit) RecordFailure() {
+// onFailure 处理失败结果
+func (c *Circuit) onFailure(slow bool) {
 	atomic.StoreInt64(&c.lastFailureTime, time.Now().UnixNano())
 	state := State(atomic.LoadInt32(&c.state))
 
 	switch state {
 	case StateClosed:
 		failures := atomic.AddInt32(&c.failures, 1)
+		if c.window != nil {
+			c.evaluate(c.window.record(true, slow))
+			return
+		}
 		if failures >= c.maxFailures {
 			c.setState(StateOpen)
 		}
@@ -152,6 +260,17 @@ func (c *Circuit) RecordFailure() {
 	}
 }
 
+// evaluate 根据窗口快照判断是否需要熔断
+func (c *Circuit) evaluate(snapshot WindowSnapshot) {
+	if snapshot.TotalCalls < c.minimumCalls {
+		return
+	}
+	if snapshot.FailureRate >= c.failureRateThreshold ||
+		(c.slowCallRateThreshold > 0 && snapshot.SlowCallRate >= c.slowCallRateThreshold) {
+		c.setState(StateOpen)
+	}
+}
+
 // setState 设置状态
 func (c *Circuit) setState(newState State) {
 	c.mu.Lock()
@@ -164,6 +283,11 @@ func (c *Circuit) setState(newState State) {
 
 	atomic.StoreInt32(&c.state, int32(newState))
 
+	// 状态切换后窗口重新统计,避免旧数据影响新一轮评估
+	if c.window != nil && newState != StateHalfOpen {
+		c.window.reset()
+	}
+
 	if c.onStateChange != nil {
 		c.onStateChange(oldState, newState)
 	}
@@ -185,16 +309,33 @@ func (c *Circuit) GetStats() map[string]interface{} {
 
 // Stats 统计信息
 func (c *Circuit) Stats() CircuitStats {
-	return CircuitStats{
-		Name:     c.name,
-		State:    c.GetState().String(),
-		Failures: atomic.LoadInt32(&c.failures),
+	stats := CircuitStats{
+		Name:       c.name,
+		State:      c.GetState().String(),
+		Failures:   atomic.LoadInt32(&c.failures),
+		WindowType: WindowNone.String(),
+	}
+	if c.window != nil {
+		snapshot := c.window.Snapshot()
+		stats.WindowType = c.window.kind.String()
+		stats.TotalCalls = snapshot.TotalCalls
+		stats.FailedCalls = snapshot.FailedCalls
+		stats.SlowCalls = snapshot.SlowCalls
+		stats.FailureRate = snapshot.FailureRate
+		stats.SlowCallRate = snapshot.SlowCallRate
 	}
+	return stats
 }
 
 // CircuitStats 熔断器统计
 type CircuitStats struct {
-	Name     string
-	State    string
-	Failures int32
+	Name         string
+	State        string
+	Failures     int32
+	WindowType   string  // 滑动窗口类型
+	TotalCalls   int64   // 窗口内调用总数
+	FailedCalls  int64   // 窗口内失败次数
+	SlowCalls    int64   // 窗口内慢调用次数
+	FailureRate  float64 // 窗口内失败率(百分比)
+	SlowCallRate float64 // 窗口内慢调用率(百分比)
 }
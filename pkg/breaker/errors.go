@@ -11,12 +11,65 @@
 
 package breaker
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+)
 
 var (
 	// ErrOpen 熔断器打开错误
 	ErrOpen = errors.New("circuit breaker is open")
 
+	// ErrCircuitOpen 熔断器打开错误(ErrOpen 的别名,两者可互换用于 errors.Is)
+	ErrCircuitOpen = ErrOpen
+
+	// ErrTooManyHalfOpenRequests 半开状态下探测请求数超过上限
+	ErrTooManyHalfOpenRequests = errors.New("too many requests in half-open state")
+
 	// ErrRateLimitExceeded 限流错误
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
+
+	// ErrBulkheadFull 舱壁已满错误,BulkheadError 可通过 errors.Is 与之匹配
+	ErrBulkheadFull = errors.New("bulkhead is full")
 )
+
+// BulkheadError 舱壁拒绝错误
+type BulkheadError struct {
+	Name   string // 资源名
+	Reason string // 拒绝原因
+}
+
+// Error 实现 error 接口
+func (e *BulkheadError) Error() string {
+	return fmt.Sprintf("bulkhead %s rejected: %s", e.Name, e.Reason)
+}
+
+// Unwrap 返回 ErrBulkheadFull
+func (e *BulkheadError) Unwrap() error {
+	return ErrBulkheadFull
+}
+
+// IsRejected 判断错误是否为熔断器拒绝请求(熔断打开或半开探测已满)
+func IsRejected(err error) bool {
+	return errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrTooManyHalfOpenRequests)
+}
+
+// DefaultIsFailure 默认的失败判定
+// 调用方主动取消以及资源不存在类错误不代表下游故障,不计入熔断统计
+func DefaultIsFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	errType := errorx.ClassifyError(err)
+	var customErr *errorx.CustomError
+	if errors.As(err, &customErr) {
+		errType = customErr.Code
+	}
+	return errType != errorx.ErrTypeNotFound && errType != errorx.ErrTypeDataNotFound
+}
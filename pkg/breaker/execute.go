@@ -0,0 +1,60 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-24 11:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-24 11:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\execute.go
+ * @Description: 泛型、支持 context 的熔断执行
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+)
+
+// ExecuteCtx 在熔断保护下执行带返回值的操作
+// 执行前检查 ctx 是否已结束; 熔断器拒绝请求或 fn 返回错误时,若提供了 fallback 则返回 fallback 的结果
+// 错误是否计入失败由 Config.IsFailure 决定,未设置时使用 DefaultIsFailure
+func ExecuteCtx[T any](ctx context.Context, c *Circuit, fn func(ctx context.Context) (T, error), fallback func(ctx context.Context, err error) (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	release, err := c.acquire()
+	if err != nil {
+		if fallback != nil {
+			return fallback(ctx, err)
+		}
+		return zero, err
+	}
+	defer release()
+
+	start := time.Now()
+	result, err := fn(ctx)
+	c.recordError(err, time.Since(start), mathx.IF(c.isFailure == nil, DefaultIsFailure, c.isFailure))
+
+	if err != nil && fallback != nil {
+		return fallback(ctx, err)
+	}
+	return result, err
+}
+
+// ExecuteWithFallback 在熔断保护下执行带返回值的操作,被拒绝或执行失败时调用 fallback
+func ExecuteWithFallback[T any](c *Circuit, fn func() (T, error), fallback func(err error) (T, error)) (T, error) {
+	var ctxFallback func(ctx context.Context, err error) (T, error)
+	if fallback != nil {
+		ctxFallback = func(_ context.Context, err error) (T, error) {
+			return fallback(err)
+		}
+	}
+	return ExecuteCtx(context.Background(), c, func(context.Context) (T, error) {
+		return fn()
+	}, ctxFallback)
+}
@@ -0,0 +1,200 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-24 11:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-24 11:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\execute_test.go
+ * @Description: 泛型熔断执行测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteCtxSuccess(t *testing.T) {
+	cb := New("test", Config{MaxFailures: 2})
+
+	result, err := ExecuteCtx(context.Background(), cb, func(ctx context.Context) (int, error) {
+		return 42, nil
+	}, nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 42, result)
+	assert.Equal(t, StateClosed, cb.GetState())
+}
+
+func TestExecuteCtxFailureWithFallback(t *testing.T) {
+	cb := New("test", Config{MaxFailures: 1, ResetTimeout: time.Second})
+	testErr := errors.New("boom")
+
+	var fallbackErr error
+	result, err := ExecuteCtx(context.Background(), cb, func(ctx context.Context) (string, error) {
+		return "", testErr
+	}, func(ctx context.Context, err error) (string, error) {
+		fallbackErr = err
+		return "fallback", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "fallback", result)
+	assert.Equal(t, testErr, fallbackErr)
+	assert.Equal(t, StateOpen, cb.GetState())
+
+	// 熔断打开后 fallback 收到 ErrCircuitOpen
+	result, err = ExecuteCtx(context.Background(), cb, func(ctx context.Context) (string, error) {
+		t.Fatal("should not be called when circuit is open")
+		return "", nil
+	}, func(ctx context.Context, err error) (string, error) {
+		fallbackErr = err
+		return "fallback", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "fallback", result)
+	assert.ErrorIs(t, fallbackErr, ErrCircuitOpen)
+}
+
+func TestExecuteCtxOpenWithoutFallback(t *testing.T) {
+	cb := New("test", Config{MaxFailures: 1, ResetTimeout: time.Second})
+	cb.RecordFailure()
+
+	_, err := ExecuteCtx(context.Background(), cb, func(ctx context.Context) (int, error) {
+		return 1, nil
+	}, nil)
+
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.ErrorIs(t, err, ErrOpen)
+	assert.True(t, IsRejected(err))
+}
+
+func TestExecuteCtxCanceledContext(t *testing.T) {
+	cb := New("test", Config{MaxFailures: 1})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	_, err := ExecuteCtx(ctx, cb, func(ctx context.Context) (int, error) {
+		called = true
+		return 0, nil
+	}, nil)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+}
+
+func TestExecuteCtxIgnoredErrors(t *testing.T) {
+	cb := New("test", Config{MaxFailures: 1})
+
+	ignored := []error{
+		context.Canceled,
+		fmt.Errorf("wrapped: %w", context.Canceled),
+		errorx.NewNotFoundError("user"),
+		fmt.Errorf("wrapped: %w", errorx.NewDataNotFoundError("order")),
+		errorx.NewTypedError(errorx.ErrTypeNotFound, "missing %s", "item"),
+	}
+	for _, ignoredErr := range ignored {
+		_, err := ExecuteCtx(context.Background(), cb, func(ctx context.Context) (int, error) {
+			return 0, ignoredErr
+		}, nil)
+		assert.Equal(t, ignoredErr, err)
+		assert.Equal(t, StateClosed, cb.GetState(), "error %v should be ignored", ignoredErr)
+	}
+}
+
+func TestExecuteCtxCustomIsFailure(t *testing.T) {
+	businessErr := errors.New("business")
+	cb := New("test", Config{
+		MaxFailures: 1,
+		IsFailure: func(err error) bool {
+			return !errors.Is(err, businessErr)
+		},
+	})
+
+	_ = cb.Execute(func() error { return businessErr })
+	assert.Equal(t, StateClosed, cb.GetState())
+
+	_ = cb.Execute(func() error { return errors.New("other") })
+	assert.Equal(t, StateOpen, cb.GetState())
+}
+
+func TestExecuteCountsAllErrors(t *testing.T) {
+	// 未设置 IsFailure 时 Execute 保持原有行为,取消和资源不存在类错误也计为失败
+	for _, err := range []error{context.Canceled, errorx.NewNotFoundError("user"), errorx.NewDataNotFoundError("order")} {
+		cb := New("test", Config{MaxFailures: 1})
+		assert.Equal(t, err, cb.Execute(func() error { return err }))
+		assert.Equal(t, StateOpen, cb.GetState(), "error %v should count as failure", err)
+	}
+}
+
+func TestExecuteCtxTooManyHalfOpenRequests(t *testing.T) {
+	cb := New("test", Config{
+		MaxFailures:         1,
+		ResetTimeout:        20 * time.Millisecond,
+		HalfOpenSuccesses:   1,
+		MaxHalfOpenRequests: 1,
+	})
+	cb.RecordFailure()
+	time.Sleep(30 * time.Millisecond)
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = ExecuteCtx(context.Background(), cb, func(ctx context.Context) (int, error) {
+			close(started)
+			<-finish
+			return 1, nil
+		}, nil)
+	}()
+
+	<-started
+	assert.Equal(t, StateHalfOpen, cb.GetState())
+	_, err := ExecuteCtx(context.Background(), cb, func(ctx context.Context) (int, error) {
+		return 2, nil
+	}, nil)
+	assert.ErrorIs(t, err, ErrTooManyHalfOpenRequests)
+	assert.True(t, IsRejected(err))
+
+	close(finish)
+	wg.Wait()
+	assert.Equal(t, StateClosed, cb.GetState())
+}
+
+func TestExecuteWithFallback(t *testing.T) {
+	cb := New("test", Config{MaxFailures: 1, ResetTimeout: time.Second})
+
+	result, err := ExecuteWithFallback(cb, func() (int, error) {
+		return 0, errors.New("boom")
+	}, func(err error) (int, error) {
+		return -1, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, -1, result)
+
+	_, err = ExecuteWithFallback(cb, func() (int, error) {
+		return 1, nil
+	}, nil)
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestDefaultIsFailure(t *testing.T) {
+	assert.False(t, DefaultIsFailure(nil))
+	assert.False(t, DefaultIsFailure(context.Canceled))
+	assert.False(t, DefaultIsFailure(errorx.NewNotFoundError("x")))
+	assert.True(t, DefaultIsFailure(context.DeadlineExceeded))
+	assert.True(t, DefaultIsFailure(errorx.NewInternalError("x")))
+	assert.True(t, DefaultIsFailure(errors.New("x")))
+}
@@ -0,0 +1,131 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-26 09:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-26 09:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\histogram.go
+ * @Description: 耗时直方图与分位数统计
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"math"
+	"sort"
+)
+
+// 执行结果标签值
+const (
+	OutcomeSuccess     = "success"
+	OutcomeFailure     = "failure"
+	OutcomeRejected    = "rejected"
+	OutcomeRateLimited = "rate_limited"
+)
+
+// DefaultLatencyBuckets 默认耗时分桶上界(秒),与 Prometheus 客户端默认值一致
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// summarySampleSize 计算分位数时保留的最近样本数
+const summarySampleSize = 1024
+
+// HistogramSnapshot 直方图快照
+type HistogramSnapshot struct {
+	Buckets   []float64           `json:"buckets"`             // 分桶上界(秒),不含 +Inf
+	Counts    []uint64            `json:"counts"`              // 各分桶的累积计数
+	Count     uint64              `json:"count"`               // 观测总数
+	Sum       float64             `json:"sum"`                 // 耗时总和(秒)
+	Quantiles map[float64]float64 `json:"quantiles,omitempty"` // 基于最近样本的分位数
+}
+
+// latencyHistogram 耗时直方图
+// 分桶计数用于 histogram 导出,最近样本环形缓冲用于 summary 分位数
+type latencyHistogram struct {
+	buckets []float64
+	counts  []uint64 // 非累积计数
+	count   uint64
+	sum     float64
+	samples []float64
+	next    int
+}
+
+// newLatencyHistogram 创建耗时直方图
+func newLatencyHistogram(buckets []float64) *latencyHistogram {
+	return &latencyHistogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+		samples: make([]float64, 0, summarySampleSize),
+	}
+}
+
+// observe 记录一次耗时(秒)
+func (h *latencyHistogram) observe(seconds float64) {
+	if i := sort.SearchFloat64s(h.buckets, seconds); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += seconds
+
+	if len(h.samples) < summarySampleSize {
+		h.samples = append(h.samples, seconds)
+		return
+	}
+	h.samples[h.next] = seconds
+	h.next = (h.next + 1) % summarySampleSize
+}
+
+// snapshot 生成快照,quantiles 为需要计算的分位数(0~1)
+func (h *latencyHistogram) snapshot(quantiles []float64) *HistogramSnapshot {
+	s := &HistogramSnapshot{
+		Buckets: append([]float64(nil), h.buckets...),
+		Counts:  make([]uint64, len(h.counts)),
+		Count:   h.count,
+		Sum:     h.sum,
+	}
+
+	var cumulative uint64
+	for i, c := range h.counts {
+		cumulative += c
+		s.Counts[i] = cumulative
+	}
+
+	if len(quantiles) > 0 {
+		sorted := append([]float64(nil), h.samples...)
+		sort.Float64s(sorted)
+		s.Quantiles = make(map[float64]float64, len(quantiles))
+		for _, q := range quantiles {
+			s.Quantiles[q] = quantileOf(sorted, q)
+		}
+	}
+	return s
+}
+
+// quantileOf 计算已排序样本的分位数(最近秩法),无样本时返回 NaN
+func quantileOf(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return math.NaN()
+	}
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	idx = max(0, min(idx, len(sorted)-1))
+	return sorted[idx]
+}
+
+// normalizeBuckets 排序去重并去掉 +Inf
+func normalizeBuckets(buckets []float64) []float64 {
+	result := make([]float64, 0, len(buckets))
+	for _, b := range buckets {
+		if !math.IsInf(b, 1) && !math.IsNaN(b) {
+			result = append(result, b)
+		}
+	}
+	sort.Float64s(result)
+
+	deduped := result[:0]
+	for _, b := range result {
+		if len(deduped) == 0 || b != deduped[len(deduped)-1] {
+			deduped = append(deduped, b)
+		}
+	}
+	return deduped
+}
@@ -0,0 +1,297 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-25 09:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-25 09:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\keyed_limiter.go
+ * @Description: 按 key 限流(如按用户/按IP),支持空闲过期淘汰和 key 数量上限
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+	"github.com/kamalyes/go-toolbox/pkg/syncx"
+)
+
+// KeyedLimiterConfig 按 key 限流配置
+type KeyedLimiterConfig[K comparable] struct {
+	Rate            int32                           // 每个 key 每秒令牌数
+	Capacity        int32                           // 每个 key 的桶容量,0时与 Rate 相同
+	TTL             time.Duration                   // key 空闲超过该时长后被淘汰,默认10分钟
+	MaxKeys         int                             // 最多跟踪的 key 数量,达到上限时按批淘汰最久未访问的 key,0表示不限制
+	CleanupInterval time.Duration                   // 后台清理间隔,0表示不启动后台清理(仅在访问时按需淘汰)
+	ShardCount      int                             // 分片数量,默认64
+	NewLimiter      func(key K) RateLimiter         // 自定义限流器工厂,默认使用令牌桶 Limiter
+	OnEvict         func(key K, stats LimiterStats) // key 被淘汰时回调
+}
+
+// keyedEntry 单个 key 的限流状态
+type keyedEntry struct {
+	limiter    RateLimiter
+	lastAccess int64 // 最后访问时间(纳秒)
+	allowed    int64 // 放行次数
+	rejected   int64 // 拒绝次数
+}
+
+// touch 更新最后访问时间
+func (e *keyedEntry) touch(now int64) {
+	atomic.StoreInt64(&e.lastAccess, now)
+}
+
+// record 记录一次限流结果
+func (e *keyedEntry) record(ok bool) bool {
+	if ok {
+		atomic.AddInt64(&e.allowed, 1)
+	} else {
+		atomic.AddInt64(&e.rejected, 1)
+	}
+	return ok
+}
+
+// stats 获取统计信息
+func (e *keyedEntry) stats() LimiterStats {
+	var stats LimiterStats
+	if s, ok := e.limiter.(interface{ Stats() LimiterStats }); ok {
+		stats = s.Stats()
+	}
+	stats.Allowed = atomic.LoadInt64(&e.allowed)
+	stats.Rejected = atomic.LoadInt64(&e.rejected)
+	return stats
+}
+
+// KeyedLimiter 按 key 限流器
+// 每个 key 按需创建独立的限流器,基于 syncx.ShardedMap 分片存储以降低锁竞争
+type KeyedLimiter[K comparable] struct {
+	config  KeyedLimiterConfig[K]
+	entries *syncx.ShardedMap[K, *keyedEntry]
+	now     func() time.Time
+	evictMu sync.Mutex // 保护容量淘汰,避免并发重复扫描
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// NewKeyedLimiter 创建按 key 限流器
+func NewKeyedLimiter[K comparable](config KeyedLimiterConfig[K]) *KeyedLimiter[K] {
+	config.Capacity = mathx.IF(config.Capacity <= 0, config.Rate, config.Capacity)
+	config.TTL = mathx.IF(config.TTL <= 0, 10*time.Minute, config.TTL)
+	config.ShardCount = mathx.IF(config.ShardCount <= 0, 64, config.ShardCount)
+
+	kl := &KeyedLimiter[K]{
+		config:  config,
+		entries: syncx.NewShardedMap[K, *keyedEntry](config.ShardCount),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+
+	if config.CleanupInterval > 0 {
+		go kl.cleanupLoop(config.CleanupInterval)
+	}
+	return kl
+}
+
+// get 获取 key 对应的限流状态,不存在时创建
+func (kl *KeyedLimiter[K]) get(key K) *keyedEntry {
+	now := kl.now().UnixNano()
+	if entry, ok := kl.entries.Load(key); ok {
+		entry.touch(now)
+		return entry
+	}
+
+	if kl.config.MaxKeys > 0 && kl.entries.Len() >= kl.config.MaxKeys {
+		kl.evictForCapacity()
+	}
+
+	var limiter RateLimiter
+	if kl.config.NewLimiter != nil {
+		limiter = kl.config.NewLimiter(key)
+	} else {
+		limiter = NewLimiter(kl.config.Rate, kl.config.Capacity)
+	}
+
+	entry, _ := kl.entries.LoadOrStore(key, &keyedEntry{limiter: limiter, lastAccess: now})
+	entry.touch(now)
+	return entry
+}
+
+// Allow 指定 key 是否允许单个请求
+func (kl *KeyedLimiter[K]) Allow(key K) bool {
+	entry := kl.get(key)
+	return entry.record(entry.limiter.Allow())
+}
+
+// AllowN 指定 key 是否允许N个请求
+func (kl *KeyedLimiter[K]) AllowN(key K, n int32) bool {
+	entry := kl.get(key)
+	return entry.record(entry.limiter.AllowN(n))
+}
+
+// Wait 等待直到指定 key 允许执行
+func (kl *KeyedLimiter[K]) Wait(ctx context.Context, key K) error {
+	entry := kl.get(key)
+	err := entry.limiter.Wait(ctx)
+	entry.record(err == nil)
+	return err
+}
+
+// Reserve 为指定 key 预占一个许可
+func (kl *KeyedLimiter[K]) Reserve(key K) (time.Duration, bool) {
+	entry := kl.get(key)
+	delay, ok := entry.limiter.Reserve()
+	entry.record(ok)
+	return delay, ok
+}
+
+// Limiter 获取指定 key 的限流器,不存在时创建
+func (kl *KeyedLimiter[K]) Limiter(key K) RateLimiter {
+	return kl.get(key).limiter
+}
+
+// Remove 移除指定 key
+func (kl *KeyedLimiter[K]) Remove(key K) {
+	kl.entries.Delete(key)
+}
+
+// Len 当前跟踪的 key 数量
+func (kl *KeyedLimiter[K]) Len() int {
+	return kl.entries.Len()
+}
+
+// Stats 获取指定 key 的统计信息
+func (kl *KeyedLimiter[K]) Stats(key K) (LimiterStats, bool) {
+	entry, ok := kl.entries.Load(key)
+	if !ok {
+		return LimiterStats{}, false
+	}
+	return entry.stats(), true
+}
+
+// AllStats 获取所有 key 的统计信息
+func (kl *KeyedLimiter[K]) AllStats() map[K]LimiterStats {
+	result := make(map[K]LimiterStats, kl.entries.Len())
+	kl.entries.Range(func(key K, entry *keyedEntry) bool {
+		result[key] = entry.stats()
+		return true
+	})
+	return result
+}
+
+// Cleanup 淘汰空闲超过 TTL 的 key,返回淘汰数量
+func (kl *KeyedLimiter[K]) Cleanup() int {
+	deadline := kl.now().Add(-kl.config.TTL).UnixNano()
+
+	var expired []K
+	kl.entries.Range(func(key K, entry *keyedEntry) bool {
+		if atomic.LoadInt64(&entry.lastAccess) < deadline {
+			expired = append(expired, key)
+		}
+		return true
+	})
+
+	evicted := 0
+	for _, key := range expired {
+		// 二次确认,避免淘汰刚被访问的 key
+		var removed *keyedEntry
+		kl.entries.WithShardLock(key, func(data map[K]*keyedEntry) {
+			if entry, ok := data[key]; ok && atomic.LoadInt64(&entry.lastAccess) < deadline {
+				removed = entry
+			}
+		})
+		if removed != nil {
+			kl.evict(key, removed)
+			evicted++
+		}
+	}
+	return evicted
+}
+
+// evictForCapacity key 数量达到上限时,单次扫描淘汰一批最久未访问的 key(MaxKeys 的 2%,至少1个)
+// 按批淘汰使扫描开销分摊到多次插入,过期 key 由 Cleanup 或后台清理负责
+func (kl *KeyedLimiter[K]) evictForCapacity() {
+	kl.evictMu.Lock()
+	defer kl.evictMu.Unlock()
+
+	if kl.entries.Len() < kl.config.MaxKeys {
+		return
+	}
+
+	batch := max(1, kl.config.MaxKeys/50)
+	oldest := make(lruHeap[K], 0, batch)
+	kl.entries.Range(func(key K, entry *keyedEntry) bool {
+		access := atomic.LoadInt64(&entry.lastAccess)
+		switch {
+		case len(oldest) < batch:
+			heap.Push(&oldest, lruItem[K]{key: key, entry: entry, access: access})
+		case access < oldest[0].access:
+			oldest[0] = lruItem[K]{key: key, entry: entry, access: access}
+			heap.Fix(&oldest, 0)
+		}
+		return true
+	})
+	for _, item := range oldest {
+		kl.evict(item.key, item.entry)
+	}
+}
+
+// lruItem 容量淘汰的候选 key
+type lruItem[K comparable] struct {
+	key    K
+	entry  *keyedEntry
+	access int64
+}
+
+// lruHeap 按最后访问时间排列的大顶堆,堆顶为候选中最近访问的 key
+type lruHeap[K comparable] []lruItem[K]
+
+func (h lruHeap[K]) Len() int           { return len(h) }
+func (h lruHeap[K]) Less(i, j int) bool { return h[i].access > h[j].access }
+func (h lruHeap[K]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *lruHeap[K]) Push(x any)        { *h = append(*h, x.(lruItem[K])) }
+func (h *lruHeap[K]) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// evict 淘汰 key 并触发回调
+func (kl *KeyedLimiter[K]) evict(key K, entry *keyedEntry) {
+	current, ok := kl.entries.Load(key)
+	if !ok || current != entry {
+		return
+	}
+	kl.entries.Delete(key)
+	if kl.config.OnEvict != nil {
+		kl.config.OnEvict(key, entry.stats())
+	}
+}
+
+// cleanupLoop 后台定期清理
+func (kl *KeyedLimiter[K]) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-kl.stop:
+			return
+		case <-ticker.C:
+			kl.Cleanup()
+		}
+	}
+}
+
+// Close 停止后台清理
+func (kl *KeyedLimiter[K]) Close() {
+	kl.once.Do(func() {
+		close(kl.stop)
+	})
+}
@@ -0,0 +1,199 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-25 09:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-25 09:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\keyed_limiter_test.go
+ * @Description: 按 key 限流器测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyedLimiterPerKey(t *testing.T) {
+	kl := NewKeyedLimiter(KeyedLimiterConfig[string]{Rate: 1, Capacity: 2})
+
+	assert.True(t, kl.Allow("user-a"))
+	assert.True(t, kl.Allow("user-a"))
+	assert.False(t, kl.Allow("user-a"))
+
+	// 不同 key 相互独立
+	assert.True(t, kl.AllowN("user-b", 2))
+	assert.Equal(t, 2, kl.Len())
+
+	stats, ok := kl.Stats("user-a")
+	assert.True(t, ok)
+	assert.Equal(t, int32(2), stats.Capacity)
+	assert.Equal(t, int64(2), stats.Allowed)
+	assert.Equal(t, int64(1), stats.Rejected)
+
+	_, ok = kl.Stats("missing")
+	assert.False(t, ok)
+}
+
+func TestKeyedLimiterDefaults(t *testing.T) {
+	kl := NewKeyedLimiter(KeyedLimiterConfig[int]{Rate: 5})
+
+	assert.Equal(t, int32(5), kl.config.Capacity)
+	assert.Equal(t, 10*time.Minute, kl.config.TTL)
+	assert.Equal(t, 64, kl.config.ShardCount)
+}
+
+func TestKeyedLimiterTTLEviction(t *testing.T) {
+	clock := newFakeClock()
+	var evicted []string
+	kl := NewKeyedLimiter(KeyedLimiterConfig[string]{
+		Rate: 10,
+		TTL:  time.Minute,
+		OnEvict: func(key string, stats LimiterStats) {
+			evicted = append(evicted, key)
+		},
+	})
+	kl.now = clock.Now
+
+	kl.Allow("idle")
+	clock.Advance(30 * time.Second)
+	kl.Allow("active")
+	clock.Advance(40 * time.Second)
+
+	assert.Equal(t, 1, kl.Cleanup())
+	assert.Equal(t, []string{"idle"}, evicted)
+	assert.Equal(t, 1, kl.Len())
+
+	_, ok := kl.Stats("active")
+	assert.True(t, ok)
+}
+
+func TestKeyedLimiterMaxKeys(t *testing.T) {
+	clock := newFakeClock()
+	kl := NewKeyedLimiter(KeyedLimiterConfig[string]{Rate: 10, MaxKeys: 3})
+	kl.now = clock.Now
+
+	for i := 0; i < 3; i++ {
+		kl.Allow(fmt.Sprintf("key-%d", i))
+		clock.Advance(time.Second)
+	}
+	// 访问 key-0 使其成为最近使用
+	kl.Allow("key-0")
+	clock.Advance(time.Second)
+
+	kl.Allow("key-3")
+	assert.Equal(t, 3, kl.Len())
+
+	_, ok := kl.Stats("key-1")
+	assert.False(t, ok, "least recently used key should be evicted")
+	_, ok = kl.Stats("key-0")
+	assert.True(t, ok)
+}
+
+func TestKeyedLimiterMaxKeysBatchEviction(t *testing.T) {
+	clock := newFakeClock()
+	var evicted []string
+	kl := NewKeyedLimiter(KeyedLimiterConfig[string]{
+		Rate:    10,
+		TTL:     time.Second,
+		MaxKeys: 100,
+		OnEvict: func(key string, stats LimiterStats) {
+			evicted = append(evicted, key)
+		},
+	})
+	kl.now = clock.Now
+
+	for i := 0; i < 100; i++ {
+		kl.Allow(fmt.Sprintf("key-%02d", i))
+		clock.Advance(time.Second)
+	}
+
+	// 达到上限时一次淘汰 MaxKeys/50 个最久未访问的 key,过期 key 不在插入时全量清理
+	kl.Allow("new-0")
+	assert.ElementsMatch(t, []string{"key-00", "key-01"}, evicted)
+	assert.Equal(t, 99, kl.Len())
+
+	// 本批腾出的空间可供后续插入,不再扫描
+	kl.Allow("new-1")
+	assert.Len(t, evicted, 2)
+	assert.Equal(t, 100, kl.Len())
+}
+
+func TestKeyedLimiterCustomFactory(t *testing.T) {
+	kl := NewKeyedLimiter(KeyedLimiterConfig[string]{
+		NewLimiter: func(key string) RateLimiter {
+			if key == "vip" {
+				return NewGCRALimiter(100, 100)
+			}
+			return NewSlidingLogLimiter(1, time.Minute)
+		},
+	})
+
+	assert.True(t, kl.Allow("normal"))
+	assert.False(t, kl.Allow("normal"))
+	assert.True(t, kl.AllowN("vip", 50))
+	assert.IsType(t, &GCRALimiter{}, kl.Limiter("vip"))
+
+	delay, ok := kl.Reserve("normal")
+	assert.True(t, ok)
+	assert.Greater(t, delay, time.Duration(0))
+}
+
+func TestKeyedLimiterWaitAndRemove(t *testing.T) {
+	kl := NewKeyedLimiter(KeyedLimiterConfig[string]{Rate: 100, Capacity: 1})
+
+	assert.NoError(t, kl.Wait(context.Background(), "ip"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Error(t, kl.Wait(ctx, "ip"))
+
+	stats := kl.AllStats()
+	assert.Equal(t, int64(1), stats["ip"].Allowed)
+	assert.Equal(t, int64(1), stats["ip"].Rejected)
+
+	kl.Remove("ip")
+	assert.Equal(t, 0, kl.Len())
+}
+
+func TestKeyedLimiterBackgroundCleanup(t *testing.T) {
+	kl := NewKeyedLimiter(KeyedLimiterConfig[string]{
+		Rate:            10,
+		TTL:             20 * time.Millisecond,
+		CleanupInterval: 10 * time.Millisecond,
+	})
+	defer kl.Close()
+
+	kl.Allow("temp")
+	assert.Eventually(t, func() bool {
+		return kl.Len() == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// 重复关闭不应 panic
+	kl.Close()
+}
+
+func TestKeyedLimiterConcurrent(t *testing.T) {
+	kl := NewKeyedLimiter(KeyedLimiterConfig[int]{Rate: 1000, MaxKeys: 50})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				kl.Allow(worker*100 + j)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, kl.Len(), 50+20, "key count should stay near MaxKeys")
+}
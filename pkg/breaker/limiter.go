@@ -76,6 +76,32 @@ func (l *Limiter) Wait(ctx context.Context) error {
 	}
 }
 
+// Reserve 预占一个令牌,令牌不足时允许透支,返回等待透支令牌补充所需的时长
+func (l *Limiter) Reserve() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 && atomic.LoadInt32(&l.tokens) < 1 {
+		return 0, false
+	}
+
+	now := time.Now().UnixNano()
+	l.refill(now)
+
+	tokens := atomic.AddInt32(&l.tokens, -1)
+	if tokens >= 0 {
+		return 0, true
+	}
+
+	// 透支的令牌需要从上次补充时间开始按速率补齐
+	elapsed := now - atomic.LoadInt64(&l.lastUpdate)
+	delay := int64(-tokens)*int64(time.Second)/int64(l.rate) - elapsed
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay), true
+}
+
 // refill 补充令牌
 func (l *Limiter) refill(now int64) {
 	lastUpdate := atomic.LoadInt64(&l.lastUpdate)
@@ -94,12 +120,12 @@ func (l *Limiter) refill(now int64) {
 	}
 }
 
-// GetAvailableTokens 获取可用令牌数
+// GetAvailableTokens 获取可用令牌数,Reserve 透支期间返回 0
 func (l *Limiter) GetAvailableTokens() int32 {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	l.refill(time.Now().UnixNano())
-	return atomic.LoadInt32(&l.tokens)
+	return max(atomic.LoadInt32(&l.tokens), 0)
 }
 
 // Stats 获取统计信息
@@ -115,5 +141,7 @@ func (l *Limiter) Stats() LimiterStats {
 type LimiterStats struct {
 	Rate            int32
 	Capacity        int32
-	AvailableTokens int32
+	AvailableTokens int32 // 可用令牌数,透支时为 0
+	Allowed         int64 // 放行次数(由 KeyedLimiter 统计)
+	Rejected        int64 // 拒绝次数(由 KeyedLimiter 统计)
 }
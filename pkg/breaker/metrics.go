@@ -12,7 +12,6 @@
 package breaker
 
 import (
-	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,12 +20,19 @@ import (
 // MetricsCollector 通用指标收集器
 type MetricsCollector struct {
 	// 执行统计
-	executionCount    map[string]*int64 // 执行总次数
-	successCount      map[string]*int64 // 成功次数
-	failureCount      map[string]*int64 // 失败次数
-	runningCount      map[string]*int64 // 当前运行中的数量
-	totalDuration     map[string]*int64 // 总执行时间(毫秒)
-	lastExecutionTime map[string]*int64 // 最后执行时间戳
+	executionCount    map[string]*int64             // 执行总次数
+	successCount      map[string]*int64             // 成功次数
+	failureCount      map[string]*int64             // 失败次数
+	runningCount      map[string]*int64             // 当前运行中的数量
+	totalDuration     map[string]*int64             // 总执行时间(毫秒)
+	lastExecutionTime map[string]*int64             // 最后执行时间戳
+	rejectedCount     map[string]*int64             // 被拒绝次数(熔断/舱壁)
+	rateLimitedCount  map[string]*int64             // 被限流次数
+	gauges            map[string]map[string]float64 // 自定义瞬时值(名称 -> 指标 -> 值)
+
+	// 耗时直方图(名称 -> 执行结果 -> 直方图)
+	histograms     map[string]map[string]*latencyHistogram
+	latencyBuckets []float64
 	// 全局统计
 	totalExecutions int64
 	totalSuccess    int64
@@ -141,12 +147,33 @@ func NewMetricsCollector() *MetricsCollector {
 		runningCount:      make(map[string]*int64),
 		totalDuration:     make(map[string]*int64),
 		lastExecutionTime: make(map[string]*int64),
+		rejectedCount:     make(map[string]*int64),
+		rateLimitedCount:  make(map[string]*int64),
+		gauges:            make(map[string]map[string]float64),
+		histograms:        make(map[string]map[string]*latencyHistogram),
+		latencyBuckets:    DefaultLatencyBuckets,
 		avgExecutionTime:  make(map[string]float64),
 		maxExecutionTime:  make(map[string]int64),
 		minExecutionTime:  make(map[string]int64),
 	}
 }
 
+// SetLatencyBuckets 设置耗时直方图分桶上界(秒),已记录的直方图会被清空
+func (mc *MetricsCollector) SetLatencyBuckets(buckets ...float64) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.latencyBuckets = normalizeBuckets(buckets)
+	mc.histograms = make(map[string]map[string]*latencyHistogram)
+}
+
+// LatencyBuckets 获取耗时直方图分桶上界(秒)
+func (mc *MetricsCollector) LatencyBuckets() []float64 {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return append([]float64(nil), mc.latencyBuckets...)
+}
+
 // RecordStart 记录开始执行
 func (mc *MetricsCollector) RecordStart(name string) {
 	mc.mu.Lock()
@@ -199,6 +226,7 @@ func (mc *MetricsCollector) RecordSuccess(name string, duration time.Duration) {
 
 	// 更新执行时间统计
 	mc.updateExecutionTimeStats(name, duration.Milliseconds())
+	mc.observeLatency(name, OutcomeSuccess, duration)
 }
 
 // RecordFailure 记录执行失败
@@ -222,6 +250,7 @@ func (mc *MetricsCollector) RecordFailure(name string, duration time.Duration) {
 
 	// 失败也记录执行时间
 	mc.updateExecutionTimeStats(name, duration.Milliseconds())
+	mc.observeLatency(name, OutcomeFailure, duration)
 }
 
 // RecordRateLimited 记录被限流
@@ -233,9 +262,85 @@ func (mc *MetricsCollector) RecordRateLimited(name string) {
 		var count int64 = 0
 		mc.failureCount[name] = &count
 	}
+	if mc.rateLimitedCount[name] == nil {
+		var count int64 = 0
+		mc.rateLimitedCount[name] = &count
+	}
 
 	// 被限流视为执行失败的一种
 	atomic.AddInt64(mc.failureCount[name], 1)
+	atomic.AddInt64(mc.rateLimitedCount[name], 1)
+}
+
+// GetRateLimitedCount 获取指定名称的被限流次数
+func (mc *MetricsCollector) GetRateLimitedCount(name string) int64 {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.getInt64Value(mc.rateLimitedCount[name])
+}
+
+// RecordRejected 记录被拒绝(熔断打开、半开探测已满或舱壁已满)
+func (mc *MetricsCollector) RecordRejected(name string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.rejectedCount[name] == nil {
+		var count int64 = 0
+		mc.rejectedCount[name] = &count
+	}
+
+	atomic.AddInt64(mc.rejectedCount[name], 1)
+}
+
+// GetRejectedCount 获取指定名称的被拒绝次数
+func (mc *MetricsCollector) GetRejectedCount(name string) int64 {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.getInt64Value(mc.rejectedCount[name])
+}
+
+// SetGauge 设置指定名称的瞬时值指标(如并发上限、排队数)
+func (mc *MetricsCollector) SetGauge(name, gauge string, value float64) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	if mc.gauges[name] == nil {
+		mc.gauges[name] = make(map[string]float64)
+	}
+	mc.gauges[name][gauge] = value
+}
+
+// GetGauge 获取指定名称的瞬时值指标
+func (mc *MetricsCollector) GetGauge(name, gauge string) float64 {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.gauges[name][gauge]
+}
+
+// GetHistogram 获取指定名称与执行结果的耗时直方图,quantiles 为需要计算的分位数(0~1)
+// 尚无观测数据时返回 nil
+func (mc *MetricsCollector) GetHistogram(name, outcome string, quantiles ...float64) *HistogramSnapshot {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	h := mc.histograms[name][outcome]
+	if h == nil {
+		return nil
+	}
+	return h.snapshot(quantiles)
+}
+
+// observeLatency 记录耗时到直方图(调用方需持有写锁)
+func (mc *MetricsCollector) observeLatency(name, outcome string, duration time.Duration) {
+	if mc.histograms[name] == nil {
+		mc.histograms[name] = make(map[string]*latencyHistogram)
+	}
+	h := mc.histograms[name][outcome]
+	if h == nil {
+		h = newLatencyHistogram(mc.latencyBuckets)
+		mc.histograms[name][outcome] = h
+	}
+	h.observe(duration.Seconds())
 }
 
 // updateExecutionTimeStats 更新执行时间统计
@@ -268,6 +373,11 @@ func (mc *MetricsCollector) GetMetrics(name string) *Metrics {
 	mc.mu.RLock()
 	defer mc.mu.RUnlock()
 
+	return mc.getMetrics(name)
+}
+
+// getMetrics 获取单个指标(调用方需持有锁)
+func (mc *MetricsCollector) getMetrics(name string) *Metrics {
 	return &Metrics{
 		Name:              name,
 		ExecutionCount:    mc.getInt64Value(mc.executionCount[name]),
@@ -278,10 +388,25 @@ func (mc *MetricsCollector) GetMetrics(name string) *Metrics {
 		MaxExecutionTime:  mc.maxExecutionTime[name],
 		MinExecutionTime:  mc.minExecutionTime[name],
 		LastExecutionTime: mc.getInt64Value(mc.lastExecutionTime[name]),
+		RejectedCount:     mc.getInt64Value(mc.rejectedCount[name]),
+		RateLimitedCount:  mc.getInt64Value(mc.rateLimitedCount[name]),
 		SuccessRate:       mc.calculateSuccessRate(name),
+		Gauges:            mc.copyGauges(name),
 	}
 }
 
+// copyGauges 复制指定名称的瞬时值指标(调用方需持有锁)
+func (mc *MetricsCollector) copyGauges(name string) map[string]float64 {
+	if len(mc.gauges[name]) == 0 {
+		return nil
+	}
+	gauges := make(map[string]float64, len(mc.gauges[name]))
+	for k, v := range mc.gauges[name] {
+		gauges[k] = v
+	}
+	return gauges
+}
+
 // GetAllMetrics 获取所有指标
 func (mc *MetricsCollector) GetAllMetrics() map[string]*Metrics {
 	mc.mu.RLock()
@@ -289,7 +414,22 @@ func (mc *MetricsCollector) GetAllMetrics() map[string]*Metrics {
 
 	metrics := make(map[string]*Metrics)
 	for name := range mc.executionCount {
-		metrics[name] = mc.GetMetrics(name)
+		metrics[name] = mc.getMetrics(name)
+	}
+	for name := range mc.rejectedCount {
+		if _, ok := metrics[name]; !ok {
+			metrics[name] = mc.getMetrics(name)
+		}
+	}
+	for name := range mc.rateLimitedCount {
+		if _, ok := metrics[name]; !ok {
+			metrics[name] = mc.getMetrics(name)
+		}
+	}
+	for name := range mc.gauges {
+		if _, ok := metrics[name]; !ok {
+			metrics[name] = mc.getMetrics(name)
+		}
 	}
 	return metrics
 }
@@ -344,6 +484,10 @@ func (mc *MetricsCollector) Reset() {
 	mc.runningCount = make(map[string]*int64)
 	mc.totalDuration = make(map[string]*int64)
 	mc.lastExecutionTime = make(map[string]*int64)
+	mc.rejectedCount = make(map[string]*int64)
+	mc.rateLimitedCount = make(map[string]*int64)
+	mc.gauges = make(map[string]map[string]float64)
+	mc.histograms = make(map[string]map[string]*latencyHistogram)
 	mc.avgExecutionTime = make(map[string]float64)
 	mc.maxExecutionTime = make(map[string]int64)
 	mc.minExecutionTime = make(map[string]int64)
@@ -365,7 +509,11 @@ type Metrics struct {
 	MaxExecutionTime  int64   `json:"max_execution_time_ms"`
 	MinExecutionTime  int64   `json:"min_execution_time_ms"`
 	LastExecutionTime int64   `json:"last_execution_time"`
+	RejectedCount     int64   `json:"rejected_count"`
+	RateLimitedCount  int64   `json:"rate_limited_count"`
 	SuccessRate       float64 `json:"success_rate"`
+
+	Gauges map[string]float64 `json:"gauges,omitempty"`
 }
 
 // GlobalMetrics 全局指标
@@ -392,55 +540,3 @@ func (mc *MetricsCollector) GetSnapshot() *MetricsSnapshot {
 		Timestamp:     time.Now().Unix(),
 	}
 }
-
-// PrometheusExporter Prometheus格式导出器
-type PrometheusExporter struct {
-	collector *MetricsCollector
-	namespace string
-	service   string
-}
-
-// NewPrometheusExporter 创建Prometheus导出器
-func NewPrometheusExporter(collector *MetricsCollector, namespace, service string) *PrometheusExporter {
-	return &PrometheusExporter{
-		collector: collector,
-		namespace: namespace,
-		service:   service,
-	}
-}
-
-// Export 导出Prometheus格式指标
-func (pe *PrometheusExporter) Export() string {
-	var output string
-	prefix := fmt.Sprintf("%s_%s_", pe.namespace, pe.service)
-
-	// 导出全局指标
-	global := pe.collector.GetGlobalMetrics()
-	output += fmt.Sprintf("# HELP %stotal_executions Total number of executions\n", prefix)
-	output += fmt.Sprintf("# TYPE %stotal_executions counter\n", prefix)
-	output += fmt.Sprintf("%stotal_executions %d\n", prefix, global.TotalExecutions)
-
-	output += fmt.Sprintf("# HELP %stotal_success Total number of successful executions\n", prefix)
-	output += fmt.Sprintf("# TYPE %stotal_success counter\n", prefix)
-	output += fmt.Sprintf("%stotal_success %d\n", prefix, global.TotalSuccess)
-
-	output += fmt.Sprintf("# HELP %stotal_failure Total number of failed executions\n", prefix)
-	output += fmt.Sprintf("# TYPE %stotal_failure counter\n", prefix)
-	output += fmt.Sprintf("%stotal_failure %d\n", prefix, global.TotalFailure)
-
-	output += fmt.Sprintf("# HELP %sactive_count Number of currently active executions\n", prefix)
-	output += fmt.Sprintf("# TYPE %sactive_count gauge\n", prefix)
-	output += fmt.Sprintf("%sactive_count %d\n", prefix, global.ActiveCount)
-
-	// 导出所有指标
-	allMetrics := pe.collector.GetAllMetrics()
-	for name, metrics := range allMetrics {
-		output += fmt.Sprintf("%sexecution_count{name=\"%s\"} %d\n", prefix, name, metrics.ExecutionCount)
-		output += fmt.Sprintf("%ssuccess_count{name=\"%s\"} %d\n", prefix, name, metrics.SuccessCount)
-		output += fmt.Sprintf("%sfailure_count{name=\"%s\"} %d\n", prefix, name, metrics.FailureCount)
-		output += fmt.Sprintf("%savg_execution_time_ms{name=\"%s\"} %.2f\n", prefix, name, metrics.AvgExecutionTime)
-		output += fmt.Sprintf("%ssuccess_rate{name=\"%s\"} %.2f\n", prefix, name, metrics.SuccessRate)
-	}
-
-	return output
-}
@@ -0,0 +1,452 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-26 09:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-26 09:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\prometheus.go
+ * @Description: Prometheus / OpenMetrics 文本格式导出(不依赖 Prometheus 客户端库)
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExpositionFormat 指标文本格式
+type ExpositionFormat int
+
+const (
+	FormatPrometheus  ExpositionFormat = iota // Prometheus 文本格式 0.0.4
+	FormatOpenMetrics                         // OpenMetrics 1.0.0
+)
+
+// 各格式对应的 Content-Type
+const (
+	ContentTypePrometheus  = "text/plain; version=0.0.4; charset=utf-8"
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// String 返回格式名称
+func (f ExpositionFormat) String() string {
+	switch f {
+	case FormatPrometheus:
+		return "prometheus"
+	case FormatOpenMetrics:
+		return "openmetrics"
+	default:
+		return "unknown"
+	}
+}
+
+// ContentType 返回格式对应的 Content-Type
+func (f ExpositionFormat) ContentType() string {
+	if f == FormatOpenMetrics {
+		return ContentTypeOpenMetrics
+	}
+	return ContentTypePrometheus
+}
+
+// DefaultQuantiles 默认导出的 summary 分位数
+var DefaultQuantiles = []float64{0.5, 0.9, 0.99}
+
+// 指标类型
+const (
+	metricTypeCounter   = "counter"
+	metricTypeGauge     = "gauge"
+	metricTypeHistogram = "histogram"
+	metricTypeSummary   = "summary"
+)
+
+// metricLabel 指标标签
+type metricLabel struct {
+	name  string
+	value string
+}
+
+// metricSample 指标样本,suffix 用于 histogram/summary 的 _bucket、_sum、_count
+type metricSample struct {
+	suffix string
+	labels []metricLabel
+	value  float64
+}
+
+// metricFamily 指标族
+type metricFamily struct {
+	name    string
+	help    string
+	typ     string
+	samples []metricSample
+}
+
+// add 添加样本
+func (f *metricFamily) add(suffix string, value float64, labels []metricLabel) {
+	f.samples = append(f.samples, metricSample{suffix: suffix, labels: labels, value: value})
+}
+
+// PrometheusExporter Prometheus格式导出器
+type PrometheusExporter struct {
+	collector   *MetricsCollector
+	registry    *Registry // 可选,用于导出熔断器与限流器状态
+	namespace   string
+	service     string
+	constLabels []metricLabel // 附加到所有样本的固定标签
+	quantiles   []float64
+	format      ExpositionFormat
+}
+
+// NewPrometheusExporter 创建Prometheus导出器
+func NewPrometheusExporter(collector *MetricsCollector, namespace, service string) *PrometheusExporter {
+	return &PrometheusExporter{
+		collector: collector,
+		namespace: namespace,
+		service:   service,
+		quantiles: DefaultQuantiles,
+	}
+}
+
+// WithRegistry 关联资源注册中心,导出时附带熔断状态与限流器指标
+func (pe *PrometheusExporter) WithRegistry(registry *Registry) *PrometheusExporter {
+	pe.registry = registry
+	return pe
+}
+
+// WithConstLabels 设置附加到所有样本的固定标签(如 env、instance)
+func (pe *PrometheusExporter) WithConstLabels(labels map[string]string) *PrometheusExporter {
+	pe.constLabels = pe.constLabels[:0]
+	for name, value := range labels {
+		pe.constLabels = append(pe.constLabels, metricLabel{name: name, value: value})
+	}
+	sort.Slice(pe.constLabels, func(i, j int) bool { return pe.constLabels[i].name < pe.constLabels[j].name })
+	return pe
+}
+
+// WithQuantiles 设置 summary 导出的分位数(0~1),不传则不导出 summary
+func (pe *PrometheusExporter) WithQuantiles(quantiles ...float64) *PrometheusExporter {
+	pe.quantiles = append([]float64(nil), quantiles...)
+	sort.Float64s(pe.quantiles)
+	return pe
+}
+
+// WithFormat 设置 Export 默认使用的文本格式
+func (pe *PrometheusExporter) WithFormat(format ExpositionFormat) *PrometheusExporter {
+	pe.format = format
+	return pe
+}
+
+// Export 按默认格式导出指标
+func (pe *PrometheusExporter) Export() string {
+	return pe.ExportFormat(pe.format)
+}
+
+// ExportFormat 按指定格式导出指标
+func (pe *PrometheusExporter) ExportFormat(format ExpositionFormat) string {
+	var sb strings.Builder
+	for _, family := range pe.gather() {
+		writeFamily(&sb, family, format)
+	}
+	if format == FormatOpenMetrics {
+		sb.WriteString("# EOF\n")
+	}
+	return sb.String()
+}
+
+// Handler 返回 /metrics 的 http.Handler
+// 请求头 Accept 包含 application/openmetrics-text 时输出 OpenMetrics 格式
+func (pe *PrometheusExporter) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		format := pe.format
+		if strings.Contains(r.Header.Get("Accept"), "application/openmetrics-text") {
+			format = FormatOpenMetrics
+		}
+		w.Header().Set("Content-Type", format.ContentType())
+		_, _ = io.WriteString(w, pe.ExportFormat(format))
+	})
+}
+
+// prefix 指标名前缀,由 namespace 与 service 组成
+func (pe *PrometheusExporter) prefix() string {
+	var parts []string
+	for _, part := range []string{pe.namespace, pe.service} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return strings.Join(parts, "_") + "_"
+}
+
+// labels 组装样本标签: service、固定标签、resource,再追加 extra
+func (pe *PrometheusExporter) labels(resource string, extra ...metricLabel) []metricLabel {
+	labels := make([]metricLabel, 0, 2+len(pe.constLabels)+len(extra))
+	if pe.service != "" {
+		labels = append(labels, metricLabel{"service", pe.service})
+	}
+	labels = append(labels, pe.constLabels...)
+	if resource != "" {
+		labels = append(labels, metricLabel{"resource", resource})
+	}
+	return append(labels, extra...)
+}
+
+// gather 收集所有指标族
+func (pe *PrometheusExporter) gather() []*metricFamily {
+	prefix := pe.prefix()
+	families := pe.gatherGlobal(prefix)
+	families = append(families, pe.gatherResources(prefix)...)
+	if pe.registry != nil {
+		families = append(families, pe.gatherRegistry(prefix)...)
+	}
+	return families
+}
+
+// gatherGlobal 收集全局指标
+func (pe *PrometheusExporter) gatherGlobal(prefix string) []*metricFamily {
+	global := pe.collector.GetGlobalMetrics()
+	base := pe.labels("")
+
+	items := []struct {
+		name, help, typ string
+		value           int64
+	}{
+		{"total_executions", "Total number of executions", metricTypeCounter, global.TotalExecutions},
+		{"total_success", "Total number of successful executions", metricTypeCounter, global.TotalSuccess},
+		{"total_failure", "Total number of failed executions", metricTypeCounter, global.TotalFailure},
+		{"active_count", "Number of currently active executions", metricTypeGauge, global.ActiveCount},
+	}
+
+	families := make([]*metricFamily, 0, len(items))
+	for _, item := range items {
+		family := &metricFamily{name: prefix + item.name, help: item.help, typ: item.typ}
+		family.add("", float64(item.value), base)
+		families = append(families, family)
+	}
+	return families
+}
+
+// gatherResources 收集各资源的计数、耗时分布与自定义瞬时值
+func (pe *PrometheusExporter) gatherResources(prefix string) []*metricFamily {
+	allMetrics := pe.collector.GetAllMetrics()
+	names := make([]string, 0, len(allMetrics))
+	gaugeSet := make(map[string]struct{})
+	for name, metrics := range allMetrics {
+		names = append(names, name)
+		for gauge := range metrics.Gauges {
+			gaugeSet[gauge] = struct{}{}
+		}
+	}
+	sort.Strings(names)
+
+	requests := &metricFamily{name: prefix + "requests_total", help: "Total number of requests by outcome", typ: metricTypeCounter}
+	execution := &metricFamily{name: prefix + "execution_count", help: "Number of executions", typ: metricTypeCounter}
+	success := &metricFamily{name: prefix + "success_count", help: "Number of successful executions", typ: metricTypeCounter}
+	failure := &metricFamily{name: prefix + "failure_count", help: "Number of failed executions, including rate limited ones", typ: metricTypeCounter}
+	rejected := &metricFamily{name: prefix + "rejected_count", help: "Number of calls rejected by circuit breaker or bulkhead", typ: metricTypeCounter}
+	running := &metricFamily{name: prefix + "running_count", help: "Number of currently running executions", typ: metricTypeGauge}
+	avgTime := &metricFamily{name: prefix + "avg_execution_time_ms", help: "Average execution time in milliseconds", typ: metricTypeGauge}
+	successRate := &metricFamily{name: prefix + "success_rate", help: "Success rate in percent", typ: metricTypeGauge}
+	histogram := &metricFamily{name: prefix + "request_duration_seconds", help: "Request latency distribution in seconds", typ: metricTypeHistogram}
+	summary := &metricFamily{name: prefix + "request_latency_seconds", help: "Request latency quantiles over recent samples in seconds", typ: metricTypeSummary}
+
+	for _, name := range names {
+		metrics := allMetrics[name]
+		labels := pe.labels(name)
+
+		outcomes := []struct {
+			outcome string
+			value   int64
+		}{
+			{OutcomeSuccess, metrics.SuccessCount},
+			{OutcomeFailure, metrics.FailureCount - metrics.RateLimitedCount},
+			{OutcomeRejected, metrics.RejectedCount},
+			{OutcomeRateLimited, metrics.RateLimitedCount},
+		}
+		for _, o := range outcomes {
+			requests.add("", float64(o.value), pe.labels(name, metricLabel{"outcome", o.outcome}))
+		}
+
+		execution.add("", float64(metrics.ExecutionCount), labels)
+		success.add("", float64(metrics.SuccessCount), labels)
+		failure.add("", float64(metrics.FailureCount), labels)
+		rejected.add("", float64(metrics.RejectedCount), labels)
+		running.add("", float64(metrics.RunningCount), labels)
+		avgTime.add("", metrics.AvgExecutionTime, labels)
+		successRate.add("", metrics.SuccessRate, labels)
+
+		for _, outcome := range []string{OutcomeSuccess, OutcomeFailure} {
+			snapshot := pe.collector.GetHistogram(name, outcome, pe.quantiles...)
+			if snapshot == nil {
+				continue
+			}
+			outcomeLabels := pe.labels(name, metricLabel{"outcome", outcome})
+			addHistogram(histogram, snapshot, outcomeLabels)
+			if len(pe.quantiles) > 0 {
+				addSummary(summary, snapshot, pe.quantiles, outcomeLabels)
+			}
+		}
+	}
+
+	families := []*metricFamily{requests, execution, success, failure, rejected, running, avgTime, successRate, histogram}
+	if len(pe.quantiles) > 0 {
+		families = append(families, summary)
+	}
+
+	gauges := make([]string, 0, len(gaugeSet))
+	for gauge := range gaugeSet {
+		gauges = append(gauges, gauge)
+	}
+	sort.Strings(gauges)
+	for _, gauge := range gauges {
+		family := &metricFamily{name: prefix + gauge, help: "Gauge " + gauge + " reported by the component", typ: metricTypeGauge}
+		for _, name := range names {
+			if value, ok := allMetrics[name].Gauges[gauge]; ok {
+				family.add("", value, pe.labels(name))
+			}
+		}
+		families = append(families, family)
+	}
+	return families
+}
+
+// addHistogram 将直方图快照转换为 _bucket/_sum/_count 样本
+func addHistogram(family *metricFamily, snapshot *HistogramSnapshot, labels []metricLabel) {
+	for i, bound := range snapshot.Buckets {
+		family.add("_bucket", float64(snapshot.Counts[i]), withLabel(labels, "le", formatValue(bound)))
+	}
+	family.add("_bucket", float64(snapshot.Count), withLabel(labels, "le", "+Inf"))
+	family.add("_sum", snapshot.Sum, labels)
+	family.add("_count", float64(snapshot.Count), labels)
+}
+
+// addSummary 将分位数转换为 summary 样本
+func addSummary(family *metricFamily, snapshot *HistogramSnapshot, quantiles []float64, labels []metricLabel) {
+	for _, q := range quantiles {
+		family.add("", snapshot.Quantiles[q], withLabel(labels, "quantile", formatValue(q)))
+	}
+	family.add("_sum", snapshot.Sum, labels)
+	family.add("_count", float64(snapshot.Count), labels)
+}
+
+// withLabel 复制标签并追加一个标签,避免共享底层数组
+func withLabel(labels []metricLabel, name, value string) []metricLabel {
+	result := make([]metricLabel, len(labels), len(labels)+1)
+	copy(result, labels)
+	return append(result, metricLabel{name, value})
+}
+
+// gatherRegistry 收集注册中心中熔断器与限流器的状态
+func (pe *PrometheusExporter) gatherRegistry(prefix string) []*metricFamily {
+	snapshot := pe.registry.Snapshot()
+	names := make([]string, 0, len(snapshot.Circuits))
+	for name := range snapshot.Circuits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	state := &metricFamily{name: prefix + "circuit_state", help: "Circuit breaker state (1 for the current state)", typ: metricTypeGauge}
+	failures := &metricFamily{name: prefix + "circuit_failures", help: "Consecutive failures recorded by the circuit breaker", typ: metricTypeGauge}
+	failureRate := &metricFamily{name: prefix + "circuit_failure_rate", help: "Failure rate within the sliding window (percent)", typ: metricTypeGauge}
+	tokens := &metricFamily{name: prefix + "limiter_available_tokens", help: "Available tokens of the rate limiter", typ: metricTypeGauge}
+
+	states := []State{StateClosed, StateOpen, StateHalfOpen}
+	for _, name := range names {
+		stats := snapshot.Circuits[name]
+		for _, s := range states {
+			value := 0.0
+			if s.String() == stats.State {
+				value = 1
+			}
+			state.add("", value, pe.labels(name, metricLabel{"state", s.String()}))
+		}
+		failures.add("", float64(stats.Failures), pe.labels(name))
+		failureRate.add("", stats.FailureRate, pe.labels(name))
+
+		if limiter, ok := snapshot.Limiters[name]; ok {
+			tokens.add("", float64(limiter.AvailableTokens), pe.labels(name))
+		}
+	}
+
+	families := []*metricFamily{state, failures, failureRate}
+	if len(tokens.samples) > 0 {
+		families = append(families, tokens)
+	}
+	return families
+}
+
+// writeFamily 按格式写出指标族
+// OpenMetrics 中 counter 的族名不带 _total 后缀,样本名必须带 _total 后缀
+func writeFamily(sb *strings.Builder, family *metricFamily, format ExpositionFormat) {
+	name := family.name
+	sampleName := func(suffix string) string { return name + suffix }
+	if format == FormatOpenMetrics && family.typ == metricTypeCounter {
+		name = strings.TrimSuffix(name, "_total")
+		sampleName = func(suffix string) string { return name + "_total" }
+	}
+
+	sb.WriteString("# HELP " + name + " " + escapeHelp(family.help) + "\n")
+	sb.WriteString("# TYPE " + name + " " + family.typ + "\n")
+	for _, sample := range family.samples {
+		sb.WriteString(sampleName(sample.suffix))
+		writeLabels(sb, sample.labels)
+		sb.WriteString(" " + formatValue(sample.value) + "\n")
+	}
+}
+
+// writeLabels 写出标签集
+func writeLabels(sb *strings.Builder, labels []metricLabel) {
+	if len(labels) == 0 {
+		return
+	}
+	sb.WriteByte('{')
+	for i, label := range labels {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(label.name + `="` + escapeLabelValue(label.value) + `"`)
+	}
+	sb.WriteByte('}')
+}
+
+// formatValue 格式化样本值
+func formatValue(v float64) string {
+	switch {
+	case math.IsNaN(v):
+		return "NaN"
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// escapeHelp 转义 HELP 文本
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+// escapeLabelValue 转义标签值
+func escapeLabelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
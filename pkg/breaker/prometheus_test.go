@@ -0,0 +1,158 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-26 09:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-26 09:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\prometheus_test.go
+ * @Description: Prometheus / OpenMetrics 导出测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricsCollectorHistogram(t *testing.T) {
+	mc := NewMetricsCollector()
+	mc.SetLatencyBuckets(0.1, 0.01, math.Inf(1), 0.1)
+	assert.Equal(t, []float64{0.01, 0.1}, mc.LatencyBuckets())
+
+	assert.Nil(t, mc.GetHistogram("api", OutcomeSuccess))
+
+	for _, d := range []time.Duration{5 * time.Millisecond, 50 * time.Millisecond, 500 * time.Millisecond} {
+		mc.RecordStart("api")
+		mc.RecordSuccess("api", d)
+	}
+
+	h := mc.GetHistogram("api", OutcomeSuccess, 0.5, 1)
+	assert.Equal(t, []uint64{1, 2}, h.Counts)
+	assert.Equal(t, uint64(3), h.Count)
+	assert.InDelta(t, 0.555, h.Sum, 1e-9)
+	assert.Equal(t, 0.05, h.Quantiles[0.5])
+	assert.Equal(t, 0.5, h.Quantiles[1])
+	assert.Nil(t, mc.GetHistogram("api", OutcomeFailure))
+}
+
+func TestQuantileOf(t *testing.T) {
+	assert.True(t, math.IsNaN(quantileOf(nil, 0.5)))
+
+	sorted := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, 1.0, quantileOf(sorted, 0))
+	assert.Equal(t, 5.0, quantileOf(sorted, 0.5))
+	assert.Equal(t, 9.0, quantileOf(sorted, 0.9))
+	assert.Equal(t, 10.0, quantileOf(sorted, 0.99))
+}
+
+func TestPrometheusExporterHistogramAndSummary(t *testing.T) {
+	mc := NewMetricsCollector()
+	mc.SetLatencyBuckets(0.01, 0.1)
+	mc.RecordStart("db")
+	mc.RecordSuccess("db", 20*time.Millisecond)
+	mc.RecordStart("db")
+	mc.RecordFailure("db", 200*time.Millisecond)
+	mc.RecordRateLimited("db")
+	mc.RecordRejected("db")
+
+	output := NewPrometheusExporter(mc, "app", "svc").
+		WithConstLabels(map[string]string{"env": "prod"}).
+		WithQuantiles(0.5).
+		Export()
+
+	labels := `service="svc",env="prod",resource="db"`
+	assert.Contains(t, output, "# HELP app_svc_request_duration_seconds Request latency distribution in seconds\n")
+	assert.Contains(t, output, "# TYPE app_svc_request_duration_seconds histogram\n")
+	assert.Contains(t, output, `app_svc_request_duration_seconds_bucket{`+labels+`,outcome="success",le="0.01"} 0`)
+	assert.Contains(t, output, `app_svc_request_duration_seconds_bucket{`+labels+`,outcome="success",le="0.1"} 1`)
+	assert.Contains(t, output, `app_svc_request_duration_seconds_bucket{`+labels+`,outcome="failure",le="+Inf"} 1`)
+	assert.Contains(t, output, `app_svc_request_duration_seconds_count{`+labels+`,outcome="failure"} 1`)
+	assert.Contains(t, output, `app_svc_request_duration_seconds_sum{`+labels+`,outcome="success"} 0.02`)
+
+	assert.Contains(t, output, "# TYPE app_svc_request_latency_seconds summary\n")
+	assert.Contains(t, output, `app_svc_request_latency_seconds{`+labels+`,outcome="failure",quantile="0.5"} 0.2`)
+
+	assert.Contains(t, output, "# TYPE app_svc_requests_total counter\n")
+	assert.Contains(t, output, `app_svc_requests_total{`+labels+`,outcome="success"} 1`)
+	assert.Contains(t, output, `app_svc_requests_total{`+labels+`,outcome="failure"} 1`)
+	assert.Contains(t, output, `app_svc_requests_total{`+labels+`,outcome="rate_limited"} 1`)
+	assert.Contains(t, output, `app_svc_requests_total{`+labels+`,outcome="rejected"} 1`)
+	assert.Contains(t, output, `app_svc_total_executions{service="svc",env="prod"} 2`)
+	assert.NotContains(t, output, "# EOF")
+}
+
+func TestPrometheusExporterWithoutQuantiles(t *testing.T) {
+	mc := NewMetricsCollector()
+	mc.RecordStart("db")
+	mc.RecordSuccess("db", time.Millisecond)
+
+	output := NewPrometheusExporter(mc, "", "").WithQuantiles().Export()
+
+	assert.NotContains(t, output, "request_latency_seconds")
+	assert.Contains(t, output, `request_duration_seconds_count{resource="db",outcome="success"} 1`)
+	assert.Contains(t, output, "# TYPE total_executions counter\n")
+}
+
+func TestPrometheusExporterOpenMetrics(t *testing.T) {
+	mc := NewMetricsCollector()
+	mc.RecordStart("db")
+	mc.RecordSuccess("db", time.Millisecond)
+
+	output := NewPrometheusExporter(mc, "app", "svc").WithFormat(FormatOpenMetrics).Export()
+
+	assert.True(t, strings.HasSuffix(output, "# EOF\n"))
+	assert.Contains(t, output, "# TYPE app_svc_requests counter\n")
+	assert.Contains(t, output, `app_svc_requests_total{service="svc",resource="db",outcome="success"} 1`)
+	assert.Contains(t, output, "# TYPE app_svc_total_executions counter\n")
+	assert.Contains(t, output, `app_svc_total_executions_total{service="svc"} 1`)
+	assert.Contains(t, output, `app_svc_execution_count_total{service="svc",resource="db"} 1`)
+	assert.Contains(t, output, "# TYPE app_svc_active_count gauge\n")
+}
+
+func TestPrometheusExporterEscaping(t *testing.T) {
+	mc := NewMetricsCollector()
+	mc.SetGauge("a\"b\\c\nd", "queue", 1)
+
+	output := NewPrometheusExporter(mc, "app", "svc").Export()
+
+	assert.Contains(t, output, `app_svc_queue{service="svc",resource="a\"b\\c\nd"} 1`)
+}
+
+func TestPrometheusExporterHandler(t *testing.T) {
+	mc := NewMetricsCollector()
+	mc.RecordStart("db")
+	mc.RecordSuccess("db", time.Millisecond)
+	handler := NewPrometheusExporter(mc, "app", "svc").Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, ContentTypePrometheus, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "app_svc_requests_total")
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0,text/plain;q=0.5")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, ContentTypeOpenMetrics, rec.Header().Get("Content-Type"))
+	assert.True(t, strings.HasSuffix(rec.Body.String(), "# EOF\n"))
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestExpositionFormatString(t *testing.T) {
+	assert.Equal(t, "prometheus", FormatPrometheus.String())
+	assert.Equal(t, "openmetrics", FormatOpenMetrics.String())
+	assert.Equal(t, "unknown", ExpositionFormat(9).String())
+	assert.Equal(t, ContentTypePrometheus, FormatPrometheus.ContentType())
+}
@@ -0,0 +1,284 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-24 16:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-24 16:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\ratelimiter.go
+ * @Description: 限流器通用接口及滑动窗口日志/滑动窗口计数限流算法
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter 限流器通用接口
+type RateLimiter interface {
+	// Allow 是否允许单个请求
+	Allow() bool
+	// AllowN 是否允许N个请求
+	AllowN(n int32) bool
+	// Wait 阻塞等待直到允许执行或 ctx 结束
+	Wait(ctx context.Context) error
+	// Reserve 预占一个许可,返回调用方需要等待的时长
+	// ok 为 false 表示无法预占(如排队已满),此时不消耗许可
+	Reserve() (delay time.Duration, ok bool)
+}
+
+var (
+	_ RateLimiter = (*Limiter)(nil)
+	_ RateLimiter = (*SlidingLogLimiter)(nil)
+	_ RateLimiter = (*SlidingWindowLimiter)(nil)
+	_ RateLimiter = (*GCRALimiter)(nil)
+	_ RateLimiter = (*LeakyBucketLimiter)(nil)
+)
+
+// waitReserve 基于 Reserve 实现的等待
+// 注意: 预占成功后即使 ctx 提前结束,已预占的许可也不会归还
+func waitReserve(ctx context.Context, reserve func() (time.Duration, bool)) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	delay, ok := reserve()
+	if !ok {
+		return ErrRateLimitExceeded
+	}
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SlidingLogLimiter 滑动窗口日志限流器
+// 记录窗口内每个请求的时间戳,精确但内存占用与 limit 成正比
+type SlidingLogLimiter struct {
+	limit  int32
+	window time.Duration
+	log    []int64 // 按时间升序的请求时间戳(纳秒),可能包含预占的未来时间
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+// NewSlidingLogLimiter 创建滑动窗口日志限流器,window 内最多允许 limit 个请求
+func NewSlidingLogLimiter(limit int32, window time.Duration) *SlidingLogLimiter {
+	return &SlidingLogLimiter{
+		limit:  limit,
+		window: window,
+		log:    make([]int64, 0, limit),
+		now:    time.Now,
+	}
+}
+
+// prune 清理窗口外的时间戳(调用方需持有锁)
+func (l *SlidingLogLimiter) prune(now int64) {
+	boundary := now - int64(l.window)
+	i := 0
+	for i < len(l.log) && l.log[i] <= boundary {
+		i++
+	}
+	if i > 0 {
+		l.log = append(l.log[:0], l.log[i:]...)
+	}
+}
+
+// AllowN 是否允许N个请求
+func (l *SlidingLogLimiter) AllowN(n int32) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now().UnixNano()
+	l.prune(now)
+
+	if int32(len(l.log))+n > l.limit {
+		return false
+	}
+	for i := int32(0); i < n; i++ {
+		l.log = append(l.log, now)
+	}
+	return true
+}
+
+// Allow 是否允许单个请求
+func (l *SlidingLogLimiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// Reserve 预占一个许可
+func (l *SlidingLogLimiter) Reserve() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit <= 0 {
+		return 0, false
+	}
+
+	now := l.now().UnixNano()
+	l.prune(now)
+
+	at := now
+	if over := len(l.log) - int(l.limit); over >= 0 {
+		// 等待第 over 个请求滑出窗口
+		at = l.log[over] + int64(l.window)
+	}
+	l.log = append(l.log, at)
+	return time.Duration(at - now), true
+}
+
+// Wait 等待直到可以执行
+func (l *SlidingLogLimiter) Wait(ctx context.Context) error {
+	return waitReserve(ctx, l.Reserve)
+}
+
+// Stats 获取统计信息
+func (l *SlidingLogLimiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now().UnixNano()
+	l.prune(now)
+	available := l.limit - int32(len(l.log))
+	if available < 0 {
+		available = 0
+	}
+	return LimiterStats{
+		Rate:            l.limit,
+		Capacity:        l.limit,
+		AvailableTokens: available,
+	}
+}
+
+// SlidingWindowLimiter 滑动窗口计数限流器
+// 仅保存相邻窗口的计数,按上一窗口剩余占比加权估算当前请求数
+type SlidingWindowLimiter struct {
+	limit  int32
+	window time.Duration
+	counts map[int64]int64 // 窗口序号 -> 请求数(包含预占到未来窗口的请求)
+	now    func() time.Time
+	mu     sync.Mutex
+}
+
+// NewSlidingWindowLimiter 创建滑动窗口计数限流器,window 内最多允许约 limit 个请求
+func NewSlidingWindowLimiter(limit int32, window time.Duration) *SlidingWindowLimiter {
+	return &SlidingWindowLimiter{
+		limit:  limit,
+		window: window,
+		counts: make(map[int64]int64),
+		now:    time.Now,
+	}
+}
+
+// estimate 估算 at 时刻滑动窗口内的请求数(调用方需持有锁)
+func (l *SlidingWindowLimiter) estimate(at int64) float64 {
+	w := int64(l.window)
+	idx := at / w
+	elapsed := float64(at-idx*w) / float64(w)
+	return float64(l.counts[idx-1])*(1-elapsed) + float64(l.counts[idx])
+}
+
+// cleanup 清理过期窗口(调用方需持有锁)
+func (l *SlidingWindowLimiter) cleanup(now int64) {
+	current := now / int64(l.window)
+	for idx := range l.counts {
+		if idx < current-1 {
+			delete(l.counts, idx)
+		}
+	}
+}
+
+// AllowN 是否允许N个请求
+func (l *SlidingWindowLimiter) AllowN(n int32) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now().UnixNano()
+	l.cleanup(now)
+
+	if l.estimate(now)+float64(n) > float64(l.limit) {
+		return false
+	}
+	l.counts[now/int64(l.window)] += int64(n)
+	return true
+}
+
+// Allow 是否允许单个请求
+func (l *SlidingWindowLimiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// Reserve 预占一个许可
+func (l *SlidingWindowLimiter) Reserve() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit <= 0 {
+		return 0, false
+	}
+
+	now := l.now().UnixNano()
+	l.cleanup(now)
+
+	w := int64(l.window)
+	limit := float64(l.limit)
+	at := now
+	for {
+		if l.estimate(at)+1 <= limit {
+			break
+		}
+		idx := at / w
+		prev, curr := float64(l.counts[idx-1]), float64(l.counts[idx])
+		// 当前窗口内随上一窗口权重衰减,求估算值降到 limit-1 的最早时刻
+		if curr+1 <= limit && prev > 0 {
+			fraction := 1 - (limit-curr-1)/prev
+			candidate := idx*w + int64(fraction*float64(w)) + 1
+			if candidate <= at {
+				candidate = at + 1
+			}
+			if candidate < (idx+1)*w {
+				at = candidate
+				continue
+			}
+		}
+		at = (idx + 1) * w
+	}
+
+	l.counts[at/w]++
+	return time.Duration(at - now), true
+}
+
+// Wait 等待直到可以执行
+func (l *SlidingWindowLimiter) Wait(ctx context.Context) error {
+	return waitReserve(ctx, l.Reserve)
+}
+
+// Stats 获取统计信息
+func (l *SlidingWindowLimiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now().UnixNano()
+	l.cleanup(now)
+	available := l.limit - int32(l.estimate(now)+0.5)
+	if available < 0 {
+		available = 0
+	}
+	return LimiterStats{
+		Rate:            l.limit,
+		Capacity:        l.limit,
+		AvailableTokens: available,
+	}
+}
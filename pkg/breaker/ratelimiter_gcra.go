@@ -0,0 +1,206 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-24 16:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-24 16:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\ratelimiter_gcra.go
+ * @Description: GCRA(通用信元速率算法)与漏桶(平滑排队)限流算法
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// GCRALimiter GCRA限流器
+// 只需保存理论到达时间(TAT),效果等价于令牌桶,允许 burst 个请求的突发
+type GCRALimiter struct {
+	rate     int32         // 每秒请求数
+	burst    int32         // 突发容量
+	interval time.Duration // 请求发射间隔(1s/rate)
+	tat      int64         // 理论到达时间(纳秒)
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+// NewGCRALimiter 创建GCRA限流器
+func NewGCRALimiter(rate, burst int32) *GCRALimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Second / time.Duration(rate)
+	}
+	return &GCRALimiter{
+		rate:     rate,
+		burst:    burst,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// AllowN 是否允许N个请求
+func (l *GCRALimiter) AllowN(n int32) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 || n > l.burst {
+		return n <= 0
+	}
+
+	now := l.now().UnixNano()
+	tat := max(l.tat, now)
+	newTat := tat + int64(n)*int64(l.interval)
+	if newTat-now > int64(l.burst)*int64(l.interval) {
+		return false
+	}
+	l.tat = newTat
+	return true
+}
+
+// Allow 是否允许单个请求
+func (l *GCRALimiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// Reserve 预占一个许可
+func (l *GCRALimiter) Reserve() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return 0, false
+	}
+
+	now := l.now().UnixNano()
+	l.tat = max(l.tat, now) + int64(l.interval)
+	delay := l.tat - now - int64(l.burst)*int64(l.interval)
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay), true
+}
+
+// Wait 等待直到可以执行
+func (l *GCRALimiter) Wait(ctx context.Context) error {
+	return waitReserve(ctx, l.Reserve)
+}
+
+// Stats 获取统计信息
+func (l *GCRALimiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	available := l.burst
+	if l.interval > 0 {
+		if backlog := l.tat - l.now().UnixNano(); backlog > 0 {
+			available -= int32((backlog + int64(l.interval) - 1) / int64(l.interval))
+		}
+	}
+	if available < 0 {
+		available = 0
+	}
+	return LimiterStats{
+		Rate:            l.rate,
+		Capacity:        l.burst,
+		AvailableTokens: available,
+	}
+}
+
+// LeakyBucketLimiter 漏桶限流器
+// 请求按固定间隔依次放行,超出的请求在容量为 queueSize 的队列中排队等待,用于平滑流量
+type LeakyBucketLimiter struct {
+	rate      int32         // 每秒放行数
+	queueSize int32         // 最大排队数
+	interval  time.Duration // 放行间隔(1s/rate)
+	next      int64         // 下一个请求可放行的时间(纳秒)
+	now       func() time.Time
+	mu        sync.Mutex
+}
+
+// NewLeakyBucketLimiter 创建漏桶限流器
+func NewLeakyBucketLimiter(rate, queueSize int32) *LeakyBucketLimiter {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Second / time.Duration(rate)
+	}
+	return &LeakyBucketLimiter{
+		rate:      rate,
+		queueSize: queueSize,
+		interval:  interval,
+		now:       time.Now,
+	}
+}
+
+// AllowN 是否允许N个请求立即执行,N个请求占用N个放行间隔
+func (l *LeakyBucketLimiter) AllowN(n int32) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return n <= 0
+	}
+
+	now := l.now().UnixNano()
+	if l.next > now {
+		return false
+	}
+	l.next = now + int64(n)*int64(l.interval)
+	return true
+}
+
+// Allow 是否允许单个请求立即执行
+func (l *LeakyBucketLimiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// Reserve 加入排队,返回需要等待的时长,队列已满时返回 false
+func (l *LeakyBucketLimiter) Reserve() (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate <= 0 {
+		return 0, false
+	}
+
+	now := l.now().UnixNano()
+	at := max(l.next, now)
+	delay := at - now
+	if delay > int64(l.queueSize)*int64(l.interval) {
+		return 0, false
+	}
+	l.next = at + int64(l.interval)
+	return time.Duration(delay), true
+}
+
+// Wait 排队等待直到放行,队列已满时返回 ErrRateLimitExceeded
+func (l *LeakyBucketLimiter) Wait(ctx context.Context) error {
+	return waitReserve(ctx, l.Reserve)
+}
+
+// Stats 获取统计信息,AvailableTokens 为剩余可排队数
+func (l *LeakyBucketLimiter) Stats() LimiterStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	available := l.queueSize
+	if l.interval > 0 {
+		if backlog := l.next - l.now().UnixNano(); backlog > 0 {
+			available -= int32(backlog / int64(l.interval))
+		}
+	}
+	if available < 0 {
+		available = 0
+	}
+	return LimiterStats{
+		Rate:            l.rate,
+		Capacity:        l.queueSize,
+		AvailableTokens: available,
+	}
+}
@@ -0,0 +1,233 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-24 16:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-24 16:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\ratelimiter_test.go
+ * @Description: 多种限流算法测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock 可控时钟
+type fakeClock struct {
+	t time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{t: time.Unix(1700000000, 0)}
+}
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestRateLimiterInterface(t *testing.T) {
+	limiters := map[string]RateLimiter{
+		"token-bucket":   NewLimiter(10, 2),
+		"sliding-log":    NewSlidingLogLimiter(2, time.Second),
+		"sliding-window": NewSlidingWindowLimiter(2, time.Second),
+		"gcra":           NewGCRALimiter(10, 2),
+		"leaky-bucket":   NewLeakyBucketLimiter(10, 5),
+	}
+
+	for name, limiter := range limiters {
+		t.Run(name, func(t *testing.T) {
+			assert.True(t, limiter.Allow())
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			assert.NoError(t, limiter.Wait(ctx))
+		})
+	}
+}
+
+func TestLimiterReserve(t *testing.T) {
+	limiter := NewLimiter(10, 1)
+
+	delay, ok := limiter.Reserve()
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), delay)
+
+	// 透支一个令牌,需要等待约100ms
+	delay, ok = limiter.Reserve()
+	assert.True(t, ok)
+	assert.InDelta(t, float64(100*time.Millisecond), float64(delay), float64(10*time.Millisecond))
+	assert.False(t, limiter.Allow())
+	assert.Equal(t, int32(0), limiter.GetAvailableTokens()) // 透支时不返回负数
+	assert.Equal(t, int32(0), limiter.Stats().AvailableTokens)
+
+	// 速率为0时无法预占
+	zero := NewLimiter(0, 0)
+	_, ok = zero.Reserve()
+	assert.False(t, ok)
+}
+
+func TestSlidingLogLimiter(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewSlidingLogLimiter(3, time.Second)
+	limiter.now = clock.Now
+
+	assert.True(t, limiter.AllowN(2))
+	clock.Advance(500 * time.Millisecond)
+	assert.True(t, limiter.Allow())
+	assert.False(t, limiter.Allow())
+	assert.False(t, limiter.AllowN(4))
+
+	// 前两个请求滑出窗口
+	clock.Advance(500 * time.Millisecond)
+	assert.Equal(t, int32(2), limiter.Stats().AvailableTokens)
+	assert.True(t, limiter.AllowN(2))
+	assert.False(t, limiter.Allow())
+}
+
+func TestSlidingLogLimiterReserve(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewSlidingLogLimiter(2, time.Second)
+	limiter.now = clock.Now
+
+	delay, ok := limiter.Reserve()
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), delay)
+
+	clock.Advance(200 * time.Millisecond)
+	delay, _ = limiter.Reserve()
+	assert.Equal(t, time.Duration(0), delay)
+
+	// 窗口已满,需等待第一个请求滑出
+	delay, _ = limiter.Reserve()
+	assert.Equal(t, 800*time.Millisecond, delay)
+	delay, _ = limiter.Reserve()
+	assert.Equal(t, time.Second, delay)
+}
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewSlidingWindowLimiter(10, time.Second)
+	limiter.now = clock.Now
+
+	assert.True(t, limiter.AllowN(10))
+	assert.False(t, limiter.Allow())
+
+	// 进入下一窗口的25%位置,上一窗口权重为75%,估算7.5个请求
+	clock.Advance(time.Second + 250*time.Millisecond)
+	assert.True(t, limiter.AllowN(2))
+	assert.False(t, limiter.AllowN(1))
+
+	// 下一窗口的中间位置,估算 2*0.5 + 0 = 1
+	clock.Advance(time.Second + 250*time.Millisecond)
+	assert.True(t, limiter.AllowN(9))
+	assert.False(t, limiter.Allow())
+}
+
+func TestSlidingWindowLimiterReserve(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewSlidingWindowLimiter(4, time.Second)
+	limiter.now = clock.Now
+
+	assert.True(t, limiter.AllowN(4))
+
+	// 当前窗口已满,需要等到下一窗口且上一窗口权重降到75%
+	delay, ok := limiter.Reserve()
+	assert.True(t, ok)
+	elapsed := clock.Now().UnixNano() % int64(time.Second)
+	expected := time.Duration(int64(time.Second) - elapsed + int64(250*time.Millisecond))
+	assert.InDelta(t, float64(expected), float64(delay), float64(time.Millisecond))
+
+	clock.Advance(delay)
+	assert.False(t, limiter.Allow(), "reserved permit should be counted")
+}
+
+func TestGCRALimiter(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewGCRALimiter(10, 3)
+	limiter.now = clock.Now
+
+	// 允许 burst 个突发请求
+	assert.True(t, limiter.AllowN(3))
+	assert.False(t, limiter.Allow())
+	assert.Equal(t, int32(0), limiter.Stats().AvailableTokens)
+
+	// 100ms 恢复一个
+	clock.Advance(100 * time.Millisecond)
+	assert.True(t, limiter.Allow())
+	assert.False(t, limiter.Allow())
+
+	// 超过 burst 的 N 永远不允许
+	clock.Advance(time.Minute)
+	assert.False(t, limiter.AllowN(4))
+	assert.Equal(t, int32(3), limiter.Stats().AvailableTokens)
+}
+
+func TestGCRALimiterReserve(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewGCRALimiter(10, 1)
+	limiter.now = clock.Now
+
+	delay, ok := limiter.Reserve()
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), delay)
+
+	delay, _ = limiter.Reserve()
+	assert.Equal(t, 100*time.Millisecond, delay)
+	delay, _ = limiter.Reserve()
+	assert.Equal(t, 200*time.Millisecond, delay)
+
+	_, ok = NewGCRALimiter(0, 1).Reserve()
+	assert.False(t, ok)
+}
+
+func TestLeakyBucketLimiter(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewLeakyBucketLimiter(10, 2)
+	limiter.now = clock.Now
+
+	assert.True(t, limiter.Allow())
+	assert.False(t, limiter.Allow())
+
+	clock.Advance(100 * time.Millisecond)
+	assert.True(t, limiter.Allow())
+}
+
+func TestLeakyBucketLimiterQueue(t *testing.T) {
+	clock := newFakeClock()
+	limiter := NewLeakyBucketLimiter(10, 2)
+	limiter.now = clock.Now
+
+	// 请求按100ms间隔依次放行
+	expected := []time.Duration{0, 100 * time.Millisecond, 200 * time.Millisecond}
+	for _, want := range expected {
+		delay, ok := limiter.Reserve()
+		assert.True(t, ok)
+		assert.Equal(t, want, delay)
+	}
+
+	// 队列已满
+	_, ok := limiter.Reserve()
+	assert.False(t, ok)
+	assert.Equal(t, int32(0), limiter.Stats().AvailableTokens)
+
+	ctx := context.Background()
+	assert.ErrorIs(t, limiter.Wait(ctx), ErrRateLimitExceeded)
+}
+
+func TestWaitReserveCanceled(t *testing.T) {
+	limiter := NewGCRALimiter(1, 1)
+	assert.True(t, limiter.Allow())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.Wait(ctx), context.DeadlineExceeded)
+
+	canceled, cancelNow := context.WithCancel(context.Background())
+	cancelNow()
+	assert.ErrorIs(t, NewLeakyBucketLimiter(10, 1).Wait(canceled), context.Canceled)
+}
@@ -0,0 +1,208 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-24 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-24 14:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\registry.go
+ * @Description: 按资源名管理熔断器、限流器与指标的注册中心
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Policy 资源保护策略
+type Policy struct {
+	Circuit  Config // 熔断器配置
+	Rate     int32  // 限流速率(每秒令牌数),0表示不限流
+	Capacity int32  // 限流桶容量,0时与 Rate 相同
+}
+
+// Resource 受保护的资源,由熔断器和可选的限流器组成
+type Resource struct {
+	Name    string
+	Circuit *Circuit
+	Limiter *Limiter // 未配置限流时为 nil
+}
+
+// Registry 资源注册中心
+// 按资源名懒加载创建熔断器与限流器,并将每次调用记录到共享的 MetricsCollector
+type Registry struct {
+	defaultPolicy Policy
+	policies      map[string]Policy
+	resources     map[string]*Resource
+	collector     *MetricsCollector
+	mu            sync.RWMutex
+}
+
+// NewRegistry 创建资源注册中心,collector 为 nil 时自动创建
+func NewRegistry(defaultPolicy Policy, collector *MetricsCollector) *Registry {
+	if collector == nil {
+		collector = NewMetricsCollector()
+	}
+	return &Registry{
+		defaultPolicy: defaultPolicy,
+		policies:      make(map[string]Policy),
+		resources:     make(map[string]*Resource),
+		collector:     collector,
+	}
+}
+
+// SetPolicy 设置指定资源的策略,仅对尚未创建的资源生效
+func (r *Registry) SetPolicy(name string, policy Policy) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[name] = policy
+	return r
+}
+
+// Collector 获取共享的指标收集器
+func (r *Registry) Collector() *MetricsCollector {
+	return r.collector
+}
+
+// Get 获取资源,不存在时按策略创建
+func (r *Registry) Get(name string) *Resource {
+	r.mu.RLock()
+	res, ok := r.resources[name]
+	r.mu.RUnlock()
+	if ok {
+		return res
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if res, ok = r.resources[name]; ok {
+		return res
+	}
+
+	policy, ok := r.policies[name]
+	if !ok {
+		policy = r.defaultPolicy
+	}
+
+	res = &Resource{
+		Name:    name,
+		Circuit: New(name, policy.Circuit),
+	}
+	if policy.Rate > 0 {
+		capacity := policy.Capacity
+		if capacity <= 0 {
+			capacity = policy.Rate
+		}
+		res.Limiter = NewLimiter(policy.Rate, capacity)
+	}
+	r.resources[name] = res
+	return res
+}
+
+// Remove 移除资源,下次访问时重新创建
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.resources, name)
+}
+
+// Names 获取已创建的资源名(已排序)
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.resources))
+	for name := range r.resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Execute 在指定资源的限流与熔断保护下执行操作
+func (r *Registry) Execute(name string, fn func() error) error {
+	_, err := ExecuteResource(context.Background(), r, name, func(context.Context) (struct{}, error) {
+		return struct{}{}, fn()
+	}, nil)
+	return err
+}
+
+// ExecuteResource 在指定资源的限流与熔断保护下执行带返回值的操作
+// 被限流时返回 ErrRateLimitExceeded,被熔断时返回 ErrCircuitOpen/ErrTooManyHalfOpenRequests,
+// 提供 fallback 时以上情况及 fn 出错均交由 fallback 处理
+func ExecuteResource[T any](ctx context.Context, r *Registry, name string, fn func(ctx context.Context) (T, error), fallback func(ctx context.Context, err error) (T, error)) (T, error) {
+	res := r.Get(name)
+	var zero T
+
+	if res.Limiter != nil && !res.Limiter.Allow() {
+		r.collector.RecordRateLimited(name)
+		if fallback != nil {
+			return fallback(ctx, ErrRateLimitExceeded)
+		}
+		return zero, ErrRateLimitExceeded
+	}
+
+	var fnErr error
+	result, err := ExecuteCtx(ctx, res.Circuit, func(ctx context.Context) (T, error) {
+		r.collector.RecordStart(name)
+		start := time.Now()
+		result, err := fn(ctx)
+		if err != nil {
+			r.collector.RecordFailure(name, time.Since(start))
+		} else {
+			r.collector.RecordSuccess(name, time.Since(start))
+		}
+		fnErr = err
+		return result, err
+	}, nil)
+
+	if err != nil && fnErr == nil && IsRejected(err) {
+		r.collector.RecordRejected(name)
+	}
+	if err != nil && fallback != nil {
+		return fallback(ctx, err)
+	}
+	return result, err
+}
+
+// RegistrySnapshot 注册中心快照
+type RegistrySnapshot struct {
+	Metrics   *MetricsSnapshot        `json:"metrics"`
+	Circuits  map[string]CircuitStats `json:"circuits"`
+	Limiters  map[string]LimiterStats `json:"limiters"`
+	Timestamp int64                   `json:"timestamp"`
+}
+
+// Snapshot 获取所有资源的熔断、限流与指标快照
+func (r *Registry) Snapshot() *RegistrySnapshot {
+	r.mu.RLock()
+	resources := make([]*Resource, 0, len(r.resources))
+	for _, res := range r.resources {
+		resources = append(resources, res)
+	}
+	r.mu.RUnlock()
+
+	snapshot := &RegistrySnapshot{
+		Metrics:   r.collector.GetSnapshot(),
+		Circuits:  make(map[string]CircuitStats, len(resources)),
+		Limiters:  make(map[string]LimiterStats),
+		Timestamp: time.Now().Unix(),
+	}
+	for _, res := range resources {
+		snapshot.Circuits[res.Name] = res.Circuit.Stats()
+		if res.Limiter != nil {
+			snapshot.Limiters[res.Name] = res.Limiter.Stats()
+		}
+	}
+	return snapshot
+}
+
+// Exporter 创建包含熔断状态的 Prometheus 导出器
+func (r *Registry) Exporter(namespace, service string) *PrometheusExporter {
+	return NewPrometheusExporter(r.collector, namespace, service).WithRegistry(r)
+}
@@ -0,0 +1,159 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-24 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-24 14:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\registry_test.go
+ * @Description: 资源注册中心测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistryGetLazyCreate(t *testing.T) {
+	r := NewRegistry(Policy{Circuit: Config{MaxFailures: 3}}, nil)
+
+	res := r.Get("db")
+	assert.NotNil(t, res)
+	assert.Equal(t, "db", res.Name)
+	assert.Equal(t, int32(3), res.Circuit.maxFailures)
+	assert.Nil(t, res.Limiter)
+	assert.NotNil(t, r.Collector())
+
+	// 再次获取返回同一实例
+	assert.Same(t, res, r.Get("db"))
+	assert.Equal(t, []string{"db"}, r.Names())
+}
+
+func TestRegistryPerNamePolicy(t *testing.T) {
+	r := NewRegistry(Policy{Circuit: Config{MaxFailures: 3}}, nil)
+	r.SetPolicy("api", Policy{Circuit: Config{MaxFailures: 1}, Rate: 5})
+
+	res := r.Get("api")
+	assert.Equal(t, int32(1), res.Circuit.maxFailures)
+	assert.NotNil(t, res.Limiter)
+	assert.Equal(t, int32(5), res.Limiter.capacity)
+
+	assert.Equal(t, int32(3), r.Get("other").Circuit.maxFailures)
+}
+
+func TestRegistryRemove(t *testing.T) {
+	r := NewRegistry(Policy{}, nil)
+	first := r.Get("db")
+	r.Remove("db")
+
+	assert.Empty(t, r.Names())
+	assert.NotSame(t, first, r.Get("db"))
+}
+
+func TestRegistryExecuteRecordsMetrics(t *testing.T) {
+	mc := NewMetricsCollector()
+	r := NewRegistry(Policy{Circuit: Config{MaxFailures: 2, ResetTimeout: time.Second}}, mc)
+	testErr := errors.New("boom")
+
+	assert.NoError(t, r.Execute("svc", func() error { return nil }))
+	assert.Equal(t, testErr, r.Execute("svc", func() error { return testErr }))
+	assert.Equal(t, testErr, r.Execute("svc", func() error { return testErr }))
+
+	// 熔断打开后被拒绝
+	err := r.Execute("svc", func() error { return nil })
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	metrics := mc.GetMetrics("svc")
+	assert.Equal(t, int64(3), metrics.ExecutionCount)
+	assert.Equal(t, int64(1), metrics.SuccessCount)
+	assert.Equal(t, int64(2), metrics.FailureCount)
+	assert.Equal(t, int64(1), metrics.RejectedCount)
+	assert.Equal(t, int64(1), mc.GetRejectedCount("svc"))
+}
+
+func TestRegistryExecuteRateLimited(t *testing.T) {
+	mc := NewMetricsCollector()
+	r := NewRegistry(Policy{Rate: 1, Capacity: 1}, mc)
+
+	assert.NoError(t, r.Execute("api", func() error { return nil }))
+	err := r.Execute("api", func() error { return nil })
+	assert.ErrorIs(t, err, ErrRateLimitExceeded)
+
+	// 限流不影响熔断器状态
+	assert.Equal(t, StateClosed, r.Get("api").Circuit.GetState())
+	assert.Equal(t, int64(1), mc.GetFailureCount("api"))
+}
+
+func TestExecuteResourceWithFallback(t *testing.T) {
+	r := NewRegistry(Policy{Circuit: Config{MaxFailures: 1, ResetTimeout: time.Second}}, nil)
+	r.Get("svc").Circuit.RecordFailure()
+
+	var got error
+	result, err := ExecuteResource(context.Background(), r, "svc", func(ctx context.Context) (int, error) {
+		return 1, nil
+	}, func(ctx context.Context, err error) (int, error) {
+		got = err
+		return -1, nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, -1, result)
+	assert.ErrorIs(t, got, ErrCircuitOpen)
+	assert.Equal(t, int64(1), r.Collector().GetRejectedCount("svc"))
+}
+
+func TestRegistrySnapshot(t *testing.T) {
+	r := NewRegistry(Policy{Rate: 10}, nil)
+	_ = r.Execute("a", func() error { return nil })
+	_ = r.Execute("b", func() error { return errors.New("x") })
+
+	snapshot := r.Snapshot()
+	assert.Len(t, snapshot.Circuits, 2)
+	assert.Len(t, snapshot.Limiters, 2)
+	assert.Equal(t, "closed", snapshot.Circuits["a"].State)
+	assert.Equal(t, int32(1), snapshot.Circuits["b"].Failures)
+	assert.Equal(t, int64(2), snapshot.Metrics.GlobalMetrics.TotalExecutions)
+}
+
+func TestRegistryPrometheusExport(t *testing.T) {
+	r := NewRegistry(Policy{Circuit: Config{MaxFailures: 1, ResetTimeout: time.Second}, Rate: 10}, nil)
+	_ = r.Execute("db", func() error { return errors.New("x") })
+	_ = r.Execute("cache", func() error { return nil })
+
+	output := r.Exporter("app", "svc").Export()
+
+	assert.Contains(t, output, "# TYPE app_svc_circuit_state gauge")
+	assert.Contains(t, output, `app_svc_circuit_state{service="svc",resource="db",state="open"} 1`)
+	assert.Contains(t, output, `app_svc_circuit_state{service="svc",resource="db",state="closed"} 0`)
+	assert.Contains(t, output, `app_svc_circuit_state{service="svc",resource="cache",state="closed"} 1`)
+	assert.Contains(t, output, `app_svc_circuit_failures{service="svc",resource="db"} 1`)
+	assert.Contains(t, output, `app_svc_limiter_available_tokens{service="svc",resource="cache"}`)
+}
+
+func TestRegistryConcurrentGet(t *testing.T) {
+	r := NewRegistry(Policy{}, nil)
+	results := make([]*Resource, 50)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			results[idx] = r.Get("shared")
+			_ = r.Execute("shared", func() error { return nil })
+		}(i)
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		assert.Same(t, results[0], res)
+	}
+	assert.Equal(t, int64(50), r.Collector().GetSuccessCount("shared"))
+}
@@ -0,0 +1,184 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-24 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-24 10:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\window.go
+ * @Description: 滑动窗口(计数窗口/时间窗口)实现,基于分桶环形缓冲区
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// WindowType 滑动窗口类型
+type WindowType int32
+
+const (
+	WindowNone  WindowType = iota // 不启用滑动窗口(按连续失败次数熔断)
+	WindowCount                   // 计数窗口(最近N次调用)
+	WindowTime                    // 时间窗口(最近N秒)
+)
+
+// String 返回窗口类型字符串
+func (w WindowType) String() string {
+	switch w {
+	case WindowNone:
+		return "none"
+	case WindowCount:
+		return "count"
+	case WindowTime:
+		return "time"
+	default:
+		return "unknown"
+	}
+}
+
+// windowBucket 窗口桶
+type windowBucket struct {
+	epoch    int64 // 桶所属的时间片(仅时间窗口使用)
+	calls    int64 // 调用次数
+	failures int64 // 失败次数
+	slowCall int64 // 慢调用次数
+}
+
+// reset 重置桶
+func (b *windowBucket) reset(epoch int64) {
+	b.epoch = epoch
+	b.calls = 0
+	b.failures = 0
+	b.slowCall = 0
+}
+
+// WindowSnapshot 滑动窗口快照
+type WindowSnapshot struct {
+	TotalCalls   int64   // 窗口内调用总数
+	FailedCalls  int64   // 窗口内失败次数
+	SlowCalls    int64   // 窗口内慢调用次数
+	FailureRate  float64 // 失败率(百分比)
+	SlowCallRate float64 // 慢调用率(百分比)
+}
+
+// slidingWindow 滑动窗口
+// 计数窗口: 每个桶存放一次调用结果,环形覆盖最旧的调用
+// 时间窗口: 每个桶对应一秒,读取/写入时淘汰过期的桶
+type slidingWindow struct {
+	kind    WindowType
+	buckets []windowBucket
+	head    int // 计数窗口下一次写入位置
+	total   windowBucket
+	now     func() time.Time
+	mu      sync.Mutex
+}
+
+// newSlidingWindow 创建滑动窗口
+func newSlidingWindow(kind WindowType, size int) *slidingWindow {
+	if size <= 0 {
+		size = 100
+	}
+	return &slidingWindow{
+		kind:    kind,
+		buckets: make([]windowBucket, size),
+		now:     time.Now,
+	}
+}
+
+// record 记录一次调用结果
+func (w *slidingWindow) record(failed, slow bool) WindowSnapshot {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var bucket *windowBucket
+	switch w.kind {
+	case WindowCount:
+		bucket = &w.buckets[w.head]
+		w.subtract(bucket)
+		bucket.reset(0)
+		w.head = (w.head + 1) % len(w.buckets)
+	default:
+		epoch := w.now().Unix()
+		w.expire(epoch)
+		bucket = &w.buckets[int(epoch%int64(len(w.buckets)))]
+	}
+
+	bucket.calls++
+	w.total.calls++
+	if failed {
+		bucket.failures++
+		w.total.failures++
+	}
+	if slow {
+		bucket.slowCall++
+		w.total.slowCall++
+	}
+
+	return w.snapshot()
+}
+
+// Snapshot 获取当前窗口快照
+func (w *slidingWindow) Snapshot() WindowSnapshot {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.kind == WindowTime {
+		w.expire(w.now().Unix())
+	}
+	return w.snapshot()
+}
+
+// reset 清空窗口
+func (w *slidingWindow) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.buckets {
+		w.buckets[i].reset(0)
+	}
+	w.total.reset(0)
+	w.head = 0
+}
+
+// expire 淘汰时间窗口中过期的桶,并确保当前时间片对应的桶可用
+func (w *slidingWindow) expire(epoch int64) {
+	size := int64(len(w.buckets))
+	for i := range w.buckets {
+		b := &w.buckets[i]
+		if b.calls == 0 && b.epoch == 0 {
+			continue
+		}
+		if epoch-b.epoch >= size || (int64(i) == epoch%size && b.epoch != epoch) {
+			w.subtract(b)
+			b.reset(0)
+		}
+	}
+	current := &w.buckets[int(epoch%size)]
+	if current.epoch != epoch {
+		current.reset(epoch)
+	}
+}
+
+// subtract 从汇总中扣除桶数据
+func (w *slidingWindow) subtract(b *windowBucket) {
+	w.total.calls -= b.calls
+	w.total.failures -= b.failures
+	w.total.slowCall -= b.slowCall
+}
+
+// snapshot 生成快照(调用方需持有锁)
+func (w *slidingWindow) snapshot() WindowSnapshot {
+	s := WindowSnapshot{
+		TotalCalls:  w.total.calls,
+		FailedCalls: w.total.failures,
+		SlowCalls:   w.total.slowCall,
+	}
+	if s.TotalCalls > 0 {
+		s.FailureRate = float64(s.FailedCalls) / float64(s.TotalCalls) * 100
+		s.SlowCallRate = float64(s.SlowCalls) / float64(s.TotalCalls) * 100
+	}
+	return s
+}
@@ -0,0 +1,224 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-24 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-24 10:00:00
+ * @FilePath: \go-toolbox\pkg\breaker\window_test.go
+ * @Description: 滑动窗口熔断测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWindowTypeString(t *testing.T) {
+	assert.Equal(t, "none", WindowNone.String())
+	assert.Equal(t, "count", WindowCount.String())
+	assert.Equal(t, "time", WindowTime.String())
+	assert.Equal(t, "unknown", WindowType(99).String())
+}
+
+func TestCountWindowEvictsOldest(t *testing.T) {
+	w := newSlidingWindow(WindowCount, 4)
+
+	w.record(true, false)
+	w.record(true, false)
+	w.record(false, true)
+	s := w.record(false, false)
+	assert.Equal(t, int64(4), s.TotalCalls)
+	assert.Equal(t, int64(2), s.FailedCalls)
+	assert.Equal(t, 50.0, s.FailureRate)
+	assert.Equal(t, 25.0, s.SlowCallRate)
+
+	// 再写入两次成功,最早的两次失败被覆盖
+	w.record(false, false)
+	s = w.record(false, false)
+	assert.Equal(t, int64(4), s.TotalCalls)
+	assert.Equal(t, int64(0), s.FailedCalls)
+	assert.Equal(t, int64(1), s.SlowCalls)
+}
+
+func TestTimeWindowExpiresBuckets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	w := newSlidingWindow(WindowTime, 3)
+	w.now = func() time.Time { return now }
+
+	w.record(true, false)
+	now = now.Add(time.Second)
+	w.record(false, false)
+	s := w.Snapshot()
+	assert.Equal(t, int64(2), s.TotalCalls)
+	assert.Equal(t, int64(1), s.FailedCalls)
+
+	// 前进3秒,第一个桶过期
+	now = now.Add(2 * time.Second)
+	s = w.Snapshot()
+	assert.Equal(t, int64(1), s.TotalCalls)
+	assert.Equal(t, int64(0), s.FailedCalls)
+
+	// 全部过期
+	now = now.Add(10 * time.Second)
+	s = w.Snapshot()
+	assert.Equal(t, int64(0), s.TotalCalls)
+}
+
+func TestWindowReset(t *testing.T) {
+	w := newSlidingWindow(WindowCount, 10)
+	w.record(true, true)
+	w.reset()
+
+	s := w.Snapshot()
+	assert.Equal(t, int64(0), s.TotalCalls)
+	assert.Equal(t, 0.0, s.FailureRate)
+}
+
+func TestCircuitWindowDefaults(t *testing.T) {
+	cb := New("test", Config{WindowType: WindowCount})
+
+	assert.NotNil(t, cb.window)
+	assert.Equal(t, 100, len(cb.window.buckets))
+	assert.Equal(t, 50.0, cb.failureRateThreshold)
+	assert.Equal(t, int64(10), cb.minimumCalls)
+	assert.Equal(t, 5*time.Second, cb.slowCallDuration)
+
+	cb = New("test", Config{WindowType: WindowTime})
+	assert.Equal(t, 60, len(cb.window.buckets))
+}
+
+func TestCircuitWindowMinimumCalls(t *testing.T) {
+	cb := New("test", Config{
+		WindowType:           WindowCount,
+		WindowSize:           10,
+		FailureRateThreshold: 50,
+		MinimumCalls:         5,
+	})
+
+	// 未达到最小调用次数时不评估
+	for i := 0; i < 4; i++ {
+		cb.RecordFailure()
+	}
+	assert.Equal(t, StateClosed, cb.GetState())
+
+	cb.RecordFailure()
+	assert.Equal(t, StateOpen, cb.GetState())
+}
+
+func TestCircuitWindowFailureRate(t *testing.T) {
+	cb := New("test", Config{
+		MaxFailures:          2,
+		WindowType:           WindowCount,
+		WindowSize:           10,
+		FailureRateThreshold: 50,
+		MinimumCalls:         10,
+	})
+
+	testErr := errors.New("test error")
+	// 6次成功 + 4次连续失败 = 40%失败率,超过 MaxFailures 也不熔断
+	for i := 0; i < 10; i++ {
+		_ = cb.Execute(func() error {
+			if i >= 6 {
+				return testErr
+			}
+			return nil
+		})
+	}
+	assert.Equal(t, StateClosed, cb.GetState())
+	assert.Equal(t, 40.0, cb.Stats().FailureRate)
+
+	// 再失败一次覆盖最早的成功,失败率达到50%
+	_ = cb.Execute(func() error { return testErr })
+	assert.Equal(t, StateOpen, cb.GetState())
+}
+
+func TestCircuitWindowSlowCallRate(t *testing.T) {
+	cb := New("test", Config{
+		WindowType:            WindowCount,
+		WindowSize:            4,
+		FailureRateThreshold:  100,
+		SlowCallRateThreshold: 50,
+		SlowCallDuration:      10 * time.Millisecond,
+		MinimumCalls:          4,
+	})
+
+	cb.RecordResult(true, time.Millisecond)
+	cb.RecordResult(true, time.Millisecond)
+	cb.RecordResult(true, 20*time.Millisecond)
+	assert.Equal(t, StateClosed, cb.GetState())
+
+	stats := cb.Stats()
+	assert.Equal(t, "count", stats.WindowType)
+	assert.Equal(t, int64(3), stats.TotalCalls)
+	assert.Equal(t, int64(1), stats.SlowCalls)
+
+	cb.RecordResult(true, 20*time.Millisecond)
+	assert.Equal(t, StateOpen, cb.GetState())
+
+	// 熔断后窗口重新统计
+	assert.Equal(t, int64(0), cb.Stats().TotalCalls)
+}
+
+func TestCircuitWindowSlowCallInHalfOpen(t *testing.T) {
+	cb := New("test", Config{
+		ResetTimeout:          20 * time.Millisecond,
+		WindowType:            WindowCount,
+		WindowSize:            2,
+		FailureRateThreshold:  50,
+		SlowCallRateThreshold: 50,
+		SlowCallDuration:      10 * time.Millisecond,
+		MinimumCalls:          2,
+	})
+
+	cb.RecordFailure()
+	cb.RecordFailure()
+	assert.Equal(t, StateOpen, cb.GetState())
+
+	time.Sleep(30 * time.Millisecond)
+	assert.True(t, cb.AllowRequest())
+	assert.Equal(t, StateHalfOpen, cb.GetState())
+
+	// 半开状态下的慢调用视为失败
+	cb.RecordResult(true, 20*time.Millisecond)
+	assert.Equal(t, StateOpen, cb.GetState())
+}
+
+func TestCircuitWindowTimeBased(t *testing.T) {
+	cb := New("test", Config{
+		WindowType:           WindowTime,
+		WindowSize:           10,
+		FailureRateThreshold: 60,
+		MinimumCalls:         5,
+	})
+
+	var changes []State
+	cb.onStateChange = func(from, to State) {
+		changes = append(changes, to)
+	}
+
+	for i := 0; i < 3; i++ {
+		cb.RecordSuccess()
+	}
+	for i := 0; i < 4; i++ {
+		cb.RecordFailure()
+	}
+	assert.Equal(t, StateClosed, cb.GetState())
+
+	cb.RecordFailure()
+	assert.Equal(t, StateOpen, cb.GetState())
+	assert.Equal(t, []State{StateOpen}, changes)
+}
+
+func TestCircuitStatsWithoutWindow(t *testing.T) {
+	cb := New("test", Config{})
+	stats := cb.Stats()
+
+	assert.Equal(t, "none", stats.WindowType)
+	assert.Equal(t, int64(0), stats.TotalCalls)
+}
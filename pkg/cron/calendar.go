@@ -0,0 +1,280 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-28 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-28 10:00:00
+ * @FilePath: \go-toolbox\pkg\cron\calendar.go
+ * @Description: 排除日历(节假日、维护窗口)及日历感知的调度
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package cron
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// calendarSearchLimit 查找未排除时间的最大跳跃次数，防止日历排除全部时间时死循环
+const calendarSearchLimit = 5 * 366
+
+// Calendar 排除日历，日期按传入时间所在时区判断
+type Calendar interface {
+	// IsExcluded 判断时间是否被排除
+	IsExcluded(t time.Time) bool
+	// NextIncluded 返回不早于 t 的第一个未被排除的时间，找不到时返回零值
+	NextIncluded(t time.Time) time.Time
+}
+
+// CalendarSchedule 日历感知的调度，跳过被日历排除的触发时间
+type CalendarSchedule struct {
+	Schedule CronSchedule
+	Calendar Calendar
+}
+
+// NewCalendarSchedule 创建日历感知的调度，多个日历取并集
+func NewCalendarSchedule(schedule CronSchedule, calendars ...Calendar) *CalendarSchedule {
+	return &CalendarSchedule{Schedule: schedule, Calendar: UnionCalendar(calendars...)}
+}
+
+// Next 返回下次未被排除的激活时间
+func (s *CalendarSchedule) Next(t time.Time) time.Time {
+	for range calendarSearchLimit {
+		next := s.Schedule.Next(t)
+		if next.IsZero() || !s.Calendar.IsExcluded(next) {
+			return next
+		}
+		included := s.Calendar.NextIncluded(next)
+		if included.IsZero() {
+			return time.Time{}
+		}
+		// 从第一个未排除时间开始(含)继续查找
+		t = included.Add(-time.Nanosecond)
+	}
+	return time.Time{}
+}
+
+// UnionCalendar 合并多个日历，任一日历排除即排除
+func UnionCalendar(calendars ...Calendar) Calendar {
+	if len(calendars) == 1 {
+		return calendars[0]
+	}
+	return unionCalendar(calendars)
+}
+
+// unionCalendar 日历并集
+type unionCalendar []Calendar
+
+// IsExcluded 任一日历排除即排除
+func (u unionCalendar) IsExcluded(t time.Time) bool {
+	for _, c := range u {
+		if c.IsExcluded(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextIncluded 反复推进直到所有日历都不排除
+func (u unionCalendar) NextIncluded(t time.Time) time.Time {
+	for range calendarSearchLimit {
+		moved := false
+		for _, c := range u {
+			if c.IsExcluded(t) {
+				if t = c.NextIncluded(t); t.IsZero() {
+					return t
+				}
+				moved = true
+			}
+		}
+		if !moved {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// timeRange 时间区间 [Start, End)
+type timeRange struct {
+	start, end time.Time
+}
+
+// ExclusionCalendar 排除日历，支持具体日期、每年固定日期和时间区间
+type ExclusionCalendar struct {
+	mu     sync.RWMutex
+	dates  map[int]struct{}
+	annual map[int]struct{}
+	ranges []timeRange
+}
+
+// NewExclusionCalendar 创建排除日历
+func NewExclusionCalendar() *ExclusionCalendar {
+	return &ExclusionCalendar{
+		dates:  make(map[int]struct{}),
+		annual: make(map[int]struct{}),
+	}
+}
+
+// AddDates 排除具体日期(整天)
+func (c *ExclusionCalendar) AddDates(dates ...time.Time) *ExclusionCalendar {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, d := range dates {
+		c.dates[dateKey(d)] = struct{}{}
+	}
+	return c
+}
+
+// AddAnnual 排除每年的固定日期(整天)，如 12 月 25 日
+func (c *ExclusionCalendar) AddAnnual(month time.Month, day int) *ExclusionCalendar {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.annual[int(month)*100+day] = struct{}{}
+	return c
+}
+
+// AddRange 排除时间区间 [start, end)，如维护窗口
+func (c *ExclusionCalendar) AddRange(start, end time.Time) *ExclusionCalendar {
+	if !end.After(start) {
+		return c
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ranges = append(c.ranges, timeRange{start: start, end: end})
+	sort.Slice(c.ranges, func(i, j int) bool { return c.ranges[i].start.Before(c.ranges[j].start) })
+	return c
+}
+
+// IsExcluded 判断时间是否被排除
+func (c *ExclusionCalendar) IsExcluded(t time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.excludedDay(t) || c.rangeEnd(t) != nil
+}
+
+// NextIncluded 返回不早于 t 的第一个未被排除的时间
+func (c *ExclusionCalendar) NextIncluded(t time.Time) time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for range calendarSearchLimit {
+		switch end := c.rangeEnd(t); {
+		case end != nil:
+			t = *end
+		case c.excludedDay(t):
+			t = nextDayStart(t)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// excludedDay 判断所在日期是否整天排除(调用方需持有锁)
+func (c *ExclusionCalendar) excludedDay(t time.Time) bool {
+	if _, ok := c.dates[dateKey(t)]; ok {
+		return true
+	}
+	_, ok := c.annual[int(t.Month())*100+t.Day()]
+	return ok
+}
+
+// rangeEnd 返回包含 t 的时间区间的结束时间，不在任何区间内时返回 nil(调用方需持有锁)
+func (c *ExclusionCalendar) rangeEnd(t time.Time) *time.Time {
+	for i := range c.ranges {
+		r := &c.ranges[i]
+		if r.start.After(t) {
+			break
+		}
+		if t.Before(r.end) {
+			return &r.end
+		}
+	}
+	return nil
+}
+
+// HolidayCalendar 法定节假日日历(含调休)
+// 周末和节假日被排除，调休上班日即使是周末也不排除
+type HolidayCalendar struct {
+	mu       sync.RWMutex
+	holidays map[int]string
+	workdays map[int]struct{}
+}
+
+// NewHolidayCalendar 创建法定节假日日历
+func NewHolidayCalendar() *HolidayCalendar {
+	return &HolidayCalendar{
+		holidays: make(map[int]string),
+		workdays: make(map[int]struct{}),
+	}
+}
+
+// AddHoliday 添加节假日，start 到 end(含)的每一天均放假
+func (c *HolidayCalendar) AddHoliday(name string, start, end time.Time) *HolidayCalendar {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		c.holidays[dateKey(d)] = name
+	}
+	return c
+}
+
+// AddWorkdays 添加调休上班日
+func (c *HolidayCalendar) AddWorkdays(dates ...time.Time) *HolidayCalendar {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, d := range dates {
+		c.workdays[dateKey(d)] = struct{}{}
+	}
+	return c
+}
+
+// IsWorkday 判断是否为工作日
+func (c *HolidayCalendar) IsWorkday(t time.Time) bool {
+	return !c.IsExcluded(t)
+}
+
+// HolidayName 返回节假日名称
+func (c *HolidayCalendar) HolidayName(t time.Time) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	name, ok := c.holidays[dateKey(t)]
+	return name, ok
+}
+
+// IsExcluded 节假日和非调休的周末被排除
+func (c *HolidayCalendar) IsExcluded(t time.Time) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	key := dateKey(t)
+	if _, ok := c.workdays[key]; ok {
+		return false
+	}
+	if _, ok := c.holidays[key]; ok {
+		return true
+	}
+	return t.Weekday() == time.Saturday || t.Weekday() == time.Sunday
+}
+
+// NextIncluded 返回不早于 t 的第一个工作日时间
+func (c *HolidayCalendar) NextIncluded(t time.Time) time.Time {
+	for range calendarSearchLimit {
+		if !c.IsExcluded(t) {
+			return t
+		}
+		t = nextDayStart(t)
+	}
+	return time.Time{}
+}
+
+// nextDayStart 返回下一天的开始时间
+func nextDayStart(t time.Time) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day()+1, 12, 0, 0, 0, t.Location())
+	return startOfDay(next.Year(), next.Month(), next.Day(), t.Location())
+}
+
+// dateKey 日期键(yyyymmdd)，按时间所在时区计算
+func dateKey(t time.Time) int {
+	return t.Year()*10000 + int(t.Month())*100 + t.Day()
+}
@@ -0,0 +1,344 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-28 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-28 10:00:00
+ * @FilePath: \go-toolbox\pkg\cron\calendar_loader.go
+ * @Description: 从 JSON / iCal 加载排除日历和法定节假日日历
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package cron
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+)
+
+const (
+	calendarDateLayout   = "2006-01-02"
+	calendarAnnualLayout = "01-02"
+	icalDateLayout       = "20060102"
+	icalDateTimeLayout   = "20060102T150405"
+)
+
+// CalendarFile 排除日历 JSON 格式
+//
+// 示例：
+//
+//	{
+//	  "dates":  ["2025-10-01"],
+//	  "annual": ["01-01", "12-25"],
+//	  "ranges": [{"start": "2025-12-27T02:00:00+08:00", "end": "2025-12-27T04:00:00+08:00"}]
+//	}
+//
+// ranges 的起止时间支持 RFC3339 或日期(日期形式的结束日当天也被排除)
+type CalendarFile struct {
+	Dates  []string            `json:"dates,omitempty"`
+	Annual []string            `json:"annual,omitempty"`
+	Ranges []CalendarFileRange `json:"ranges,omitempty"`
+}
+
+// CalendarFileRange 时间区间
+type CalendarFileRange struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ChineseHolidayFile 法定节假日 JSON 格式(单个年份)
+// 文件内容可以是单个对象，也可以是多个年份组成的数组
+//
+// 示例：
+//
+//	{
+//	  "year": 2025,
+//	  "holidays": [
+//	    {"name": "元旦", "start": "2025-01-01", "end": "2025-01-01"},
+//	    {"name": "春节", "start": "2025-01-28", "end": "2025-02-04"}
+//	  ],
+//	  "workdays": ["2025-01-26", "2025-02-08"]
+//	}
+type ChineseHolidayFile struct {
+	Year     int                  `json:"year"`
+	Holidays []ChineseHolidayItem `json:"holidays"`
+	Workdays []string             `json:"workdays"`
+}
+
+// ChineseHolidayItem 节假日区间(含首尾)
+type ChineseHolidayItem struct {
+	Name  string `json:"name"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// LoadCalendarFile 按扩展名(.json / .ics / .ical)加载排除日历
+func LoadCalendarFile(path string) (*ExclusionCalendar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return LoadJSONCalendar(f)
+	case ".ics", ".ical":
+		return LoadICalCalendar(f)
+	default:
+		return nil, fmt.Errorf("不支持的日历文件格式: %s", path)
+	}
+}
+
+// LoadJSONCalendar 从 JSON 加载排除日历，格式见 CalendarFile
+func LoadJSONCalendar(r io.Reader) (*ExclusionCalendar, error) {
+	var file CalendarFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, fmt.Errorf("解析日历 JSON 失败: %v", err)
+	}
+
+	cal := NewExclusionCalendar()
+	for _, s := range file.Dates {
+		d, err := time.ParseInLocation(calendarDateLayout, s, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("无效的日期 %s: %v", s, err)
+		}
+		cal.AddDates(d)
+	}
+	for _, s := range file.Annual {
+		d, err := time.Parse(calendarAnnualLayout, s)
+		if err != nil {
+			return nil, fmt.Errorf("无效的年度日期 %s: %v", s, err)
+		}
+		cal.AddAnnual(d.Month(), d.Day())
+	}
+	for _, r := range file.Ranges {
+		start, _, err := parseCalendarTime(r.Start)
+		if err != nil {
+			return nil, err
+		}
+		end, dateOnly, err := parseCalendarTime(r.End)
+		if err != nil {
+			return nil, err
+		}
+		if dateOnly {
+			end = nextDayStart(end)
+		}
+		cal.AddRange(start, end)
+	}
+	return cal, nil
+}
+
+// parseCalendarTime 解析 RFC3339 时间或日期
+func parseCalendarTime(s string) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.RFC3339, s); err == nil {
+		return t, false, nil
+	}
+	if t, err = time.ParseInLocation(calendarDateLayout, s, time.Local); err == nil {
+		return t, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("无效的时间 %s: 需要 RFC3339 或 yyyy-mm-dd 格式", s)
+}
+
+// LoadICalCalendar 从 iCal(RFC 5545)加载排除日历
+// 全天事件排除对应日期(RRULE:FREQ=YEARLY 时按年重复)，带时刻的事件排除对应时间区间
+func LoadICalCalendar(r io.Reader) (*ExclusionCalendar, error) {
+	cal := NewExclusionCalendar()
+
+	var event map[string]icalProperty
+	for _, line := range unfoldICalLines(r) {
+		switch {
+		case line == "BEGIN:VEVENT":
+			event = make(map[string]icalProperty)
+		case line == "END:VEVENT":
+			if event == nil {
+				return nil, fmt.Errorf("iCal 格式错误: END:VEVENT 缺少对应的 BEGIN")
+			}
+			if err := addICalEvent(cal, event); err != nil {
+				return nil, err
+			}
+			event = nil
+		case event != nil:
+			if prop, ok := parseICalProperty(line); ok {
+				event[prop.name] = prop
+			}
+		}
+	}
+	return cal, nil
+}
+
+// icalProperty iCal 属性
+type icalProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// unfoldICalLines 读取并展开折行(以空格或制表符开头的行是上一行的延续)
+func unfoldICalLines(r io.Reader) []string {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseICalProperty 解析 NAME;PARAM=VALUE:VALUE 形式的属性
+func parseICalProperty(line string) (icalProperty, bool) {
+	colon := strings.IndexByte(line, ':')
+	if colon == -1 {
+		return icalProperty{}, false
+	}
+	parts := strings.Split(line[:colon], ";")
+	prop := icalProperty{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string, len(parts)-1),
+		value:  line[colon+1:],
+	}
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			prop.params[strings.ToUpper(k)] = v
+		}
+	}
+	return prop, true
+}
+
+// addICalEvent 将事件加入日历
+func addICalEvent(cal *ExclusionCalendar, event map[string]icalProperty) error {
+	startProp, ok := event["DTSTART"]
+	if !ok {
+		return fmt.Errorf("iCal 事件缺少 DTSTART")
+	}
+	start, allDay, err := parseICalTime(startProp)
+	if err != nil {
+		return err
+	}
+
+	var end time.Time
+	if endProp, ok := event["DTEND"]; ok {
+		if end, _, err = parseICalTime(endProp); err != nil {
+			return err
+		}
+	} else {
+		// 无 DTEND 时全天事件持续一天，带时刻的事件视为瞬时事件
+		end = mathx.IF(allDay, nextDayStart(start), start)
+	}
+
+	yearly := false
+	if rule, ok := event["RRULE"]; ok {
+		if !strings.Contains(strings.ToUpper(rule.value), "FREQ=YEARLY") || !allDay {
+			return fmt.Errorf("不支持的 RRULE: %s", rule.value)
+		}
+		yearly = true
+	}
+
+	if !allDay {
+		cal.AddRange(start, end)
+		return nil
+	}
+	// 全天事件的 DTEND 不含当天
+	for d := start; d.Before(end); d = nextDayStart(d) {
+		if yearly {
+			cal.AddAnnual(d.Month(), d.Day())
+		} else {
+			cal.AddDates(d)
+		}
+	}
+	return nil
+}
+
+// parseICalTime 解析 DATE / DATE-TIME 值，返回是否为全天
+func parseICalTime(prop icalProperty) (time.Time, bool, error) {
+	value := prop.value
+	if len(value) == len(icalDateLayout) {
+		t, err := time.ParseInLocation(icalDateLayout, value, time.Local)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("无效的 iCal 日期 %s: %v", value, err)
+		}
+		return t, true, nil
+	}
+
+	loc := time.Local
+	if strings.HasSuffix(value, "Z") {
+		loc, value = time.UTC, strings.TrimSuffix(value, "Z")
+	} else if tzid, ok := prop.params["TZID"]; ok {
+		var err error
+		if loc, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, false, fmt.Errorf("无效的时区 %s: %v", tzid, err)
+		}
+	}
+	t, err := time.ParseInLocation(icalDateTimeLayout, value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("无效的 iCal 时间 %s: %v", prop.value, err)
+	}
+	return t, false, nil
+}
+
+// LoadChineseHolidayFile 从文件加载法定节假日日历
+func LoadChineseHolidayFile(path string) (*HolidayCalendar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return LoadChineseHolidayCalendar(f)
+}
+
+// LoadChineseHolidayCalendar 加载法定节假日日历，格式见 ChineseHolidayFile
+func LoadChineseHolidayCalendar(r io.Reader) (*HolidayCalendar, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []ChineseHolidayFile
+	if data = bytes.TrimSpace(data); len(data) > 0 && data[0] == '[' {
+		err = json.Unmarshal(data, &files)
+	} else {
+		files = make([]ChineseHolidayFile, 1)
+		err = json.Unmarshal(data, &files[0])
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析节假日 JSON 失败: %v", err)
+	}
+
+	cal := NewHolidayCalendar()
+	for _, file := range files {
+		for _, h := range file.Holidays {
+			start, err := time.ParseInLocation(calendarDateLayout, h.Start, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("%d 年 %s 起始日期无效: %v", file.Year, h.Name, err)
+			}
+			end := start
+			if h.End != "" {
+				if end, err = time.ParseInLocation(calendarDateLayout, h.End, time.Local); err != nil {
+					return nil, fmt.Errorf("%d 年 %s 结束日期无效: %v", file.Year, h.Name, err)
+				}
+			}
+			cal.AddHoliday(h.Name, start, end)
+		}
+		for _, s := range file.Workdays {
+			d, err := time.ParseInLocation(calendarDateLayout, s, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("%d 年调休日期 %s 无效: %v", file.Year, s, err)
+			}
+			cal.AddWorkdays(d)
+		}
+	}
+	return cal, nil
+}
@@ -0,0 +1,200 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-28 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-28 10:00:00
+ * @FilePath: \go-toolbox\pkg\cron\calendar_test.go
+ * @Description: 排除日历测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package cron
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// date 构造本地时区日期
+func date(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.Local)
+}
+
+const holidays2025 = `{
+  "year": 2025,
+  "holidays": [
+    {"name": "元旦", "start": "2025-01-01", "end": "2025-01-01"},
+    {"name": "国庆节、中秋节", "start": "2025-10-01", "end": "2025-10-08"}
+  ],
+  "workdays": ["2025-09-28", "2025-10-11"]
+}`
+
+func TestExclusionCalendar(t *testing.T) {
+	cal := NewExclusionCalendar().
+		AddDates(date(2025, 5, 1, 0, 0)).
+		AddAnnual(time.December, 25).
+		AddRange(date(2025, 6, 1, 2, 0), date(2025, 6, 1, 4, 0)).
+		AddRange(date(2025, 6, 1, 3, 0), date(2025, 6, 1, 5, 0))
+
+	assert.True(t, cal.IsExcluded(date(2025, 5, 1, 23, 59)))
+	assert.False(t, cal.IsExcluded(date(2025, 5, 2, 0, 0)))
+	assert.True(t, cal.IsExcluded(date(2031, 12, 25, 8, 0)))
+	assert.True(t, cal.IsExcluded(date(2025, 6, 1, 2, 0)))
+	assert.False(t, cal.IsExcluded(date(2025, 6, 1, 5, 0)))
+
+	assert.Equal(t, date(2025, 5, 2, 0, 0), cal.NextIncluded(date(2025, 5, 1, 9, 0)))
+	// 重叠区间连续跳过
+	assert.Equal(t, date(2025, 6, 1, 5, 0), cal.NextIncluded(date(2025, 6, 1, 2, 30)))
+	assert.Equal(t, date(2025, 6, 1, 1, 0), cal.NextIncluded(date(2025, 6, 1, 1, 0)))
+}
+
+func TestHolidayCalendar(t *testing.T) {
+	cal, err := LoadChineseHolidayCalendar(strings.NewReader(holidays2025))
+	assert.NoError(t, err)
+
+	name, ok := cal.HolidayName(date(2025, 10, 3, 0, 0))
+	assert.True(t, ok)
+	assert.Equal(t, "国庆节、中秋节", name)
+
+	assert.True(t, cal.IsExcluded(date(2025, 10, 8, 9, 0)))  // 节假日
+	assert.True(t, cal.IsExcluded(date(2025, 10, 12, 9, 0))) // 普通周日
+	assert.True(t, cal.IsWorkday(date(2025, 9, 28, 9, 0)))   // 调休上班的周日
+	assert.True(t, cal.IsWorkday(date(2025, 10, 9, 9, 0)))
+	assert.Equal(t, date(2025, 10, 9, 0, 0), cal.NextIncluded(date(2025, 10, 1, 9, 0)))
+
+	// 多年份数组
+	cal, err = LoadChineseHolidayCalendar(strings.NewReader("[" + holidays2025 + `,{"year":2026,"holidays":[{"name":"元旦","start":"2026-01-01"}]}]`))
+	assert.NoError(t, err)
+	assert.True(t, cal.IsExcluded(date(2026, 1, 1, 9, 0)))
+
+	_, err = LoadChineseHolidayCalendar(strings.NewReader(`{"holidays":[{"name":"x","start":"bad"}]}`))
+	assert.Error(t, err)
+}
+
+func TestCalendarSchedule(t *testing.T) {
+	holidays, _ := LoadChineseHolidayCalendar(strings.NewReader(holidays2025))
+	maintenance := NewExclusionCalendar().AddRange(date(2025, 10, 9, 9, 0), date(2025, 10, 9, 10, 0))
+
+	schedule, err := ParseCronStandard("30 9 * * *")
+	assert.NoError(t, err)
+	cs := NewCalendarSchedule(schedule, holidays, maintenance)
+
+	// 跳过国庆假期和 10/9 的维护窗口
+	assert.Equal(t, date(2025, 10, 10, 9, 30), cs.Next(date(2025, 9, 30, 10, 0)))
+	// 10/11 是调休上班的周六，10/12 是周日
+	assert.Equal(t, date(2025, 10, 11, 9, 30), cs.Next(date(2025, 10, 10, 9, 30)))
+	assert.Equal(t, date(2025, 10, 13, 9, 30), cs.Next(date(2025, 10, 11, 9, 30)))
+
+	// 触发时间全部被排除时返回零值
+	saturday, _ := ParseCronStandard("30 9 * * SAT")
+	assert.True(t, NewCalendarSchedule(saturday, NewHolidayCalendar()).Next(date(2025, 1, 1, 0, 0)).IsZero())
+}
+
+func TestLoadJSONCalendar(t *testing.T) {
+	cal, err := LoadJSONCalendar(strings.NewReader(`{
+		"dates": ["2025-05-01"],
+		"annual": ["12-25"],
+		"ranges": [
+			{"start": "2025-06-01T02:00:00Z", "end": "2025-06-01T04:00:00Z"},
+			{"start": "2025-07-01", "end": "2025-07-03"}
+		]
+	}`))
+	assert.NoError(t, err)
+	assert.True(t, cal.IsExcluded(date(2025, 5, 1, 12, 0)))
+	assert.True(t, cal.IsExcluded(date(2026, 12, 25, 12, 0)))
+	assert.True(t, cal.IsExcluded(time.Date(2025, 6, 1, 3, 0, 0, 0, time.UTC)))
+	assert.True(t, cal.IsExcluded(date(2025, 7, 3, 23, 0)))
+	assert.False(t, cal.IsExcluded(date(2025, 7, 4, 0, 0)))
+
+	for _, bad := range []string{`{`, `{"dates":["2025/05/01"]}`, `{"annual":["13-01"]}`, `{"ranges":[{"start":"x","end":"2025-01-01"}]}`} {
+		_, err := LoadJSONCalendar(strings.NewReader(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestLoadICalCalendar(t *testing.T) {
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"BEGIN:VEVENT",
+		"SUMMARY:New Year",
+		"DTSTART;VALUE=DATE:20250101",
+		"RRULE:FREQ=YEARLY",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"SUMMARY:Company ",
+		" offsite",
+		"DTSTART;VALUE=DATE:20250303",
+		"DTEND;VALUE=DATE:20250305",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART:20250601T020000Z",
+		"DTEND:20250601T040000Z",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"DTSTART;TZID=Asia/Shanghai:20250701T020000",
+		"DTEND;TZID=Asia/Shanghai:20250701T030000",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	cal, err := LoadICalCalendar(strings.NewReader(ics))
+	assert.NoError(t, err)
+	assert.True(t, cal.IsExcluded(date(2030, 1, 1, 8, 0)))
+	assert.True(t, cal.IsExcluded(date(2025, 3, 4, 8, 0)))
+	assert.False(t, cal.IsExcluded(date(2025, 3, 5, 8, 0)))
+	assert.True(t, cal.IsExcluded(time.Date(2025, 6, 1, 3, 0, 0, 0, time.UTC)))
+	assert.True(t, cal.IsExcluded(time.Date(2025, 6, 30, 18, 30, 0, 0, time.UTC)))
+
+	for _, bad := range []string{
+		"END:VEVENT",
+		"BEGIN:VEVENT\nDTEND:20250101\nEND:VEVENT",
+		"BEGIN:VEVENT\nDTSTART:2025\nEND:VEVENT",
+		"BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250101\nRRULE:FREQ=WEEKLY\nEND:VEVENT",
+	} {
+		_, err := LoadICalCalendar(strings.NewReader(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestLoadCalendarFile(t *testing.T) {
+	dir := t.TempDir()
+	jsonPath := filepath.Join(dir, "exclusions.json")
+	icsPath := filepath.Join(dir, "holidays.ics")
+	holidayPath := filepath.Join(dir, "cn-2025.json")
+	assert.NoError(t, os.WriteFile(jsonPath, []byte(`{"dates":["2025-05-01"]}`), 0o644))
+	assert.NoError(t, os.WriteFile(icsPath, []byte("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20250501\nEND:VEVENT\n"), 0o644))
+	assert.NoError(t, os.WriteFile(holidayPath, []byte(holidays2025), 0o644))
+
+	for _, path := range []string{jsonPath, icsPath} {
+		cal, err := LoadCalendarFile(path)
+		assert.NoError(t, err)
+		assert.True(t, cal.IsExcluded(date(2025, 5, 1, 12, 0)))
+	}
+
+	holidays, err := LoadChineseHolidayFile(holidayPath)
+	assert.NoError(t, err)
+	assert.True(t, holidays.IsExcluded(date(2025, 1, 1, 12, 0)))
+
+	_, err = LoadCalendarFile(filepath.Join(dir, "calendar.txt"))
+	assert.Error(t, err)
+	_, err = LoadChineseHolidayFile(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestScheduler_Calendar(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{})
+	s.now = func() time.Time { return date(2025, 4, 30, 12, 0) }
+
+	id, err := s.AddJob("0 0 9 * * *", func(ctx context.Context) error { return nil }, JobConfig{
+		Calendar: NewExclusionCalendar().AddDates(date(2025, 5, 1, 0, 0)),
+	})
+	assert.NoError(t, err)
+	entry, _ := s.Entry(id)
+	assert.Equal(t, date(2025, 5, 2, 9, 0), entry.Next)
+}
@@ -22,8 +22,12 @@ var (
 		CronDom,
 		CronMonth,
 		CronDow,
+		CronYear,
 	}
 
+	// cron 字段名称
+	cronFieldNames = []string{"秒", "分钟", "小时", "日期", "月份", "星期", "年份"}
+
 	// cron 字段默认值
 	cronDefaults = []string{
 		"0", // 秒
@@ -32,6 +36,7 @@ var (
 		"*", // 日
 		"*", // 月
 		"*", // 周
+		"*", // 年
 	}
 )
 
@@ -50,6 +55,13 @@ var (
 		"sun": 0, "mon": 1, "tue": 2, "wed": 3,
 		"thu": 4, "fri": 5, "sat": 6,
 	}}
+	cronYears = cronBounds{Min: 1970, Max: 2199}
+
+	// 按字段位置排列的取值范围
+	cronFieldBounds = []cronBounds{cronSeconds, cronMinutes, cronHours, cronDom, cronMonths, cronDow, cronYears}
+
+	// 哈希字段 H 在日期字段的默认范围，避开月末不存在的日期
+	cronHashDom = cronBounds{Min: 1, Max: 28}
 )
 
 // 预计算的位掩码常量
@@ -68,6 +80,7 @@ const (
 	cronNthChar      = '#' // # 字符 - 第几个星期X
 	cronCalendarChar = 'C' // C 字符 - 日历关联
 	cronQuestionChar = '?' // ? 字符 - 不关心该字段
+	cronHashChar     = 'H' // H 字符 - 哈希取值
 )
 
 // 常用的单个时间点位掩码
@@ -0,0 +1,498 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-27 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-27 10:00:00
+ * @FilePath: \go-toolbox\pkg\cron\describe.go
+ * @Description: Cron 表达式自然语言描述(支持中文和英文)
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+)
+
+// DescribeLocale 描述语言
+type DescribeLocale string
+
+const (
+	// LocaleChinese 中文
+	LocaleChinese DescribeLocale = "zh"
+	// LocaleEnglish 英文
+	LocaleEnglish DescribeLocale = "en"
+)
+
+// DescribeOptions 描述选项
+type DescribeOptions struct {
+	Locale DescribeLocale // 描述语言，默认中文
+	Parser *CronParser    // 解析器，默认 DefaultSchedulerParser(秒字段可选，支持描述符)
+}
+
+// Describe 将 cron 表达式转换为自然语言描述
+//
+// 示例：
+//
+//	desc, _ := Describe("0 0/15 9-17 ? * MON-FRI", DescribeOptions{Locale: LocaleEnglish})
+//	// Every 15 minutes, between 09:00 and 17:59, Monday through Friday
+//
+//	desc, _ = Describe("0 0/15 9-17 ? * MON-FRI", DescribeOptions{})
+//	// 每 15 分钟，在 09:00 至 17:59 之间，周一至周五
+func Describe(spec string, opts DescribeOptions) (string, error) {
+	parser := mathx.IF(opts.Parser == nil, DefaultSchedulerParser, opts.Parser)
+	schedule, err := parser.Parse(spec)
+	if err != nil {
+		return "", err
+	}
+	return DescribeSchedule(schedule, opts)
+}
+
+// DescribeSchedule 将已解析的调度转换为自然语言描述
+func DescribeSchedule(schedule CronSchedule, opts DescribeOptions) (string, error) {
+	locale := mathx.IF(opts.Locale == "", LocaleChinese, opts.Locale)
+	words, ok := describeLocales[locale]
+	if !ok {
+		return "", fmt.Errorf("不支持的描述语言: %s", locale)
+	}
+
+	var desc string
+	switch s := schedule.(type) {
+	case *CronSpecSchedule:
+		desc = words.describeSpec(s)
+	case *CronEverySchedule:
+		desc = fmt.Sprintf(words.every, words.duration(s.Duration))
+	default:
+		return "", fmt.Errorf("不支持的调度类型: %T", schedule)
+	}
+
+	if words.capitalize {
+		r, size := utf8.DecodeRuneInString(desc)
+		desc = string(unicode.ToUpper(r)) + desc[size:]
+	}
+	return desc, nil
+}
+
+// unitWords 单个字段的描述模板
+type unitWords struct {
+	every     string // 全部取值，为空表示省略
+	single    string // 单个值：%[1]s
+	rng       string // 连续范围：%[1]s 起，%[2]s 止
+	list      string // 离散列表：%[1]s
+	step      string // 从最小值开始的步长：%[1]d
+	stepRange string // 限定范围的步长：%[1]d 步长，%[2]s 起，%[3]s 止
+}
+
+// describeWords 某种语言的全部描述模板
+type describeWords struct {
+	second, minute, hour, dom, dow, month, year unitWords
+
+	atTime         string    // 具体时刻：%[1]s
+	everyHour      string    // 每小时整点
+	every          string    // @every 间隔：%[1]s
+	lastDay        string    // L
+	lastWeekday    string    // LW
+	nearestWeekday string    // nW：%[1]d
+	lastDow        string    // xL：%[1]s 星期
+	nthDow         string    // x#n：%[1]s 星期，%[2]s 序数
+	ordinals       [5]string // 第 1~5 个
+	weekdays       [7]string
+	months         [12]string
+	units          [4][2]string // 时/分/秒/毫秒的单复数
+	span           string       // 列表中的连续范围：%[1]s 起，%[2]s 止
+	separator      string       // 句子片段之间
+	listSep        string       // 列表元素之间
+	listLast       string       // 列表最后一个元素之前
+	timezone       string       // 时区：%[1]s
+	capitalize     bool         // 句首大写
+}
+
+// describeLocales 已支持的描述语言
+var describeLocales = map[DescribeLocale]*describeWords{
+	LocaleChinese: {
+		second: unitWords{
+			every:     "每秒",
+			single:    "在第 %s 秒",
+			rng:       "在第 %s 至 %s 秒",
+			list:      "在第 %s 秒",
+			step:      "每 %d 秒",
+			stepRange: "在第 %[2]s 至 %[3]s 秒之间每 %[1]d 秒",
+		},
+		minute: unitWords{
+			every:     "每分钟",
+			single:    "在第 %s 分钟",
+			rng:       "在第 %s 至 %s 分钟",
+			list:      "在第 %s 分钟",
+			step:      "每 %d 分钟",
+			stepRange: "在第 %[2]s 至 %[3]s 分钟之间每 %[1]d 分钟",
+		},
+		hour: unitWords{
+			rng:       "在 %s 至 %s 之间",
+			list:      "在 %s",
+			step:      "每 %d 小时",
+			stepRange: "在 %[2]s 至 %[3]s 之间每 %[1]d 小时",
+		},
+		dom: unitWords{
+			single:    "每月 %s 日",
+			rng:       "每月 %s 至 %s 日",
+			list:      "每月 %s 日",
+			step:      "每 %d 天",
+			stepRange: "每月 %[2]s 至 %[3]s 日之间每 %[1]d 天",
+		},
+		dow: unitWords{
+			single: "仅%s",
+			rng:    "%s至%s",
+			list:   "仅%s",
+		},
+		month: unitWords{
+			single:    "仅%s",
+			rng:       "%s至%s",
+			list:      "仅%s",
+			step:      "每 %d 个月",
+			stepRange: "%[2]s至%[3]s每 %[1]d 个月",
+		},
+		year: unitWords{
+			single:    "仅 %s 年",
+			rng:       "%s 至 %s 年",
+			list:      "仅 %s 年",
+			step:      "每 %d 年",
+			stepRange: "%[2]s 至 %[3]s 年每 %[1]d 年",
+		},
+		atTime:         "在 %s",
+		everyHour:      "每小时",
+		every:          "每 %s",
+		lastDay:        "每月最后一天",
+		lastWeekday:    "每月最后一个工作日",
+		nearestWeekday: "每月离 %d 日最近的工作日",
+		lastDow:        "每月最后一个%s",
+		nthDow:         "每月第%[2]s个%[1]s",
+		ordinals:       [5]string{"一", "二", "三", "四", "五"},
+		weekdays:       [7]string{"周日", "周一", "周二", "周三", "周四", "周五", "周六"},
+		months:         [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+		units:          [4][2]string{{"小时", "小时"}, {"分钟", "分钟"}, {"秒", "秒"}, {"毫秒", "毫秒"}},
+		span:           "%s至%s",
+		separator:      "，",
+		listSep:        "、",
+		listLast:       "、",
+		timezone:       "（%s）",
+	},
+	LocaleEnglish: {
+		second: unitWords{
+			every:     "every second",
+			single:    "at %s seconds past the minute",
+			rng:       "seconds %s through %s past the minute",
+			list:      "at %s seconds past the minute",
+			step:      "every %d seconds",
+			stepRange: "every %[1]d seconds, seconds %[2]s through %[3]s past the minute",
+		},
+		minute: unitWords{
+			every:     "every minute",
+			single:    "at %s minutes past the hour",
+			rng:       "minutes %s through %s past the hour",
+			list:      "at %s minutes past the hour",
+			step:      "every %d minutes",
+			stepRange: "every %[1]d minutes, minutes %[2]s through %[3]s past the hour",
+		},
+		hour: unitWords{
+			rng:       "between %s and %s",
+			list:      "at %s",
+			step:      "every %d hours",
+			stepRange: "every %[1]d hours, between %[2]s and %[3]s",
+		},
+		dom: unitWords{
+			single:    "on day %s of the month",
+			rng:       "between day %s and %s of the month",
+			list:      "on day %s of the month",
+			step:      "every %d days",
+			stepRange: "every %[1]d days, between day %[2]s and %[3]s of the month",
+		},
+		dow: unitWords{
+			single: "only on %s",
+			rng:    "%s through %s",
+			list:   "only on %s",
+		},
+		month: unitWords{
+			single:    "only in %s",
+			rng:       "%s through %s",
+			list:      "only in %s",
+			step:      "every %d months",
+			stepRange: "every %[1]d months, %[2]s through %[3]s",
+		},
+		year: unitWords{
+			single:    "only in %s",
+			rng:       "%s through %s",
+			list:      "only in %s",
+			step:      "every %d years",
+			stepRange: "every %[1]d years, %[2]s through %[3]s",
+		},
+		atTime:         "at %s",
+		everyHour:      "every hour",
+		every:          "every %s",
+		lastDay:        "on the last day of the month",
+		lastWeekday:    "on the last weekday of the month",
+		nearestWeekday: "on the weekday nearest day %d of the month",
+		lastDow:        "on the last %s of the month",
+		nthDow:         "on the %[2]s %[1]s of the month",
+		ordinals:       [5]string{"first", "second", "third", "fourth", "fifth"},
+		weekdays:       [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		months:         [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		units:          [4][2]string{{"hour", "hours"}, {"minute", "minutes"}, {"second", "seconds"}, {"millisecond", "milliseconds"}},
+		span:           "%s through %s",
+		separator:      ", ",
+		listSep:        ", ",
+		listLast:       " and ",
+		timezone:       " (%s)",
+		capitalize:     true,
+	},
+}
+
+// maxListedTimes 逐一列出具体时刻的最大数量
+const maxListedTimes = 6
+
+// 字段取值形态
+const (
+	patternAll    = iota // 全部取值
+	patternSingle        // 单个值
+	patternRange         // 连续范围
+	patternStep          // 等差序列
+	patternList          // 离散列表
+)
+
+// fieldPattern 从位掩码归纳出的字段取值形态
+type fieldPattern struct {
+	kind     int
+	values   []uint
+	from, to uint
+	step     uint
+}
+
+// analyzeField 将位掩码归纳为全部、单值、范围、步长或列表
+// allowStep 为 false 时等差序列按列表处理(如星期 MON,WED,FRI)
+func analyzeField(bits uint64, bounds cronBounds, allowStep bool) fieldPattern {
+	var values []uint
+	for v := bounds.Min; v <= bounds.Max; v++ {
+		if bits&(1<<v) != 0 {
+			values = append(values, v)
+		}
+	}
+	return analyzeValues(values, bounds, allowStep)
+}
+
+// analyzeValues 将已排序的取值归纳为全部、单值、范围、步长或列表
+func analyzeValues(values []uint, bounds cronBounds, allowStep bool) fieldPattern {
+	p := fieldPattern{kind: patternList, values: values}
+	if len(values) == 0 {
+		return p
+	}
+	p.from, p.to = values[0], values[len(values)-1]
+
+	switch {
+	case uint(len(values)) == bounds.Max-bounds.Min+1:
+		p.kind = patternAll
+	case len(values) == 1:
+		p.kind = patternSingle
+	case p.to-p.from+1 == uint(len(values)):
+		p.kind = patternRange
+	case allowStep && isArithmetic(values):
+		// 两个值的等差序列仅在形如 */30 时视为步长
+		p.step = values[1] - values[0]
+		if len(values) > 2 || (p.from == bounds.Min && p.to+p.step > bounds.Max) {
+			p.kind = patternStep
+		}
+	}
+	return p
+}
+
+// isArithmetic 判断是否为等差序列
+func isArithmetic(values []uint) bool {
+	for i := 2; i < len(values); i++ {
+		if values[i]-values[i-1] != values[1]-values[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// describeSpec 描述 CronSpecSchedule
+func (w *describeWords) describeSpec(s *CronSpecSchedule) string {
+	parts := append(w.describeTime(s), w.describeDays(s)...)
+	if month := analyzeField(s.Month, cronMonths, true); month.kind != patternAll {
+		parts = append(parts, w.describeField(w.month, month, cronMonths, w.monthName, w.monthName))
+	}
+	if s.Year != [4]uint64{} {
+		var years []uint
+		for y := cronYears.Min; y <= cronYears.Max; y++ {
+			if s.matchYear(int(y)) {
+				years = append(years, y)
+			}
+		}
+		year := analyzeValues(years, cronYears, true)
+		parts = append(parts, w.describeField(w.year, year, cronYears, numberString, numberString))
+	}
+
+	desc := strings.Join(parts, w.separator)
+	if s.Location != nil && s.Location != time.Local {
+		desc += fmt.Sprintf(w.timezone, s.Location.String())
+	}
+	return desc
+}
+
+// describeTime 描述时、分、秒
+func (w *describeWords) describeTime(s *CronSpecSchedule) []string {
+	second := analyzeField(s.Second, cronSeconds, true)
+	minute := analyzeField(s.Minute, cronMinutes, true)
+	hour := analyzeField(s.Hour, cronHours, true)
+
+	// 秒和分固定时，小时取值不多则逐一列出具体时刻
+	if second.kind == patternSingle && minute.kind == patternSingle {
+		switch {
+		case hour.kind != patternAll && len(hour.values) <= maxListedTimes:
+			times := make([]string, len(hour.values))
+			for i, h := range hour.values {
+				times[i] = clockString(h, minute.from, second.from)
+			}
+			return []string{fmt.Sprintf(w.atTime, w.join(times))}
+		case hour.kind == patternAll && minute.from == 0 && second.from == 0:
+			return []string{w.everyHour}
+		}
+	}
+
+	var parts []string
+	if second.kind != patternSingle || second.from != 0 {
+		parts = append(parts, w.describeField(w.second, second, cronSeconds, numberString, numberString))
+	}
+	if minute.kind != patternAll || len(parts) == 0 {
+		parts = append(parts, w.describeField(w.minute, minute, cronMinutes, numberString, numberString))
+	}
+	if hour.kind == patternSingle {
+		hour.kind = patternRange
+	}
+	if hour.kind != patternAll {
+		hourStart := func(v uint) string { return clockString(v, 0, 0) }
+		hourEnd := func(v uint) string { return clockString(v, 59, 0) }
+		parts = append(parts, w.describeField(w.hour, hour, cronHours, hourStart, hourEnd))
+	}
+	return parts
+}
+
+// describeDays 描述日期和星期(含 L、W、# 特殊字符)
+func (w *describeWords) describeDays(s *CronSpecSchedule) []string {
+	var parts []string
+	switch {
+	case s.LastWeekday:
+		parts = append(parts, w.lastWeekday)
+	case s.LastDay:
+		parts = append(parts, w.lastDay)
+	case s.NearestWeekday > 0:
+		parts = append(parts, fmt.Sprintf(w.nearestWeekday, s.NearestWeekday))
+	case s.Dom&cronStarBit == 0 && s.Dom != 0:
+		dom := analyzeField(s.Dom, cronDom, true)
+		parts = append(parts, w.describeField(w.dom, dom, cronDom, numberString, numberString))
+	}
+
+	switch {
+	case s.NthDow > 0:
+		nth := s.NthDow % 10
+		parts = append(parts, fmt.Sprintf(w.nthDow, w.weekdays[s.NthDow/10], w.ordinals[nth-1]))
+	case s.Dow == 0 && s.LastDow >= 0:
+		parts = append(parts, fmt.Sprintf(w.lastDow, w.weekdays[s.LastDow]))
+	case s.Dow&cronStarBit == 0 && s.Dow != 0:
+		dow := analyzeField(s.Dow, cronDow, false)
+		if dow.kind != patternAll {
+			parts = append(parts, w.describeField(w.dow, dow, cronDow, w.weekdayName, w.weekdayName))
+		}
+	}
+	return parts
+}
+
+// describeField 按取值形态套用模板，start/end 分别格式化范围的起止值
+func (w *describeWords) describeField(u unitWords, p fieldPattern, bounds cronBounds, start, end func(uint) string) string {
+	switch p.kind {
+	case patternAll:
+		return u.every
+	case patternSingle:
+		return fmt.Sprintf(u.single, start(p.from))
+	case patternRange:
+		return fmt.Sprintf(u.rng, start(p.from), end(p.to))
+	case patternStep:
+		if p.from == bounds.Min && p.to+p.step > bounds.Max {
+			return fmt.Sprintf(u.step, p.step)
+		}
+		return fmt.Sprintf(u.stepRange, p.step, start(p.from), end(p.to))
+	}
+
+	// 列表中连续 3 个及以上的值合并为范围
+	var items []string
+	for i := 0; i < len(p.values); {
+		j := i
+		for j+1 < len(p.values) && p.values[j+1] == p.values[j]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			items = append(items, fmt.Sprintf(w.span, start(p.values[i]), end(p.values[j])))
+			i = j + 1
+			continue
+		}
+		items = append(items, start(p.values[i]))
+		i++
+	}
+	return fmt.Sprintf(u.list, w.join(items))
+}
+
+// join 按语言习惯连接列表
+func (w *describeWords) join(items []string) string {
+	if len(items) <= 1 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], w.listSep) + w.listLast + items[len(items)-1]
+}
+
+// duration 描述间隔时长，如 1 hour 30 minutes / 1 小时 30 分钟
+func (w *describeWords) duration(d time.Duration) string {
+	amounts := []time.Duration{
+		d / time.Hour,
+		d % time.Hour / time.Minute,
+		d % time.Minute / time.Second,
+		d % time.Second / time.Millisecond,
+	}
+	var parts []string
+	for i, n := range amounts {
+		if n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, w.units[i][mathx.IF(n == 1, 0, 1)]))
+		}
+	}
+	if len(parts) == 0 {
+		return d.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// weekdayName 星期名称
+func (w *describeWords) weekdayName(v uint) string {
+	return w.weekdays[v]
+}
+
+// monthName 月份名称
+func (w *describeWords) monthName(v uint) string {
+	return w.months[v-1]
+}
+
+// numberString 数字
+func numberString(v uint) string {
+	return fmt.Sprintf("%d", v)
+}
+
+// clockString 格式化为 HH:MM，秒不为 0 时为 HH:MM:SS
+func clockString(hour, minute, second uint) string {
+	if second != 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", hour, minute, second)
+	}
+	return fmt.Sprintf("%02d:%02d", hour, minute)
+}
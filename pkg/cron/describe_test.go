@@ -0,0 +1,76 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-27 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-27 10:00:00
+ * @FilePath: \go-toolbox\pkg\cron\describe_test.go
+ * @Description: Cron 表达式自然语言描述测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package cron
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		spec string
+		en   string
+		zh   string
+	}{
+		{"0 0/15 9-17 ? * MON-FRI", "Every 15 minutes, between 09:00 and 17:59, Monday through Friday", "每 15 分钟，在 09:00 至 17:59 之间，周一至周五"},
+		{"* * * * *", "Every minute", "每分钟"},
+		{"* * * * * *", "Every second", "每秒"},
+		{"*/10 * * * * *", "Every 10 seconds", "每 10 秒"},
+		{"5 * * * * *", "At 5 seconds past the minute", "在第 5 秒"},
+		{"0 5/15 * * * *", "Every 15 minutes, minutes 5 through 50 past the hour", "在第 5 至 50 分钟之间每 15 分钟"},
+		{"0 0 * * * *", "Every hour", "每小时"},
+		{"30 9 * * *", "At 09:30", "在 09:30"},
+		{"15 30 9 * * *", "At 09:30:15", "在 09:30:15"},
+		{"0 30 9,12,18 * * *", "At 09:30, 12:30 and 18:30", "在 09:30、12:30、18:30"},
+		{"0 0 */3 * * *", "At 0 minutes past the hour, every 3 hours", "在第 0 分钟，每 3 小时"},
+		{"0 0 0 L * ?", "At 00:00, on the last day of the month", "在 00:00，每月最后一天"},
+		{"0 0 0 LW * ?", "At 00:00, on the last weekday of the month", "在 00:00，每月最后一个工作日"},
+		{"0 0 0 15W * ?", "At 00:00, on the weekday nearest day 15 of the month", "在 00:00，每月离 15 日最近的工作日"},
+		{"0 0 12 ? * 6L", "At 12:00, on the last Saturday of the month", "在 12:00，每月最后一个周六"},
+		{"0 0 12 ? * WED#3", "At 12:00, on the third Wednesday of the month", "在 12:00，每月第三个周三"},
+		{"0 0 0 * * MON,WED,FRI", "At 00:00, only on Monday, Wednesday and Friday", "在 00:00，仅周一、周三、周五"},
+		{"0 0 0 1-5,10,20-25 JAN-MAR *", "At 00:00, on day 1 through 5, 10 and 20 through 25 of the month, January through March", "在 00:00，每月 1至5、10、20至25 日，1月至3月"},
+		{"0 0 0 1,15 */3 *", "At 00:00, on day 1 and 15 of the month, every 3 months", "在 00:00，每月 1、15 日，每 3 个月"},
+		{"@lunch_time", "At 11:00, 12:00 and 13:00", "在 11:00、12:00、13:00"},
+		{"@weekly", "At 00:00, only on Sunday", "在 00:00，仅周日"},
+		{"@every 1h30m", "Every 1 hour 30 minutes", "每 1 小时 30 分钟"},
+		{"CRON_TZ=Asia/Shanghai 0 0 9 * * *", "At 09:00 (Asia/Shanghai)", "在 09:00（Asia/Shanghai）"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			en, err := Describe(tt.spec, DescribeOptions{Locale: LocaleEnglish})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.en, en)
+
+			zh, err := Describe(tt.spec, DescribeOptions{})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.zh, zh)
+		})
+	}
+}
+
+func TestDescribe_Errors(t *testing.T) {
+	_, err := Describe("invalid", DescribeOptions{})
+	assert.Error(t, err)
+
+	_, err = Describe("0 0 * * *", DescribeOptions{Locale: "fr"})
+	assert.Error(t, err)
+
+	// 自定义解析器不支持描述符
+	_, err = Describe("@daily", DescribeOptions{Parser: NewCronParser(CronMinute | CronHour | CronDom | CronMonth | CronDow)})
+	assert.Error(t, err)
+
+	_, err = DescribeSchedule(nil, DescribeOptions{})
+	assert.Error(t, err)
+}
@@ -17,9 +17,15 @@ package cron
 // - types.go: 基本类型定义(CronParseOption, CronSchedule, CronSpecSchedule 等)
 // - constants.go: 常量定义(字段范围、位掩码等)
 // - parser.go: 解析器实现(NewCronParser, Parse 等)
-// - schedule.go: 调度逻辑实现(Next 方法等)
+// - schedule.go: 调度逻辑实现(Next/Prev/NextN/Between 方法等)
 // - descriptor.go: 描述符解析(@yearly, @monthly 等)
 // - expression.go: 表达式解析辅助函数(ParseFieldToBits 等)
+// - scheduler.go: 任务调度器(Scheduler)
+// - describe.go: 表达式自然语言描述(Describe)
+// - hash.go: 哈希字段 H 的展开(CronHash)
+// - calendar.go: 排除日历与日历感知的调度(Calendar, CalendarSchedule)
+// - calendar_loader.go: 从 JSON / iCal / 法定节假日文件加载日历
+// - validate.go: 表达式校验诊断(Validate)
 //
 // 使用示例：
 //
@@ -39,7 +45,24 @@ package cron
 //	schedule, err := ParseCronStandard("@every 1h30m")
 //	nextTime := schedule.Next(time.Now())
 //
+//	Quartz 格式(秒 分 时 日 月 周 [年])
+//	schedule, err := ParseQuartz("0 0 12 ? * WED 2025-2027")
+//	diags := QuartzParser.Validate("0 0 25 ? * MON 2025") // 定位到出错的字段和片段
+//
 //	自定义解析器
 //	parser := NewCronParser(CronSecond | CronMinute | CronHour | CronDom | CronMonth | CronDow)
 //	schedule, err := parser.Parse("*/5 * * * * *")
 //	nextTime := schedule.Next(time.Now())
+//
+//	查询上次及后续若干次执行时间
+//	prev := schedule.(*CronSpecSchedule).Prev(time.Now())
+//	for t := range schedule.(*CronSpecSchedule).Between(start, end) { ... }
+//
+//	生成可读描述
+//	desc, err := Describe("0 0/15 9-17 ? * MON-FRI", DescribeOptions{Locale: LocaleEnglish})
+//
+//	使用调度器运行任务
+//	scheduler := NewScheduler(SchedulerConfig{})
+//	id, err := scheduler.AddJob("0 */5 * * * *", job, JobConfig{Overlap: OverlapSkip, Timeout: time.Minute})
+//	scheduler.Start()
+//	defer scheduler.Stop()
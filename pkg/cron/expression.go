@@ -258,6 +258,39 @@ func validateFieldExpr[T types.Numerical](start, end, step T, bounds types.Bound
 	return nil
 }
 
+// parseYearField 解析年份字段，返回相对 1970 年的位集合
+// 年份范围超出 64 位，因此不复用 ParseFieldToBits；* 和 ? 返回全零表示不限制
+func parseYearField(field string) (years [4]uint64, err error) {
+	if field == "*" || field == "?" {
+		return years, nil
+	}
+	for _, expr := range strings.Split(field, ",") {
+		parts := strings.Split(expr, "/")
+		if len(parts) > 2 {
+			return years, errorx.NewInvalidFormatError(fmt.Sprintf("表达式 '%s' 包含过多斜杠", expr))
+		}
+		start, end, _, err := parseFieldExpr(parts[0], cronYears)
+		if err != nil {
+			return years, err
+		}
+		step := uint(1)
+		if len(parts) == 2 {
+			if step, err = ParseIntOrName[uint](parts[1], nil); err != nil {
+				return years, errorx.WrapError("解析步长失败", err)
+			}
+			end = mathx.IF(start == end, cronYears.Max, end)
+		}
+		if err := validateFieldExpr(start, end, step, cronYears); err != nil {
+			return years, err
+		}
+		for y := start; y <= end; y += step {
+			offset := y - cronYears.Min
+			years[offset/64] |= 1 << (offset % 64)
+		}
+	}
+	return years, nil
+}
+
 // ParseIntOrName 解析整数或命名值
 func ParseIntOrName[T types.Numerical](expr string, names map[string]T) (T, error) {
 	var zero T
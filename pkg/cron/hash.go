@@ -0,0 +1,105 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-27 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-27 14:00:00
+ * @FilePath: \go-toolbox\pkg\cron\hash.go
+ * @Description: Jenkins 风格哈希字段 H 的展开
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package cron
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+)
+
+// expandHashedField 将字段中的哈希表达式展开为普通表达式
+// 支持 H、H/step、H(start-end)、H(start-end)/step，可与其他表达式用逗号组合
+//
+// 示例(假设哈希值为 37)：
+//
+//	"H"          -> "37"
+//	"H/15"       -> "7-59/15"
+//	"H(0-29)"    -> "7"
+//	"H(0-29)/10" -> "7-29/10"
+func expandHashedField(field string, place int, key string) (string, error) {
+	if !strings.ContainsRune(field, cronHashChar) {
+		return field, nil
+	}
+
+	bounds := cronFieldBounds[place]
+	hash := hashSeed(key, place)
+
+	exprs := strings.Split(field, ",")
+	for i, expr := range exprs {
+		if len(expr) == 0 || expr[0] != cronHashChar {
+			continue
+		}
+		expanded, err := expandHashedExpr(expr, bounds, cronPlaces[place] == CronDom, hash)
+		if err != nil {
+			return "", err
+		}
+		exprs[i] = expanded
+	}
+	return strings.Join(exprs, ","), nil
+}
+
+// expandHashedExpr 展开单个哈希表达式
+func expandHashedExpr(expr string, bounds cronBounds, isDOM bool, hash uint64) (string, error) {
+	rest := expr[1:]
+
+	// 解析可选范围 H(start-end)
+	start, end := bounds.Min, bounds.Max
+	if isDOM {
+		start, end = cronHashDom.Min, cronHashDom.Max
+	}
+	if strings.HasPrefix(rest, "(") {
+		closing := strings.IndexByte(rest, ')')
+		if closing == -1 {
+			return "", errorx.NewInvalidFormatError(fmt.Sprintf("哈希表达式 '%s' 缺少右括号", expr))
+		}
+		var err error
+		if start, end, _, err = parseFieldExpr(rest[1:closing], bounds); err != nil {
+			return "", errorx.WrapError(fmt.Sprintf("解析哈希范围 '%s' 失败", expr), err)
+		}
+		rest = rest[closing+1:]
+	}
+
+	// 解析可选步长 H/step
+	step, hasStep := uint(1), strings.HasPrefix(rest, "/")
+	if hasStep {
+		var err error
+		if step, err = ParseIntOrName[uint](rest[1:], nil); err != nil {
+			return "", errorx.WrapError(fmt.Sprintf("解析哈希步长 '%s' 失败", expr), err)
+		}
+		rest = ""
+	}
+	if rest != "" {
+		return "", errorx.NewInvalidFormatError(fmt.Sprintf("无效的哈希表达式 '%s'", expr))
+	}
+	if err := validateFieldExpr(start, end, step, bounds); err != nil {
+		return "", err
+	}
+
+	span := uint64(end - start + 1)
+	if !hasStep {
+		return fmt.Sprintf("%d", start+uint(hash%span)), nil
+	}
+	// 偏移量落在第一个步长周期内，保证每个周期都命中一次
+	offset := uint(hash % uint64(min(uint64(step), span)))
+	return fmt.Sprintf("%d-%d/%d", start+offset, end, step), nil
+}
+
+// hashSeed 根据 key 和字段位置计算稳定的哈希值，不同字段互不相关
+func hashSeed(key string, place int) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	_, _ = h.Write([]byte{0, byte(place)})
+	return h.Sum64()
+}
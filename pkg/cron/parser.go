@@ -13,6 +13,8 @@ package cron
 
 import (
 	"fmt"
+	"math/bits"
+	"slices"
 	"strings"
 	"time"
 
@@ -31,14 +33,7 @@ import (
 //	parser := NewCronParser(CronSecond | CronMinute | CronHour | CronDom | CronMonth | CronDow)
 //	schedule, err := parser.Parse("0 0 0 15 */3 *")
 func NewCronParser(options CronParseOption) *CronParser {
-	optionals := 0
-	if options&CronDowOptional > 0 {
-		optionals++
-	}
-	if options&CronSecondOptional > 0 {
-		optionals++
-	}
-	if optionals > 1 {
+	if bits.OnesCount(uint(options&(CronSecondOptional|CronDowOptional|CronYearOptional))) > 1 {
 		panic("不能配置多个可选字段")
 	}
 	return &CronParser{options: options}
@@ -47,6 +42,17 @@ func NewCronParser(options CronParseOption) *CronParser {
 // Parse 解析 cron 表达式并返回调度规范
 // 支持时区、描述符和标准 cron 表达式
 func (p *CronParser) Parse(spec string) (CronSchedule, error) {
+	return p.ParseWithKey(spec, "")
+}
+
+// ParseWithKey 解析 cron 表达式，key 用于计算哈希字段 H 的取值
+// 启用 CronHash 时，相同表达式在不同 key 下分散到区间内的不同时刻，同一 key 的结果保持稳定
+//
+// 示例：
+//
+//	parser := NewCronParser(CronMinute | CronHour | CronDom | CronMonth | CronDow | CronHash)
+//	schedule, err := parser.ParseWithKey("H * * * *", "tenant-42")
+func (p *CronParser) ParseWithKey(spec, key string) (CronSchedule, error) {
 	if len(spec) == 0 {
 		return nil, fmt.Errorf("cron 表达式不能为空")
 	}
@@ -89,107 +95,116 @@ func (p *CronParser) Parse(spec string) (CronSchedule, error) {
 		return nil, err
 	}
 
-	// 创建 schedule 对象
+	// 展开哈希字段
+	if p.options&CronHash > 0 {
+		for i := range fields {
+			if fields[i], err = expandHashedField(fields[i], i, key); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// 创建 schedule 对象并逐个解析字段
 	schedule := &CronSpecSchedule{
 		Location: loc,
 	}
-
-	// 解析秒字段
-	schedule.Second, _, _, _, _, _, err = ParseFieldWithSpecialChars(fields[0], cronSeconds, 0, false, false)
-	if err != nil {
-		return nil, fmt.Errorf("解析秒字段失败: %v", err)
+	for place, field := range fields {
+		if err := parseCronField(schedule, place, field); err != nil {
+			return nil, fmt.Errorf("解析%s字段失败: %v", cronFieldNames[place], err)
+		}
 	}
 
-	// 解析分钟字段
-	schedule.Minute, _, _, _, _, _, err = ParseFieldWithSpecialChars(fields[1], cronMinutes, 0, false, false)
-	if err != nil {
-		return nil, fmt.Errorf("解析分钟字段失败: %v", err)
-	}
+	return schedule, nil
+}
 
-	// 解析小时字段
-	schedule.Hour, _, _, _, _, _, err = ParseFieldWithSpecialChars(fields[2], cronHours, 0, false, false)
-	if err != nil {
-		return nil, fmt.Errorf("解析小时字段失败: %v", err)
-	}
+// parseCronField 解析指定位置的字段并写入 schedule
+func parseCronField(schedule *CronSpecSchedule, place int, field string) (err error) {
+	switch cronPlaces[place] {
+	case CronSecond:
+		schedule.Second, _, _, _, _, _, err = ParseFieldWithSpecialChars(field, cronSeconds, 0, false, false)
+	case CronMinute:
+		schedule.Minute, _, _, _, _, _, err = ParseFieldWithSpecialChars(field, cronMinutes, 0, false, false)
+	case CronHour:
+		schedule.Hour, _, _, _, _, _, err = ParseFieldWithSpecialChars(field, cronHours, 0, false, false)
+	case CronDom:
+		// 支持 L, W, LW
+		schedule.Dom, schedule.LastDay, schedule.LastWeekday, schedule.NearestWeekday, _, _, err =
+			ParseFieldWithSpecialChars(field, cronDom, cronStarBit, true, false)
+	case CronMonth:
+		schedule.Month, _, _, _, _, _, err = ParseFieldWithSpecialChars(field, cronMonths, 0, false, false)
+	case CronDow:
+		// 支持 L, #
+		schedule.Dow, _, _, _, schedule.LastDow, schedule.NthDow, err =
+			ParseFieldWithSpecialChars(field, cronDow, cronStarBit, false, true)
+	case CronYear:
+		schedule.Year, err = parseYearField(field)
+	}
+	return err
+}
 
-	// 解析日期字段 (支持 L, W, LW)
-	schedule.Dom, schedule.LastDay, schedule.LastWeekday, schedule.NearestWeekday, _, _, err =
-		ParseFieldWithSpecialChars(fields[3], cronDom, cronStarBit, true, false)
+// normalizeFields 规范化字段，填充默认值
+// 返回秒、分、时、日、月、周 6 个字段，启用年份字段时再追加年份
+func (p *CronParser) normalizeFields(fields []string) ([]string, error) {
+	places, err := p.fieldPlaces(len(fields))
 	if err != nil {
-		return nil, fmt.Errorf("解析日期字段失败: %v", err)
+		return nil, fmt.Errorf("%v: %s", err, strings.Join(fields, " "))
 	}
 
-	// 解析月份字段
-	schedule.Month, _, _, _, _, _, err = ParseFieldWithSpecialChars(fields[4], cronMonths, 0, false, false)
-	if err != nil {
-		return nil, fmt.Errorf("解析月份字段失败: %v", err)
+	expandedFields := make([]string, len(cronPlaces))
+	copy(expandedFields, cronDefaults)
+	for i, place := range places {
+		expandedFields[place] = fields[i]
 	}
-
-	// 解析星期字段 (支持 L, #)
-	schedule.Dow, _, _, _, schedule.LastDow, schedule.NthDow, err =
-		ParseFieldWithSpecialChars(fields[5], cronDow, cronStarBit, false, true)
-	if err != nil {
-		return nil, fmt.Errorf("解析星期字段失败: %v", err)
+	if p.options&(CronYear|CronYearOptional) == 0 {
+		expandedFields = expandedFields[:len(cronPlaces)-1]
 	}
-
-	return schedule, nil
+	return expandedFields, nil
 }
 
-// normalizeFields 规范化字段，填充默认值
-func (p *CronParser) normalizeFields(fields []string) ([]string, error) {
+// fieldPlaces 验证字段数量，返回表达式中每个字段对应的位置(cronPlaces 的下标)
+func (p *CronParser) fieldPlaces(count int) ([]int, error) {
 	// 验证可选字段并添加到选项中
 	options := p.options
-	if options&CronSecondOptional > 0 {
+	optional := options & (CronSecondOptional | CronDowOptional | CronYearOptional)
+	if optional&CronSecondOptional > 0 {
 		options |= CronSecond
 	}
-	if options&CronDowOptional > 0 {
+	if optional&CronDowOptional > 0 {
 		options |= CronDow
 	}
-	optionals := mathx.IF(options&CronSecondOptional > 0, 1, 0) + mathx.IF(options&CronDowOptional > 0, 1, 0)
+	if optional&CronYearOptional > 0 {
+		options |= CronYear
+	}
+	optionals := bits.OnesCount(uint(optional))
 	if optionals > 1 {
 		return nil, fmt.Errorf("不能配置多个可选字段")
 	}
 
 	// 计算需要的字段数量
-	max := 0
-	for _, place := range cronPlaces {
+	var places []int
+	for i, place := range cronPlaces {
 		if options&place > 0 {
-			max++
+			places = append(places, i)
 		}
 	}
+	max := len(places)
 	min := max - optionals
 
 	// 验证字段数量
-	if count := len(fields); count < min || count > max {
-		msg := mathx.IF(min == max,
-			fmt.Sprintf("期望 %d 个字段，实际 %d 个: %s", min, count, strings.Join(fields, " ")),
-			fmt.Sprintf("期望 %d 到 %d 个字段，实际 %d 个: %s", min, max, count, strings.Join(fields, " ")),
-		)
-		return nil, fmt.Errorf("%s", msg)
-	}
-
-	// 如果未提供可选字段，则填充默认值
-	if min < max && len(fields) == min {
-		if options&CronDowOptional > 0 {
-			fields = append(fields, cronDefaults[5])
-		} else if options&CronSecondOptional > 0 {
-			fields = append([]string{cronDefaults[0]}, fields...)
-		} else {
-			return nil, fmt.Errorf("未知的可选字段")
-		}
+	if count < min || count > max {
+		return nil, fmt.Errorf("%s", mathx.IF(min == max,
+			fmt.Sprintf("期望 %d 个字段，实际 %d 个", min, count),
+			fmt.Sprintf("期望 %d 到 %d 个字段，实际 %d 个", min, max, count),
+		))
 	}
 
-	// 填充不在选项中的字段为默认值
-	n := 0
-	expandedFields := make([]string, len(cronPlaces))
-	copy(expandedFields, cronDefaults)
-	for i, place := range cronPlaces {
-		if options&place > 0 {
-			expandedFields[i] = fields[n]
-			n++
-		}
+	// 省略可选字段时去掉其位置
+	if count < max {
+		omitted := mathx.IF(optional&CronSecondOptional > 0, CronSecond,
+			mathx.IF(optional&CronDowOptional > 0, CronDow, CronYear))
+		places = slices.DeleteFunc(places, func(i int) bool { return cronPlaces[i] == omitted })
 	}
-	return expandedFields, nil
+	return places, nil
 }
 
 // CronStandardParser 标准解析器(分 时 日 月 周，5个字段)
@@ -202,6 +217,11 @@ var CronSecondParser = NewCronParser(
 	CronSecond | CronMinute | CronHour | CronDom | CronMonth | CronDow | CronDescriptor,
 )
 
+// QuartzParser Quartz 兼容解析器(秒 分 时 日 月 周 [年]，6或7个字段)
+var QuartzParser = NewCronParser(
+	CronSecond | CronMinute | CronHour | CronDom | CronMonth | CronDow | CronYearOptional | CronDescriptor,
+)
+
 // ParseCronStandard 使用标准解析器解析 cron 表达式(5个字段)
 func ParseCronStandard(spec string) (CronSchedule, error) {
 	return CronStandardParser.Parse(spec)
@@ -211,3 +231,8 @@ func ParseCronStandard(spec string) (CronSchedule, error) {
 func ParseCronWithSeconds(spec string) (CronSchedule, error) {
 	return CronSecondParser.Parse(spec)
 }
+
+// ParseQuartz 使用 Quartz 兼容解析器解析 cron 表达式(6或7个字段，年份可选)
+func ParseQuartz(spec string) (CronSchedule, error) {
+	return QuartzParser.Parse(spec)
+}
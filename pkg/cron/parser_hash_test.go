@@ -0,0 +1,125 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-27 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-27 14:00:00
+ * @FilePath: \go-toolbox\pkg\cron\parser_hash_test.go
+ * @Description: 哈希字段 H 测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package cron
+
+import (
+	"context"
+	"fmt"
+	"math/bits"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var hashParser = NewCronParser(CronSecondOptional | CronMinute | CronHour | CronDom | CronMonth | CronDow | CronHash)
+
+func TestExpandHashedExpr(t *testing.T) {
+	tests := map[string]struct {
+		expr     string
+		bounds   cronBounds
+		isDOM    bool
+		hash     uint64
+		expected string
+	}{
+		"plain":            {expr: "H", bounds: cronMinutes, hash: 37, expected: "37"},
+		"plain_wrap":       {expr: "H", bounds: cronMinutes, hash: 97, expected: "37"},
+		"step":             {expr: "H/15", bounds: cronMinutes, hash: 37, expected: "7-59/15"},
+		"range":            {expr: "H(0-29)", bounds: cronMinutes, hash: 37, expected: "7"},
+		"range_step":       {expr: "H(0-29)/10", bounds: cronMinutes, hash: 37, expected: "7-29/10"},
+		"step_wider":       {expr: "H(10-12)/10", bounds: cronMinutes, hash: 37, expected: "11-12/10"},
+		"dom_default":      {expr: "H", bounds: cronDom, isDOM: true, hash: 28, expected: "1"},
+		"dom_range":        {expr: "H(1-31)", bounds: cronDom, isDOM: true, hash: 30, expected: "31"},
+		"dow_names":        {expr: "H(mon-fri)", bounds: cronDow, hash: 7, expected: "3"},
+		"missing_paren":    {expr: "H(0-29", bounds: cronMinutes},
+		"bad_range":        {expr: "H(0-99)", bounds: cronMinutes},
+		"bad_step":         {expr: "H/x", bounds: cronMinutes},
+		"zero_step":        {expr: "H/0", bounds: cronMinutes},
+		"trailing_garbage": {expr: "Hx", bounds: cronMinutes},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			expanded, err := expandHashedExpr(tt.expr, tt.bounds, tt.isDOM, tt.hash)
+			if tt.expected == "" {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, expanded)
+		})
+	}
+}
+
+func TestCronParser_Hash(t *testing.T) {
+	// 同一 key 结果稳定
+	a, err := hashParser.ParseWithKey("H H * * *", "tenant-1")
+	assert.NoError(t, err)
+	b, _ := hashParser.ParseWithKey("H H * * *", "tenant-1")
+	assert.Equal(t, a, b)
+
+	// 单个取值，且落在范围内
+	spec := a.(*CronSpecSchedule)
+	assert.Equal(t, 1, bits.OnesCount64(spec.Minute))
+	assert.Equal(t, 1, bits.OnesCount64(spec.Hour))
+	assert.Less(t, bits.TrailingZeros64(spec.Minute), 60)
+	assert.Less(t, bits.TrailingZeros64(spec.Hour), 24)
+
+	// 步长保持每个周期命中一次
+	s, err := hashParser.ParseWithKey("H/15 * * * *", "tenant-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, bits.OnesCount64(s.(*CronSpecSchedule).Minute))
+
+	// 与普通表达式组合
+	s, err = hashParser.ParseWithKey("0 H(0-29),45 9 * * MON-FRI", "tenant-1")
+	assert.NoError(t, err)
+	minute := s.(*CronSpecSchedule).Minute
+	assert.Equal(t, 2, bits.OnesCount64(minute))
+	assert.NotZero(t, minute&(1<<45))
+	assert.Less(t, bits.TrailingZeros64(minute), 30)
+}
+
+func TestCronParser_HashSpread(t *testing.T) {
+	minutes := make(map[int]bool)
+	for i := 0; i < 100; i++ {
+		s, err := hashParser.ParseWithKey("H * * * *", fmt.Sprintf("tenant-%d", i))
+		assert.NoError(t, err)
+		minutes[bits.TrailingZeros64(s.(*CronSpecSchedule).Minute)] = true
+	}
+	// 100 个租户应分散到多个分钟
+	assert.Greater(t, len(minutes), 30)
+}
+
+func TestCronParser_HashDisabled(t *testing.T) {
+	// 未启用 CronHash 时 H 不合法
+	_, err := ParseCronStandard("H * * * *")
+	assert.Error(t, err)
+
+	_, err = hashParser.ParseWithKey("H(0-99) * * * *", "tenant-1")
+	assert.Error(t, err)
+}
+
+func TestScheduler_HashedSpec(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{Parser: hashParser, Location: time.UTC})
+	s.now = func() time.Time { return time.Date(2025, 12, 27, 0, 0, 0, 0, time.UTC) }
+	job := func(ctx context.Context) error { return nil }
+
+	// 任务名作为哈希 key
+	id1, err := s.AddJob("H * * * *", job, JobConfig{Name: "a"})
+	assert.NoError(t, err)
+	id2, _ := s.AddJob("H * * * *", job, JobConfig{Name: "a"})
+	e1, _ := s.Entry(id1)
+	e2, _ := s.Entry(id2)
+	assert.Equal(t, e1.Next, e2.Next)
+
+	expected, _ := hashParser.ParseWithKey("H * * * *", "a")
+	assert.Equal(t, expected.Next(s.now()), e1.Next)
+}
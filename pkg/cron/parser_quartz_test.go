@@ -12,6 +12,7 @@ package cron
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -714,3 +715,98 @@ func BenchmarkQuartzParseComplex(b *testing.B) {
 		})
 	}
 }
+
+// TestQuartzCron_YearField 测试 Quartz 第 7 个年份字段
+func TestQuartzCron_YearField(t *testing.T) {
+	tests := map[string]struct {
+		spec      string
+		from      time.Time
+		expected  time.Time
+		expectErr bool
+	}{
+		"year_omitted": {
+			spec:     "0 0 12 * * ?",
+			from:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+		"year_wildcard": {
+			spec:     "0 0 12 * * ? *",
+			from:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+		},
+		"future_year": {
+			spec:     "0 0 0 1 1 ? 2030",
+			from:     time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"year_list": {
+			spec:     "0 0 0 1 1 ? 2026,2028",
+			from:     time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2028, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"year_step": {
+			spec:     "0 0 0 29 2 ? 2000/4",
+			from:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		"year_passed": {
+			spec:     "0 0 0 1 1 ? 2020-2024",
+			from:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			expected: time.Time{},
+		},
+		"year_out_of_range": {
+			spec:      "0 0 0 1 1 ? 2200",
+			expectErr: true,
+		},
+		"year_before_1970": {
+			spec:      "0 0 0 1 1 ? 1969",
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			schedule, err := ParseQuartz(tc.spec)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, schedule.Next(tc.from))
+		})
+	}
+}
+
+// TestQuartzCron_YearPrev 测试带年份字段的上次执行时间
+func TestQuartzCron_YearPrev(t *testing.T) {
+	schedule, err := ParseQuartz("0 30 9 15 6 ? 2021,2023")
+	assert.NoError(t, err)
+	spec := schedule.(*CronSpecSchedule)
+
+	assert.Equal(t, time.Date(2023, 6, 15, 9, 30, 0, 0, time.UTC), spec.Prev(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2021, 6, 15, 9, 30, 0, 0, time.UTC), spec.Prev(time.Date(2023, 6, 15, 9, 30, 0, 0, time.UTC)))
+	assert.True(t, spec.Prev(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero())
+}
+
+// TestQuartzCron_YearOptions 测试年份字段选项
+func TestQuartzCron_YearOptions(t *testing.T) {
+	// 必选年份字段
+	parser := NewCronParser(CronSecond | CronMinute | CronHour | CronDom | CronMonth | CronDow | CronYear)
+	_, err := parser.Parse("0 0 0 1 1 ?")
+	assert.Error(t, err)
+	_, err = parser.Parse("0 0 0 1 1 ? 2030")
+	assert.NoError(t, err)
+
+	// 不能与其他可选字段同时配置
+	assert.Panics(t, func() {
+		NewCronParser(CronSecondOptional | CronMinute | CronHour | CronDom | CronMonth | CronDow | CronYearOptional)
+	})
+
+	// 未启用年份字段时 7 个字段不合法
+	_, err = ParseCronWithSeconds("0 0 0 1 1 ? 2030")
+	assert.Error(t, err)
+
+	desc, err := Describe("0 0 0 1 1 ? 2026,2028", DescribeOptions{Parser: QuartzParser, Locale: LocaleEnglish})
+	assert.NoError(t, err)
+	assert.Equal(t, "At 00:00, on day 1 of the month, only in January, only in 2026 and 2028", desc)
+}
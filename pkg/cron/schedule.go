@@ -11,10 +11,49 @@
 
 package cron
 
-import "time"
+import (
+	"iter"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+)
 
 // Next 计算下次执行时间(支持纳秒精度)
+// 夏令时跳变中不存在的时间会被跳过；回拨导致重复出现的时间，
+// 小时字段非通配时只在第一次出现时触发
 func (s *CronSpecSchedule) Next(t time.Time) time.Time {
+	for {
+		next := s.next(t)
+		if next.IsZero() || !s.isRepeatedOccurrence(next, t.Location()) {
+			return next
+		}
+		t = next
+	}
+}
+
+// Prev 计算上次执行时间，早于给定时间，5 年内无匹配时返回零值
+func (s *CronSpecSchedule) Prev(t time.Time) time.Time {
+	for {
+		prev := s.prev(t)
+		if prev.IsZero() || !s.isRepeatedOccurrence(prev, t.Location()) {
+			return prev
+		}
+		t = prev
+	}
+}
+
+// NextN 计算从给定时间开始的后 n 次执行时间
+func (s *CronSpecSchedule) NextN(t time.Time, n int) []time.Time {
+	return NextN(s, t, n)
+}
+
+// Between 按时间顺序遍历 (start, end] 区间内的所有执行时间
+func (s *CronSpecSchedule) Between(start, end time.Time) iter.Seq[time.Time] {
+	return Between(s, start, end)
+}
+
+// next 计算下次执行时间(不处理夏令时重复时段)
+func (s *CronSpecSchedule) next(t time.Time) time.Time {
 	// 转换到调度器时区
 	origLocation := t.Location()
 	loc := s.Location
@@ -39,11 +78,22 @@ WRAP:
 		return time.Time{}
 	}
 
+	// 查找匹配的年份
+	if !s.matchYear(t.Year()) {
+		year, ok := s.seekYear(t.Year(), 1)
+		if !ok {
+			return time.Time{}
+		}
+		added = true
+		t = startOfDay(year, time.January, 1, loc)
+		yearLimit = year + 5
+	}
+
 	// 查找匹配的月份
 	for !s.matchBit(s.Month, uint(t.Month())) {
 		if !added {
 			added = true
-			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+			t = startOfDay(t.Year(), t.Month(), 1, loc)
 		}
 		t = t.AddDate(0, 1, 0)
 		if t.Month() == time.January {
@@ -55,7 +105,7 @@ WRAP:
 	for !s.matchDayOfMonthAndWeek(t) {
 		if !added {
 			added = true
-			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+			t = startOfDay(t.Year(), t.Month(), t.Day(), loc)
 		}
 		t = t.AddDate(0, 0, 1)
 		if t.Day() == 1 {
@@ -67,7 +117,7 @@ WRAP:
 	for !s.matchBit(s.Hour, uint(t.Hour())) {
 		if !added {
 			added = true
-			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+			t = t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second())*time.Second)
 		}
 		t = t.Add(1 * time.Hour)
 		if t.Hour() == 0 {
@@ -79,7 +129,7 @@ WRAP:
 	for !s.matchBit(s.Minute, uint(t.Minute())) {
 		if !added {
 			added = true
-			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, loc)
+			t = t.Add(-time.Duration(t.Second()) * time.Second)
 		}
 		t = t.Add(1 * time.Minute)
 		if t.Minute() == 0 {
@@ -89,10 +139,7 @@ WRAP:
 
 	// 查找匹配的秒
 	for !s.matchBit(s.Second, uint(t.Second())) {
-		if !added {
-			added = true
-			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, loc)
-		}
+		added = true
 		t = t.Add(1 * time.Second)
 		if t.Second() == 0 {
 			goto WRAP
@@ -102,6 +149,118 @@ WRAP:
 	return t.In(origLocation)
 }
 
+// prev 计算上次执行时间(不处理夏令时重复时段)
+// 每当某个字段不匹配时，跳到该字段上一个单位的最后一秒，再从月份开始重新匹配
+func (s *CronSpecSchedule) prev(t time.Time) time.Time {
+	origLocation := t.Location()
+	loc := s.location(t)
+	t = t.In(loc)
+
+	// 从上一秒开始
+	if truncated := t.Add(-time.Duration(t.Nanosecond())); truncated.Before(t) {
+		t = truncated
+	} else {
+		t = t.Add(-time.Second)
+	}
+
+	// 最多查找 5 年
+	yearLimit := t.Year() - 5
+
+	for t.Year() >= yearLimit {
+		switch {
+		case !s.matchYear(t.Year()):
+			year, ok := s.seekYear(t.Year(), -1)
+			if !ok {
+				return time.Time{}
+			}
+			t = startOfDay(year+1, time.January, 1, loc).Add(-time.Second)
+			yearLimit = year - 5
+		case !s.matchBit(s.Month, uint(t.Month())):
+			t = startOfDay(t.Year(), t.Month(), 1, loc).Add(-time.Second)
+		case !s.matchDayOfMonthAndWeek(t):
+			t = startOfDay(t.Year(), t.Month(), t.Day(), loc).Add(-time.Second)
+		case !s.matchBit(s.Hour, uint(t.Hour())):
+			t = t.Add(-time.Duration(t.Minute())*time.Minute - time.Duration(t.Second()+1)*time.Second)
+		case !s.matchBit(s.Minute, uint(t.Minute())):
+			t = t.Add(-time.Duration(t.Second()+1) * time.Second)
+		case !s.matchBit(s.Second, uint(t.Second())):
+			t = t.Add(-time.Second)
+		default:
+			return t.In(origLocation)
+		}
+	}
+	return time.Time{}
+}
+
+// matchYear 检查年份是否匹配，未限制年份时总是匹配
+func (s *CronSpecSchedule) matchYear(year int) bool {
+	if s.Year == [4]uint64{} {
+		return true
+	}
+	offset := year - int(cronYears.Min)
+	if offset < 0 || offset > int(cronYears.Max-cronYears.Min) {
+		return false
+	}
+	return s.Year[offset/64]&(1<<(offset%64)) != 0
+}
+
+// seekYear 从 year 开始按 step(1 或 -1)查找第一个匹配的年份
+func (s *CronSpecSchedule) seekYear(year, step int) (int, bool) {
+	year = mathx.IF(step > 0, max(year, int(cronYears.Min)), min(year, int(cronYears.Max)))
+	for ; year >= int(cronYears.Min) && year <= int(cronYears.Max); year += step {
+		if s.matchYear(year) {
+			return year, true
+		}
+	}
+	return 0, false
+}
+
+// location 返回计算使用的时区，Local 表示跟随输入时间的时区
+func (s *CronSpecSchedule) location(t time.Time) *time.Location {
+	if s.Location == nil || s.Location == time.Local {
+		return t.Location()
+	}
+	return s.Location
+}
+
+// isRepeatedOccurrence 判断 t 是否为夏令时回拨后重复出现的时间且应当跳过
+// 小时字段为通配(每小时都执行)时不跳过，保持按实际时间流逝触发
+func (s *CronSpecSchedule) isRepeatedOccurrence(t time.Time, inputLoc *time.Location) bool {
+	if s.Hour&cronAllHours == cronAllHours {
+		return false
+	}
+	loc := s.Location
+	if loc == nil || loc == time.Local {
+		loc = inputLoc
+	}
+	return isRepeatedWallTime(t.In(loc))
+}
+
+// isRepeatedWallTime 判断 t 的墙上时间是否在更早的时刻已经出现过(夏令时回拨)
+func isRepeatedWallTime(t time.Time) bool {
+	_, offset := t.Zone()
+	for _, shift := range []time.Duration{30 * time.Minute, time.Hour, 2 * time.Hour} {
+		if _, earlier := t.Add(-shift).Zone(); time.Duration(earlier-offset)*time.Second == shift {
+			return true
+		}
+	}
+	return false
+}
+
+// startOfDay 返回指定日期的第一个时刻(兼容零点落在夏令时跳变中的时区)
+func startOfDay(year int, month time.Month, day int, loc *time.Location) time.Time {
+	start := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	for start.Day() != day && start.Before(time.Date(year, month, day, 12, 0, 0, 0, loc)) {
+		start = start.Add(30 * time.Minute)
+	}
+	return start
+}
+
+// lastDayOfMonth 返回指定月份的最后一天
+func lastDayOfMonth(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
 // matchBit 检查值是否匹配位集合
 func (s *CronSpecSchedule) matchBit(bits uint64, value uint) bool {
 	return bits&(1<<value) != 0
@@ -109,8 +268,8 @@ func (s *CronSpecSchedule) matchBit(bits uint64, value uint) bool {
 
 // matchDayOfMonthAndWeek 检查日期和星期是否匹配
 func (s *CronSpecSchedule) matchDayOfMonthAndWeek(t time.Time) bool {
-	domMatch := s.matchBit(s.Dom, uint(t.Day()))
-	dowMatch := s.matchBit(s.Dow, uint(t.Weekday()))
+	domMatch := s.matchBit(s.Dom, uint(t.Day())) || s.matchSpecialDom(t)
+	dowMatch := s.matchBit(s.Dow, uint(t.Weekday())) || s.matchSpecialDow(t)
 
 	// 如果 Dom 或 Dow 设置了星号位，表示是通配符
 	domStar := s.Dom&cronStarBit != 0
@@ -128,7 +287,105 @@ func (s *CronSpecSchedule) matchDayOfMonthAndWeek(t time.Time) bool {
 	return domMatch && dowMatch
 }
 
+// matchSpecialDom 检查日期字段的特殊字符(L、LW、nW)，仅在日期字段没有普通取值时生效
+func (s *CronSpecSchedule) matchSpecialDom(t time.Time) bool {
+	if s.Dom != 0 {
+		return false
+	}
+	year, month, day := t.Date()
+	lastDay := lastDayOfMonth(year, month)
+
+	switch {
+	case s.LastDay:
+		return day == lastDay
+	case s.LastWeekday:
+		return day == nearestWeekdayIn(year, month, lastDay, lastDay)
+	case s.NearestWeekday > 0:
+		// 当月不存在该日期时不触发
+		return s.NearestWeekday <= lastDay && day == nearestWeekdayIn(year, month, s.NearestWeekday, lastDay)
+	}
+	return false
+}
+
+// matchSpecialDow 检查星期字段的特殊字符(nL、n#m)，仅在星期字段没有普通取值时生效
+func (s *CronSpecSchedule) matchSpecialDow(t time.Time) bool {
+	if s.Dow != 0 {
+		return false
+	}
+	weekday := int(t.Weekday())
+
+	switch {
+	case s.LastDow >= 0:
+		return weekday == s.LastDow && t.Day()+7 > lastDayOfMonth(t.Year(), t.Month())
+	case s.NthDow > 0:
+		return weekday == s.NthDow/10 && (t.Day()-1)/7+1 == s.NthDow%10
+	}
+	return false
+}
+
+// nearestWeekdayIn 返回离指定日期最近的工作日(不跨月)
+func nearestWeekdayIn(year int, month time.Month, day, lastDay int) int {
+	switch time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Weekday() {
+	case time.Saturday:
+		if day == 1 {
+			return 3 // 1号是周六时顺延到周一
+		}
+		return day - 1
+	case time.Sunday:
+		if day == lastDay {
+			return day - 2 // 月末是周日时提前到周五
+		}
+		return day + 1
+	}
+	return day
+}
+
 // Next 计算下次执行时间
 func (s *CronEverySchedule) Next(t time.Time) time.Time {
 	return t.Add(s.Duration)
 }
+
+// Prev 计算上次执行时间
+func (s *CronEverySchedule) Prev(t time.Time) time.Time {
+	return t.Add(-s.Duration)
+}
+
+// NextN 计算从给定时间开始的后 n 次执行时间
+func (s *CronEverySchedule) NextN(t time.Time, n int) []time.Time {
+	return NextN(s, t, n)
+}
+
+// Between 按时间顺序遍历 (start, end] 区间内的所有执行时间
+func (s *CronEverySchedule) Between(start, end time.Time) iter.Seq[time.Time] {
+	return Between(s, start, end)
+}
+
+// NextN 计算任意调度从给定时间开始的后 n 次执行时间，无后续执行时提前结束
+func NextN(schedule CronSchedule, t time.Time, n int) []time.Time {
+	times := make([]time.Time, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		t = schedule.Next(t)
+		if t.IsZero() {
+			break
+		}
+		times = append(times, t)
+	}
+	return times
+}
+
+// Between 按时间顺序遍历任意调度在 (start, end] 区间内的所有执行时间
+//
+// 示例：
+//
+//	for t := range Between(schedule, lastRun, time.Now()) {
+//	    // 补偿错过的执行
+//	}
+func Between(schedule CronSchedule, start, end time.Time) iter.Seq[time.Time] {
+	return func(yield func(time.Time) bool) {
+		for t := schedule.Next(start); !t.IsZero() && !t.After(end); t = schedule.Next(t) {
+			if !yield(t) {
+				return
+			}
+		}
+	}
+}
@@ -308,3 +308,198 @@ func TestCronSpecSchedule_WithMethods(t *testing.T) {
 	assert.Equal(t, uint64(1<<6), schedule.Month)
 	assert.Equal(t, uint64(1<<1), schedule.Dow)
 }
+
+func TestCronSpecSchedule_Prev(t *testing.T) {
+	schedule, err := ParseCronWithSeconds("0 30 9 * * 1-5")
+	assert.NoError(t, err)
+
+	// 2025-12-29 是周一，上一次应为上周五
+	now := time.Date(2025, 12, 29, 8, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 12, 26, 9, 30, 0, 0, time.UTC), schedule.(*CronSpecSchedule).Prev(now))
+
+	// 恰好在执行时间点上时返回更早的一次
+	at := time.Date(2025, 12, 29, 9, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 12, 26, 9, 30, 0, 0, time.UTC), schedule.(*CronSpecSchedule).Prev(at))
+	assert.Equal(t, at, schedule.(*CronSpecSchedule).Prev(at.Add(time.Nanosecond)))
+
+	// Prev 与 Next 互逆
+	spec := schedule.(*CronSpecSchedule)
+	for _, next := range spec.NextN(now, 20) {
+		assert.Equal(t, next, spec.Next(spec.Prev(next)))
+	}
+}
+
+func TestCronSpecSchedule_Prev_NoMatch(t *testing.T) {
+	schedule := NewZeroCronSpecSchedule(time.UTC).WithDom(1 << 30).WithMonth(1 << 2)
+	assert.True(t, schedule.Prev(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)).IsZero())
+}
+
+func TestCronSpecSchedule_NextN(t *testing.T) {
+	schedule, err := ParseCronWithSeconds("0 0 */6 * * *")
+	assert.NoError(t, err)
+	spec := schedule.(*CronSpecSchedule)
+
+	now := time.Date(2025, 12, 25, 1, 0, 0, 0, time.UTC)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 12, 25, 6, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 25, 12, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 25, 18, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 26, 0, 0, 0, 0, time.UTC),
+	}, spec.NextN(now, 4))
+	assert.Empty(t, spec.NextN(now, 0))
+
+	every := &CronEverySchedule{Duration: time.Minute}
+	assert.Equal(t, []time.Time{now.Add(time.Minute), now.Add(2 * time.Minute)}, every.NextN(now, 2))
+	assert.Equal(t, now.Add(-time.Minute), every.Prev(now))
+}
+
+func TestCronSpecSchedule_Between(t *testing.T) {
+	schedule, err := ParseCronWithSeconds("0 0 9 * * *")
+	assert.NoError(t, err)
+	spec := schedule.(*CronSpecSchedule)
+
+	start := time.Date(2025, 12, 1, 9, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 12, 4, 9, 0, 0, 0, time.UTC)
+
+	var times []time.Time
+	for t := range spec.Between(start, end) {
+		times = append(times, t)
+	}
+	// 区间为 (start, end]
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 12, 2, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 3, 9, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 4, 9, 0, 0, 0, time.UTC),
+	}, times)
+
+	// 提前结束遍历
+	count := 0
+	for range Between(&CronEverySchedule{Duration: time.Hour}, start, end) {
+		count++
+		if count == 5 {
+			break
+		}
+	}
+	assert.Equal(t, 5, count)
+}
+
+func TestCronSpecSchedule_SpecialChars(t *testing.T) {
+	tests := map[string]struct {
+		spec     string
+		from     time.Time
+		expected []time.Time
+	}{
+		"last_day": {
+			spec: "0 0 0 L * ?",
+			from: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"last_weekday": {
+			spec: "0 0 0 LW * ?",
+			from: time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2025, 5, 30, 0, 0, 0, 0, time.UTC), // 5/31 是周六
+				time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, 7, 31, 0, 0, 0, 0, time.UTC),
+				time.Date(2025, 8, 29, 0, 0, 0, 0, time.UTC), // 8/31 是周日
+			},
+		},
+		"nearest_weekday": {
+			spec: "0 0 0 15W * ?",
+			from: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC), // 2/15 是周六
+				time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC), // 3/15 是周六
+				time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"nearest_weekday_first_saturday": {
+			spec: "0 0 0 1W * ?",
+			from: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC), // 2/1 是周六，不跨月到 1/31
+				time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		"last_friday": {
+			spec: "0 15 10 ? * 5L",
+			from: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2025, 12, 26, 10, 15, 0, 0, time.UTC),
+				time.Date(2026, 1, 30, 10, 15, 0, 0, time.UTC),
+			},
+		},
+		"third_wednesday": {
+			spec: "0 0 12 ? * WED#3",
+			from: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
+			expected: []time.Time{
+				time.Date(2025, 12, 17, 12, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 21, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			schedule, err := ParseCronWithSeconds(tc.spec)
+			assert.NoError(t, err)
+			spec := schedule.(*CronSpecSchedule)
+
+			times := spec.NextN(tc.from, len(tc.expected))
+			assert.Equal(t, tc.expected, times)
+
+			// 反向查找得到同样的结果
+			last := tc.expected[len(tc.expected)-1]
+			for i := len(tc.expected) - 2; i >= 0; i-- {
+				last = spec.Prev(last)
+				assert.Equal(t, tc.expected[i], last)
+			}
+		})
+	}
+}
+
+func TestCronSpecSchedule_DST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("缺少时区数据")
+	}
+	parser := NewCronParser(CronSecond | CronMinute | CronHour | CronDom | CronMonth | CronDow)
+
+	// 2025-03-09 02:00 跳到 03:00，02:30 不存在被跳过
+	schedule, err := parser.Parse("CRON_TZ=America/New_York 0 30 2 * * *")
+	assert.NoError(t, err)
+	spec := schedule.(*CronSpecSchedule)
+	from := time.Date(2025, 3, 8, 12, 0, 0, 0, ny)
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 3, 10, 2, 30, 0, 0, ny),
+	}, spec.NextN(from, 1))
+	assert.Equal(t, time.Date(2025, 3, 8, 2, 30, 0, 0, ny), spec.Prev(time.Date(2025, 3, 10, 0, 0, 0, 0, ny)))
+
+	// 2025-11-02 02:00 回拨到 01:00，01:30 只执行一次
+	schedule, err = parser.Parse("CRON_TZ=America/New_York 0 30 1 * * *")
+	assert.NoError(t, err)
+	spec = schedule.(*CronSpecSchedule)
+	from = time.Date(2025, 11, 1, 12, 0, 0, 0, ny)
+	times := spec.NextN(from, 2)
+	assert.Equal(t, time.Date(2025, 11, 2, 5, 30, 0, 0, time.UTC), times[0].UTC()) // EDT
+	assert.Equal(t, time.Date(2025, 11, 3, 6, 30, 0, 0, time.UTC), times[1].UTC()) // EST
+	assert.Equal(t, times[0], spec.Prev(times[1]))
+
+	// 每小时执行的任务在重复时段按实际时间流逝执行
+	schedule, err = parser.Parse("CRON_TZ=America/New_York 0 0 * * * *")
+	assert.NoError(t, err)
+	spec = schedule.(*CronSpecSchedule)
+	var hourly []time.Time
+	for t := range spec.Between(time.Date(2025, 11, 2, 4, 30, 0, 0, time.UTC), time.Date(2025, 11, 2, 7, 30, 0, 0, time.UTC)) {
+		hourly = append(hourly, t.UTC())
+	}
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 11, 2, 5, 0, 0, 0, time.UTC), // 01:00 EDT
+		time.Date(2025, 11, 2, 6, 0, 0, 0, time.UTC), // 01:00 EST
+		time.Date(2025, 11, 2, 7, 0, 0, 0, time.UTC), // 02:00 EST
+	}, hourly)
+}
@@ -0,0 +1,543 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-26 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-26 10:00:00
+ * @FilePath: \go-toolbox\pkg\cron\scheduler.go
+ * @Description: Cron 任务调度器
+ *
+ * 功能特性：
+ * - 通过 cron 表达式或 CronSchedule 注册任务
+ * - 每个任务可单独指定时区、重叠策略、随机抖动和超时
+ * - 任务 panic 自动恢复并转换为错误
+ * - 通过 EntryID 在运行时暂停、恢复和移除任务
+ * - 提供开始、结束、错误和跳过回调
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package cron
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+	"github.com/kamalyes/go-toolbox/pkg/random"
+)
+
+// EntryID 任务条目 ID
+type EntryID int64
+
+// Job 任务函数
+type Job func(ctx context.Context) error
+
+// OverlapPolicy 任务重叠策略(上一次执行尚未结束时又到了触发时间)
+type OverlapPolicy int
+
+const (
+	// OverlapAllow 允许并发执行
+	OverlapAllow OverlapPolicy = iota
+	// OverlapSkip 跳过本次触发
+	OverlapSkip
+	// OverlapQueue 排队，上一次执行结束后立即补执行
+	OverlapQueue
+	// OverlapReplace 取消正在执行的任务并开始新的执行
+	OverlapReplace
+)
+
+// String 返回策略名称
+func (p OverlapPolicy) String() string {
+	switch p {
+	case OverlapAllow:
+		return "allow"
+	case OverlapSkip:
+		return "skip"
+	case OverlapQueue:
+		return "queue"
+	case OverlapReplace:
+		return "replace"
+	default:
+		return "unknown"
+	}
+}
+
+// JobConfig 任务配置
+type JobConfig struct {
+	Name     string         // 任务名称，默认使用表达式
+	Location *time.Location // 任务时区，默认使用调度器时区
+	Overlap  OverlapPolicy  // 重叠策略，默认允许并发
+	Jitter   time.Duration  // 随机抖动上限，每次执行前随机延迟 [0, Jitter)
+	Timeout  time.Duration  // 单次执行超时，0 表示不限制
+	Calendar Calendar       // 排除日历，被排除的触发时间将被跳过
+}
+
+// SchedulerConfig 调度器配置
+type SchedulerConfig struct {
+	Location *time.Location // 默认时区，默认 time.Local
+	Parser   *CronParser    // 表达式解析器，默认支持可选秒字段和描述符
+
+	OnStart  func(entry Entry)                                   // 任务开始执行
+	OnFinish func(entry Entry, err error, elapsed time.Duration) // 任务执行结束
+	OnError  func(entry Entry, err error)                        // 任务执行失败(含 panic、超时)
+	OnSkip   func(entry Entry)                                   // 因重叠策略跳过本次触发
+}
+
+// DefaultSchedulerParser 调度器默认解析器(秒字段可选，支持描述符)
+var DefaultSchedulerParser = NewCronParser(
+	CronSecondOptional | CronMinute | CronHour | CronDom | CronMonth | CronDow | CronDescriptor,
+)
+
+// Entry 任务条目快照
+type Entry struct {
+	ID       EntryID
+	Name     string
+	Schedule CronSchedule
+	Location *time.Location
+	Overlap  OverlapPolicy
+	Prev     time.Time // 上一次触发时间
+	Next     time.Time // 下一次触发时间，暂停或无后续触发时为零值
+	Paused   bool
+	Running  int // 正在执行的次数
+	Pending  int // 排队等待执行的次数(OverlapQueue)
+}
+
+// JobPanicError 任务 panic 转换得到的错误
+type JobPanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error 实现 error 接口
+func (e *JobPanicError) Error() string {
+	return fmt.Sprintf("任务执行 panic: %v", e.Value)
+}
+
+// entry 任务条目(字段由 Scheduler.mu 保护)
+type entry struct {
+	id       EntryID
+	schedule CronSchedule
+	job      Job
+	config   JobConfig
+	prev     time.Time
+	next     time.Time
+	paused   bool
+	running  int
+	pending  int
+	runSeq   uint64             // 执行序号
+	current  uint64             // 最近一次执行的序号
+	cancel   context.CancelFunc // 最近一次执行的取消函数
+}
+
+// snapshot 生成条目快照(调用方需持有锁)
+func (e *entry) snapshot() Entry {
+	return Entry{
+		ID:       e.id,
+		Name:     e.config.Name,
+		Schedule: e.schedule,
+		Location: e.config.Location,
+		Overlap:  e.config.Overlap,
+		Prev:     e.prev,
+		Next:     e.next,
+		Paused:   e.paused,
+		Running:  e.running,
+		Pending:  e.pending,
+	}
+}
+
+// Scheduler Cron 任务调度器
+type Scheduler struct {
+	config  SchedulerConfig
+	entries map[EntryID]*entry
+	nextID  EntryID
+	running bool
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wake    chan struct{}
+	done    chan struct{}
+	jobs    sync.WaitGroup
+	mu      sync.Mutex
+	now     func() time.Time
+}
+
+// NewScheduler 创建调度器
+func NewScheduler(config SchedulerConfig) *Scheduler {
+	config.Location = mathx.IF(config.Location == nil, time.Local, config.Location)
+	config.Parser = mathx.IF(config.Parser == nil, DefaultSchedulerParser, config.Parser)
+
+	return &Scheduler{
+		config:  config,
+		entries: make(map[EntryID]*entry),
+		wake:    make(chan struct{}, 1),
+		now:     time.Now,
+	}
+}
+
+// AddFunc 通过 cron 表达式注册任务
+func (s *Scheduler) AddFunc(spec string, job Job) (EntryID, error) {
+	return s.AddJob(spec, job, JobConfig{})
+}
+
+// AddJob 通过 cron 表达式和任务配置注册任务
+// 任务名(默认为表达式本身)作为哈希字段 H 的 key
+func (s *Scheduler) AddJob(spec string, job Job, config JobConfig) (EntryID, error) {
+	config.Name = mathx.IF(config.Name == "", spec, config.Name)
+	schedule, err := s.config.Parser.ParseWithKey(spec, config.Name)
+	if err != nil {
+		return 0, err
+	}
+	return s.Schedule(schedule, job, config)
+}
+
+// Schedule 通过 CronSchedule 注册任务
+func (s *Scheduler) Schedule(schedule CronSchedule, job Job, config JobConfig) (EntryID, error) {
+	if schedule == nil {
+		return 0, fmt.Errorf("调度规则不能为空")
+	}
+	if job == nil {
+		return 0, fmt.Errorf("任务函数不能为空")
+	}
+	config.Location = mathx.IF(config.Location == nil, s.config.Location, config.Location)
+	if config.Calendar != nil {
+		schedule = NewCalendarSchedule(schedule, config.Calendar)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.nextID++
+	e := &entry{
+		id:       s.nextID,
+		schedule: schedule,
+		job:      job,
+		config:   config,
+	}
+	e.config.Name = mathx.IF(e.config.Name == "", fmt.Sprintf("entry-%d", e.id), e.config.Name)
+	e.next = s.nextTime(e, s.now())
+	s.entries[e.id] = e
+	s.notify()
+	return e.id, nil
+}
+
+// Remove 移除任务，正在执行的任务不会被中断
+func (s *Scheduler) Remove(id EntryID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return false
+	}
+	e.pending = 0
+	delete(s.entries, id)
+	s.notify()
+	return true
+}
+
+// Pause 暂停任务的后续触发
+func (s *Scheduler) Pause(id EntryID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return false
+	}
+	e.paused = true
+	e.next = time.Time{}
+	s.notify()
+	return true
+}
+
+// Resume 恢复已暂停的任务，从当前时间重新计算下一次触发时间
+func (s *Scheduler) Resume(id EntryID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return false
+	}
+	if e.paused {
+		e.paused = false
+		e.next = s.nextTime(e, s.now())
+		s.notify()
+	}
+	return true
+}
+
+// Entry 获取任务条目快照
+func (s *Scheduler) Entry(id EntryID) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[id]
+	if !ok {
+		return Entry{}, false
+	}
+	return e.snapshot(), true
+}
+
+// Entries 获取所有任务条目快照，按下一次触发时间排序(无触发时间的排在最后)
+func (s *Scheduler) Entries() []Entry {
+	s.mu.Lock()
+	entries := make([]Entry, 0, len(s.entries))
+	for _, e := range s.entries {
+		entries = append(entries, e.snapshot())
+	}
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i].Next, entries[j].Next
+		if a.IsZero() != b.IsZero() {
+			return b.IsZero()
+		}
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries
+}
+
+// IsRunning 调度器是否正在运行
+func (s *Scheduler) IsRunning() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Start 启动调度器(非阻塞)，重复调用无效
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running {
+		return
+	}
+	s.running = true
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.done = make(chan struct{})
+
+	now := s.now()
+	for _, e := range s.entries {
+		if !e.paused {
+			e.next = s.nextTime(e, now)
+		}
+	}
+	go s.loop(s.ctx, s.done)
+}
+
+// Stop 停止调度器，取消正在执行任务的上下文并等待其结束
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.cancel()
+	done := s.done
+	for _, e := range s.entries {
+		e.pending = 0
+	}
+	s.mu.Unlock()
+
+	<-done
+	s.jobs.Wait()
+}
+
+// loop 调度主循环
+func (s *Scheduler) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		s.mu.Lock()
+		next := s.earliest()
+		s.mu.Unlock()
+
+		var timer *time.Timer
+		var fire <-chan time.Time
+		if !next.IsZero() {
+			timer = time.NewTimer(max(0, next.Sub(s.now())))
+			fire = timer.C
+		}
+
+		select {
+		case <-fire:
+			s.runDue(s.now())
+		case <-s.wake:
+		case <-ctx.Done():
+		}
+
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// earliest 获取最近的触发时间(调用方需持有锁)
+func (s *Scheduler) earliest() time.Time {
+	var earliest time.Time
+	for _, e := range s.entries {
+		if e.next.IsZero() {
+			continue
+		}
+		if earliest.IsZero() || e.next.Before(earliest) {
+			earliest = e.next
+		}
+	}
+	return earliest
+}
+
+// runDue 触发所有已到期的任务
+func (s *Scheduler) runDue(now time.Time) {
+	var skipped []Entry
+
+	s.mu.Lock()
+	if s.running {
+		for _, e := range s.entries {
+			if e.paused || e.next.IsZero() || e.next.After(now) {
+				continue
+			}
+			e.prev = e.next
+			e.next = s.nextTime(e, now)
+			if !s.dispatch(e) {
+				skipped = append(skipped, e.snapshot())
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if s.config.OnSkip != nil {
+		for _, entry := range skipped {
+			s.callHook(func() { s.config.OnSkip(entry) })
+		}
+	}
+}
+
+// nextTime 在任务时区下计算下一次触发时间
+func (s *Scheduler) nextTime(e *entry, t time.Time) time.Time {
+	return e.schedule.Next(t.In(e.config.Location))
+}
+
+// dispatch 按重叠策略分发一次触发，被跳过时返回 false(调用方需持有锁)
+func (s *Scheduler) dispatch(e *entry) bool {
+	if e.running > 0 {
+		switch e.config.Overlap {
+		case OverlapSkip:
+			return false
+		case OverlapQueue:
+			e.pending++
+			return true
+		case OverlapReplace:
+			if e.cancel != nil {
+				e.cancel()
+			}
+		}
+	}
+	s.start(e)
+	return true
+}
+
+// start 启动一次任务执行(调用方需持有锁)
+func (s *Scheduler) start(e *entry) {
+	ctx, cancel := context.WithCancel(s.ctx)
+	if e.config.Timeout > 0 {
+		var timeoutCancel context.CancelFunc
+		ctx, timeoutCancel = context.WithTimeout(ctx, e.config.Timeout)
+		parentCancel := cancel
+		cancel = func() {
+			timeoutCancel()
+			parentCancel()
+		}
+	}
+
+	e.runSeq++
+	seq := e.runSeq
+	e.current = seq
+	e.cancel = cancel
+	e.running++
+	snapshot := e.snapshot()
+
+	s.jobs.Add(1)
+	go s.execute(ctx, e, seq, snapshot, cancel)
+}
+
+// execute 执行任务并处理回调、排队补执行
+func (s *Scheduler) execute(ctx context.Context, e *entry, seq uint64, snapshot Entry, cancel context.CancelFunc) {
+	defer s.jobs.Done()
+	defer cancel()
+
+	if e.config.Jitter > 0 {
+		timer := time.NewTimer(random.RandDuration(0, e.config.Jitter))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+
+	var err error
+	var elapsed time.Duration
+	if err = ctx.Err(); err == nil {
+		if s.config.OnStart != nil {
+			s.callHook(func() { s.config.OnStart(snapshot) })
+		}
+		start := time.Now()
+		err = s.invoke(ctx, e.job)
+		elapsed = time.Since(start)
+	}
+
+	if s.config.OnFinish != nil {
+		s.callHook(func() { s.config.OnFinish(snapshot, err, elapsed) })
+	}
+	if err != nil && s.config.OnError != nil {
+		s.callHook(func() { s.config.OnError(snapshot, err) })
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.running--
+	if e.current == seq {
+		e.cancel = nil
+	}
+	if e.pending > 0 && s.running {
+		if _, ok := s.entries[e.id]; ok {
+			e.pending--
+			s.start(e)
+		}
+	}
+}
+
+// invoke 调用任务函数，将 panic 转换为 JobPanicError
+func (s *Scheduler) invoke(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &JobPanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+	return job(ctx)
+}
+
+// callHook 调用回调，回调中的 panic 不影响调度器
+func (s *Scheduler) callHook(fn func()) {
+	defer func() { _ = recover() }()
+	fn()
+}
+
+// notify 唤醒调度主循环重新计算等待时间
+func (s *Scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
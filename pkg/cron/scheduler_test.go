@@ -0,0 +1,248 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-26 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-26 10:00:00
+ * @FilePath: \go-toolbox\pkg\cron\scheduler_test.go
+ * @Description: 调度器测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package cron
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// everyMs 毫秒级间隔调度，便于测试
+func everyMs(ms int) CronSchedule {
+	return &CronEverySchedule{Duration: time.Duration(ms) * time.Millisecond}
+}
+
+func TestScheduler_AddValidation(t *testing.T) {
+	s := NewScheduler(SchedulerConfig{})
+
+	_, err := s.AddFunc("invalid spec", func(ctx context.Context) error { return nil })
+	assert.Error(t, err)
+
+	_, err = s.AddFunc("*/5 * * * *", nil)
+	assert.Error(t, err)
+
+	_, err = s.Schedule(nil, func(ctx context.Context) error { return nil }, JobConfig{})
+	assert.Error(t, err)
+
+	// 秒字段可选
+	id, err := s.AddFunc("*/5 * * * * *", func(ctx context.Context) error { return nil })
+	assert.NoError(t, err)
+	entry, ok := s.Entry(id)
+	assert.True(t, ok)
+	assert.Equal(t, "*/5 * * * * *", entry.Name)
+	assert.False(t, entry.Next.IsZero())
+}
+
+func TestScheduler_RunsJobs(t *testing.T) {
+	var count int32
+	var started, finished int32
+	s := NewScheduler(SchedulerConfig{
+		OnStart:  func(entry Entry) { atomic.AddInt32(&started, 1) },
+		OnFinish: func(entry Entry, err error, elapsed time.Duration) { atomic.AddInt32(&finished, 1) },
+	})
+	_, err := s.Schedule(everyMs(10), func(ctx context.Context) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	}, JobConfig{Name: "counter"})
+	assert.NoError(t, err)
+
+	s.Start()
+	s.Start() // 重复启动无效
+	assert.True(t, s.IsRunning())
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&count) >= 3 }, time.Second, 5*time.Millisecond)
+	s.Stop()
+	s.Stop()
+
+	assert.False(t, s.IsRunning())
+	assert.Equal(t, atomic.LoadInt32(&started), atomic.LoadInt32(&finished))
+
+	// 停止后不再执行
+	stopped := atomic.LoadInt32(&count)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, stopped, atomic.LoadInt32(&count))
+}
+
+func TestScheduler_OverlapSkip(t *testing.T) {
+	var running, peak, skipped int32
+	s := NewScheduler(SchedulerConfig{
+		OnSkip: func(entry Entry) { atomic.AddInt32(&skipped, 1) },
+	})
+	_, _ = s.Schedule(everyMs(5), func(ctx context.Context) error {
+		n := atomic.AddInt32(&running, 1)
+		if n > atomic.LoadInt32(&peak) {
+			atomic.StoreInt32(&peak, n)
+		}
+		time.Sleep(40 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}, JobConfig{Overlap: OverlapSkip})
+
+	s.Start()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&skipped) >= 2 }, time.Second, 5*time.Millisecond)
+	s.Stop()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&peak))
+}
+
+func TestScheduler_OverlapQueue(t *testing.T) {
+	var running, peak, count int32
+	s := NewScheduler(SchedulerConfig{})
+	id, _ := s.Schedule(everyMs(5), func(ctx context.Context) error {
+		n := atomic.AddInt32(&running, 1)
+		if n > atomic.LoadInt32(&peak) {
+			atomic.StoreInt32(&peak, n)
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		atomic.AddInt32(&count, 1)
+		return nil
+	}, JobConfig{Overlap: OverlapQueue})
+
+	s.Start()
+	assert.Eventually(t, func() bool {
+		entry, _ := s.Entry(id)
+		return entry.Pending > 0
+	}, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&count) >= 3 }, time.Second, 5*time.Millisecond)
+	s.Stop()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&peak))
+	entry, _ := s.Entry(id)
+	assert.Equal(t, 0, entry.Pending)
+}
+
+func TestScheduler_OverlapReplace(t *testing.T) {
+	var canceled int32
+	s := NewScheduler(SchedulerConfig{})
+	_, _ = s.Schedule(everyMs(10), func(ctx context.Context) error {
+		<-ctx.Done()
+		atomic.AddInt32(&canceled, 1)
+		return ctx.Err()
+	}, JobConfig{Overlap: OverlapReplace})
+
+	s.Start()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&canceled) >= 2 }, time.Second, 5*time.Millisecond)
+	s.Stop()
+}
+
+func TestScheduler_TimeoutAndPanic(t *testing.T) {
+	var mu sync.Mutex
+	errs := make(map[string]error)
+	s := NewScheduler(SchedulerConfig{
+		OnError: func(entry Entry, err error) {
+			mu.Lock()
+			if _, ok := errs[entry.Name]; !ok {
+				errs[entry.Name] = err
+			}
+			mu.Unlock()
+		},
+	})
+	_, _ = s.Schedule(everyMs(10), func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, JobConfig{Name: "slow", Timeout: 5 * time.Millisecond, Overlap: OverlapSkip})
+	_, _ = s.Schedule(everyMs(10), func(ctx context.Context) error {
+		panic("boom")
+	}, JobConfig{Name: "panic"})
+
+	s.Start()
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(errs) == 2
+	}, time.Second, 5*time.Millisecond)
+	s.Stop()
+
+	assert.ErrorIs(t, errs["slow"], context.DeadlineExceeded)
+	var panicErr *JobPanicError
+	assert.True(t, errors.As(errs["panic"], &panicErr))
+	assert.Equal(t, "boom", panicErr.Value)
+	assert.NotEmpty(t, panicErr.Stack)
+}
+
+func TestScheduler_PauseResumeRemove(t *testing.T) {
+	var count int32
+	s := NewScheduler(SchedulerConfig{})
+	id, _ := s.Schedule(everyMs(5), func(ctx context.Context) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	}, JobConfig{})
+
+	s.Start()
+	defer s.Stop()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&count) >= 1 }, time.Second, time.Millisecond)
+
+	assert.True(t, s.Pause(id))
+	entry, _ := s.Entry(id)
+	assert.True(t, entry.Paused)
+	assert.True(t, entry.Next.IsZero())
+
+	time.Sleep(15 * time.Millisecond)
+	paused := atomic.LoadInt32(&count)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, paused, atomic.LoadInt32(&count))
+
+	assert.True(t, s.Resume(id))
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&count) > paused }, time.Second, time.Millisecond)
+
+	assert.True(t, s.Remove(id))
+	assert.False(t, s.Remove(id))
+	assert.False(t, s.Pause(id))
+	assert.False(t, s.Resume(id))
+	_, ok := s.Entry(id)
+	assert.False(t, ok)
+}
+
+func TestScheduler_JobLocation(t *testing.T) {
+	shanghai := time.FixedZone("CST", 8*3600)
+	s := NewScheduler(SchedulerConfig{Location: time.UTC})
+	s.now = func() time.Time { return time.Date(2025, 12, 26, 0, 30, 0, 0, time.UTC) }
+
+	job := func(ctx context.Context) error { return nil }
+	utcID, _ := s.AddJob("0 0 9 * * *", job, JobConfig{Name: "utc"})
+	cstID, _ := s.AddJob("0 0 9 * * *", job, JobConfig{Name: "cst", Location: shanghai})
+
+	utcEntry, _ := s.Entry(utcID)
+	cstEntry, _ := s.Entry(cstID)
+	assert.Equal(t, time.Date(2025, 12, 26, 9, 0, 0, 0, time.UTC), utcEntry.Next.UTC())
+	// 上海时间 08:30，当天 09:00 即 UTC 01:00
+	assert.Equal(t, time.Date(2025, 12, 26, 1, 0, 0, 0, time.UTC), cstEntry.Next.UTC())
+
+	entries := s.Entries()
+	assert.Equal(t, []EntryID{cstID, utcID}, []EntryID{entries[0].ID, entries[1].ID})
+}
+
+func TestScheduler_Jitter(t *testing.T) {
+	var count int32
+	s := NewScheduler(SchedulerConfig{})
+	_, _ = s.Schedule(everyMs(10), func(ctx context.Context) error {
+		atomic.AddInt32(&count, 1)
+		return nil
+	}, JobConfig{Jitter: 5 * time.Millisecond})
+
+	s.Start()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&count) >= 2 }, time.Second, 5*time.Millisecond)
+	s.Stop()
+}
+
+func TestOverlapPolicy_String(t *testing.T) {
+	assert.Equal(t, "allow", OverlapAllow.String())
+	assert.Equal(t, "skip", OverlapSkip.String())
+	assert.Equal(t, "queue", OverlapQueue.String())
+	assert.Equal(t, "replace", OverlapReplace.String())
+	assert.Equal(t, "unknown", OverlapPolicy(99).String())
+}
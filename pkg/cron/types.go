@@ -39,6 +39,12 @@ const (
 	CronDowOptional
 	// CronDescriptor 允许描述符，如 @monthly, @weekly 等
 	CronDescriptor
+	// CronHash 允许哈希字段 H，如 H、H/15、H(0-29)，取值由 ParseWithKey 的 key 决定
+	CronHash
+	// CronYear 年份字段(1970-2199)，默认 *
+	CronYear
+	// CronYearOptional 可选年份字段，默认 *
+	CronYearOptional
 )
 
 // CronSchedule Cron 调度接口
@@ -51,6 +57,7 @@ type CronSchedule interface {
 type CronSpecSchedule struct {
 	// 各字段的位掩码
 	Second, Minute, Hour, Dom, Month, Dow uint64
+	Year                                  [4]uint64 // 年份位集合(第 i 位表示 1970+i 年)，全零表示不限制
 	Location                              *time.Location
 	// 特殊字符标记
 	LastDay        bool // L - 月份最后一天
@@ -83,6 +90,10 @@ func NewZeroCronSpecSchedule(loc *time.Location) *CronSpecSchedule {
 		Month:    cronWildcardMonths,
 		Dow:      cronWildcardWeekdays,
 		Location: loc,
+
+		NearestWeekday: -1,
+		LastDow:        -1,
+		NthDow:         -1,
 	}
 }
 
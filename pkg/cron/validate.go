@@ -0,0 +1,144 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-28 15:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-28 15:00:00
+ * @FilePath: \go-toolbox\pkg\cron\validate.go
+ * @Description: Cron 表达式校验，返回可定位到具体字段和片段的诊断信息
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package cron
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Diagnostic 表达式校验诊断信息
+type Diagnostic struct {
+	Field  int    `json:"field"`  // 字段在表达式中的序号(从 0 开始)，-1 表示与具体字段无关(如字段数量、时区、描述符)
+	Name   string `json:"name"`   // 字段名称，如 分钟、星期
+	Token  string `json:"token"`  // 出错的片段
+	Offset int    `json:"offset"` // 片段在表达式中的字节偏移
+	Reason string `json:"reason"` // 错误原因
+}
+
+// Error 实现 error 接口
+func (d Diagnostic) Error() string {
+	if d.Field < 0 {
+		return fmt.Sprintf("'%s': %s", d.Token, d.Reason)
+	}
+	return fmt.Sprintf("第 %d 个字段(%s) '%s': %s", d.Field+1, d.Name, d.Token, d.Reason)
+}
+
+// Validate 使用 DefaultSchedulerParser 校验表达式
+func Validate(spec string) []Diagnostic {
+	return DefaultSchedulerParser.Validate(spec)
+}
+
+// Validate 校验表达式，返回全部诊断信息，表达式有效时返回 nil
+// 与 Parse 遇到第一个错误即返回不同，Validate 会检查每个字段，并定位到列表中出错的元素
+//
+// 示例：
+//
+//	diags := QuartzParser.Validate("0 0 25 ? * MON,FUN 2025")
+//	// [{Field:2 Name:小时 Token:25 Offset:4 ...} {Field:5 Name:星期 Token:FUN Offset:15 ...}]
+func (p *CronParser) Validate(spec string) []Diagnostic {
+	if strings.TrimSpace(spec) == "" {
+		return []Diagnostic{{Field: -1, Reason: "cron 表达式不能为空"}}
+	}
+
+	// 时区前缀
+	body, offset := spec, 0
+	if strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		i, eq := strings.Index(spec, " "), strings.Index(spec, "=")
+		if i == -1 {
+			return []Diagnostic{{Field: -1, Token: spec, Reason: "时区格式错误"}}
+		}
+		if _, err := time.LoadLocation(spec[eq+1 : i]); err != nil {
+			return []Diagnostic{{Field: -1, Token: spec[eq+1 : i], Offset: eq + 1, Reason: fmt.Sprintf("无效的时区: %v", err)}}
+		}
+		body, offset = spec[i:], i
+	}
+
+	fields, offsets := splitFieldsWithOffset(body, offset)
+	if len(fields) == 0 {
+		return []Diagnostic{{Field: -1, Token: spec, Reason: "cron 表达式缺少字段"}}
+	}
+
+	// 描述符整体校验
+	if strings.HasPrefix(fields[0], "@") {
+		if _, err := p.Parse(spec); err != nil {
+			token := strings.TrimSpace(spec[offsets[0]:])
+			return []Diagnostic{{Field: -1, Token: token, Offset: offsets[0], Reason: err.Error()}}
+		}
+		return nil
+	}
+
+	places, err := p.fieldPlaces(len(fields))
+	if err != nil {
+		return []Diagnostic{{Field: -1, Token: strings.TrimSpace(body), Offset: offsets[0], Reason: err.Error()}}
+	}
+
+	var diags []Diagnostic
+	for i, field := range fields {
+		diags = append(diags, p.validateField(i, places[i], field, offsets[i])...)
+	}
+	return diags
+}
+
+// validateField 校验单个字段，字段无效时逐个检查逗号分隔的元素以定位出错片段
+func (p *CronParser) validateField(index, place int, field string, offset int) []Diagnostic {
+	schedule := &CronSpecSchedule{}
+	check := func(expr string) error {
+		if p.options&CronHash > 0 {
+			var err error
+			if expr, err = expandHashedField(expr, place, ""); err != nil {
+				return err
+			}
+		}
+		return parseCronField(schedule, place, expr)
+	}
+
+	fieldErr := check(field)
+	if fieldErr == nil {
+		return nil
+	}
+
+	var diags []Diagnostic
+	pos := offset
+	for _, expr := range strings.Split(field, ",") {
+		if err := check(expr); err != nil {
+			diags = append(diags, Diagnostic{Field: index, Name: cronFieldNames[place], Token: expr, Offset: pos, Reason: err.Error()})
+		}
+		pos += len(expr) + 1
+	}
+
+	// 各元素单独有效但组合无效(如特殊字符出现在列表中)时定位到整个字段
+	if len(diags) == 0 {
+		diags = append(diags, Diagnostic{Field: index, Name: cronFieldNames[place], Token: field, Offset: offset, Reason: fieldErr.Error()})
+	}
+	return diags
+}
+
+// splitFieldsWithOffset 按空白字符分割，同时返回每个字段在原表达式中的偏移
+func splitFieldsWithOffset(s string, base int) (fields []string, offsets []int) {
+	start := -1
+	for i, r := range s {
+		switch {
+		case unicode.IsSpace(r) && start >= 0:
+			fields, offsets = append(fields, s[start:i]), append(offsets, base+start)
+			start = -1
+		case !unicode.IsSpace(r) && start < 0:
+			start = i
+		}
+	}
+	if start >= 0 {
+		fields, offsets = append(fields, s[start:]), append(offsets, base+start)
+	}
+	return fields, offsets
+}
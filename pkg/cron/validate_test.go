@@ -0,0 +1,124 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-28 15:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-28 15:00:00
+ * @FilePath: \go-toolbox\pkg\cron\validate_test.go
+ * @Description: Cron 表达式校验诊断测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package cron
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCronParser_Validate(t *testing.T) {
+	type diag struct {
+		field  int
+		token  string
+		offset int
+	}
+	tests := map[string]struct {
+		parser   *CronParser
+		spec     string
+		expected []diag
+	}{
+		"valid":            {parser: QuartzParser, spec: "0 0/15 9-17 ? * MON-FRI 2025"},
+		"valid_descriptor": {parser: QuartzParser, spec: "@daily"},
+		"valid_timezone":   {parser: CronStandardParser, spec: "CRON_TZ=Asia/Shanghai 0 9 * * *"},
+		"multiple_fields": {
+			parser:   QuartzParser,
+			spec:     "0 0 25 ? * MON,FUN 2025",
+			expected: []diag{{field: 2, token: "25", offset: 4}, {field: 5, token: "FUN", offset: 15}},
+		},
+		"list_elements": {
+			parser:   CronStandardParser,
+			spec:     "5,61,70 * * * *",
+			expected: []diag{{field: 0, token: "61", offset: 2}, {field: 0, token: "70", offset: 5}},
+		},
+		"special_in_list": {
+			parser:   QuartzParser,
+			spec:     "0 0 0 1,L * ?",
+			expected: []diag{{field: 3, token: "1,L", offset: 6}},
+		},
+		"year": {
+			parser:   QuartzParser,
+			spec:     "0 0 0 1 1 ?  2300",
+			expected: []diag{{field: 6, token: "2300", offset: 13}},
+		},
+		"optional_second": {
+			parser:   DefaultSchedulerParser,
+			spec:     "99 * * * *",
+			expected: []diag{{field: 0, token: "99", offset: 0}},
+		},
+		"timezone_offset": {
+			parser:   CronStandardParser,
+			spec:     "TZ=UTC 0 24 * * *",
+			expected: []diag{{field: 1, token: "24", offset: 9}},
+		},
+		"field_count": {
+			parser:   CronStandardParser,
+			spec:     "* * *",
+			expected: []diag{{field: -1, token: "* * *", offset: 0}},
+		},
+		"bad_timezone": {
+			parser:   CronStandardParser,
+			spec:     "TZ=Mars/Base * * * * *",
+			expected: []diag{{field: -1, token: "Mars/Base", offset: 3}},
+		},
+		"bad_descriptor": {
+			parser:   QuartzParser,
+			spec:     "@sometimes",
+			expected: []diag{{field: -1, token: "@sometimes", offset: 0}},
+		},
+		"hash": {
+			parser:   hashParser,
+			spec:     "H(0-99) H * * *",
+			expected: []diag{{field: 0, token: "H(0-99)", offset: 0}},
+		},
+		"empty": {
+			parser:   CronStandardParser,
+			spec:     "  ",
+			expected: []diag{{field: -1, token: "", offset: 0}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			diags := tc.parser.Validate(tc.spec)
+			if len(tc.expected) == 0 {
+				assert.Empty(t, diags)
+				_, err := tc.parser.Parse(tc.spec)
+				assert.NoError(t, err)
+				return
+			}
+
+			actual := make([]diag, len(diags))
+			for i, d := range diags {
+				actual[i] = diag{field: d.Field, token: d.Token, offset: d.Offset}
+				assert.NotEmpty(t, d.Reason)
+				if d.Field >= 0 {
+					assert.Equal(t, d.Token, tc.spec[d.Offset:d.Offset+len(d.Token)])
+				}
+			}
+			assert.Equal(t, tc.expected, actual)
+
+			_, err := tc.parser.Parse(tc.spec)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, Validate("*/5 * * * * *"))
+
+	diags := Validate("0 0 32 * *")
+	assert.Len(t, diags, 1)
+	assert.Equal(t, "日期", diags[0].Name)
+	assert.Contains(t, diags[0].Error(), "第 3 个字段(日期) '32'")
+	assert.Contains(t, Diagnostic{Field: -1, Token: "x", Reason: "y"}.Error(), "'x': y")
+}
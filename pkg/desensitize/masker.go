@@ -215,6 +215,21 @@ func (dm *DataMasker) WithMaxBodySize(size int) *DataMasker {
 	return dm
 }
 
+// IsSensitiveKey 检查字段名是否为敏感字段（不区分大小写，包含敏感字段即视为敏感）
+func (dm *DataMasker) IsSensitiveKey(key string) bool {
+	return dm.isSensitive(key)
+}
+
+// MaskValue 返回掩码
+func (dm *DataMasker) MaskValue() string {
+	return dm.getMask()
+}
+
+// MaxBodySize 返回最大处理数据大小（字节）
+func (dm *DataMasker) MaxBodySize() int {
+	return dm.getMaxBodySize()
+}
+
 // MaskString 脱敏字符串（链式调用）
 func (dm *DataMasker) MaskString(data string) string {
 	return dm.Mask([]byte(data))
@@ -126,6 +126,20 @@ func TestDataMasker_MaxBodySize(t *testing.T) {
 	assert.LessOrEqual(t, len(result), 20)
 }
 
+func TestDataMasker_Accessors(t *testing.T) {
+	masker := NewMasker(&MaskerConfig{SensitiveKeys: []string{"password", "token"}})
+
+	assert.True(t, masker.IsSensitiveKey("Password"))
+	assert.True(t, masker.IsSensitiveKey("x_auth_token"))
+	assert.False(t, masker.IsSensitiveKey("username"))
+	assert.Equal(t, "***", masker.MaskValue())
+	assert.Equal(t, DefaultMaskerConfig().MaxBodySize, masker.MaxBodySize())
+
+	masker.WithMask("[REDACTED]").WithMaxBodySize(100)
+	assert.Equal(t, "[REDACTED]", masker.MaskValue())
+	assert.Equal(t, 100, masker.MaxBodySize())
+}
+
 func TestDataMasker_EmptyData(t *testing.T) {
 	masker := NewMasker()
 
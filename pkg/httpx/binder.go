@@ -0,0 +1,426 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-01-03 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-01-03 10:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\binder.go
+ * @Description: 声明式 API 客户端，通过结构体函数字段的标签生成类型化请求
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/kamalyes/go-argus"
+	"github.com/kamalyes/go-toolbox/pkg/convert"
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+)
+
+// 绑定使用的结构体标签
+const (
+	TagMethod = "method" // 函数字段：请求方法
+	TagPath   = "path"   // 函数字段：请求路径，支持 {name} 占位符；参数字段：路径参数名
+	TagQuery  = "query"  // 参数字段：查询参数名，支持 ,omitempty
+	TagHeader = "header" // 参数字段：请求头名，支持 ,omitempty
+	TagBody   = "body"   // 参数字段：请求体
+)
+
+var (
+	pathParamRegex = regexp.MustCompile(`\{(\w+)\}`)
+
+	contextType  = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	responseType = reflect.TypeOf(Response{})
+)
+
+// BindOption 绑定选项
+type BindOption func(*bindConfig)
+
+// bindConfig 绑定配置
+type bindConfig struct {
+	baseURL string
+}
+
+// WithBindBaseURL 设置基础 URL，函数字段的 path 基于该 URL 拼接
+func WithBindBaseURL(baseURL string) BindOption {
+	return func(c *bindConfig) {
+		c.baseURL = strings.TrimSuffix(NormalizeBaseURL(baseURL), "/")
+	}
+}
+
+// paramKind 参数字段的用途
+type paramKind int
+
+const (
+	paramPath paramKind = iota
+	paramQuery
+	paramHeader
+	paramBody
+)
+
+// paramField 参数结构体中带标签的字段
+type paramField struct {
+	index     []int
+	kind      paramKind
+	name      string
+	omitEmpty bool
+}
+
+// endpoint 由函数字段解析出的接口定义
+type endpoint struct {
+	method  string
+	path    string
+	hasCtx  bool         // 第一个参数为 context.Context
+	argType reflect.Type // 参数结构体类型，为 nil 时无参数
+	params  []paramField
+	outType reflect.Type // 结果类型，为 nil 时只返回 error
+}
+
+// Bind 为 api 指向的结构体中带 method/path 标签的函数字段生成实现，client 为 nil 时使用 NewClient()
+//
+// 函数签名为 func([ctx context.Context], [req Req 或 *Req]) ([T], error)：
+//   - Req 结构体字段通过 path/query/header/body 标签声明路径参数、查询参数、请求头和请求体
+//   - T 为 Response/*Response 时返回原始响应(需调用方关闭)，为 []byte/string 时返回响应体，其他类型通过 Response.JSON 解码
+//   - 状态码不低于 300 时返回 ErrRequestStatusCode
+//
+// 示例：
+//
+//	type UserAPI struct {
+//	    GetUser    func(ctx context.Context, req GetUserReq) (*User, error) `method:"GET" path:"/users/{id}"`
+//	    CreateUser func(ctx context.Context, req CreateUserReq) (*User, error) `method:"POST" path:"/users"`
+//	}
+//	type GetUserReq struct {
+//	    ID     int64  `path:"id"`
+//	    Fields string `query:"fields,omitempty"`
+//	    Tenant string `header:"X-Tenant"`
+//	}
+//	var api UserAPI
+//	err := httpx.Bind(client, &api, httpx.WithBindBaseURL("https://api.example.com"))
+//	user, err := api.GetUser(ctx, GetUserReq{ID: 1})
+func Bind(client *Client, api any, opts ...BindOption) error {
+	if client == nil {
+		client = NewClient()
+	}
+	config := &bindConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	v := reflect.ValueOf(api)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errorx.NewError(ErrInvalidBinding, fmt.Sprintf("%T", api), "api must be a non-nil pointer to struct")
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		method, ok := field.Tag.Lookup(TagMethod)
+		if !ok {
+			continue
+		}
+		if !field.IsExported() {
+			return errorx.NewError(ErrInvalidBinding, t.Name()+"."+field.Name, "field must be exported")
+		}
+		ep, err := parseEndpoint(field, method, config.baseURL)
+		if err != nil {
+			return errorx.NewError(ErrInvalidBinding, t.Name()+"."+field.Name, err.Error())
+		}
+		v.Field(i).Set(reflect.MakeFunc(field.Type, func(args []reflect.Value) []reflect.Value {
+			return ep.call(client, args)
+		}))
+	}
+	return nil
+}
+
+// parseEndpoint 解析函数字段的签名和标签
+func parseEndpoint(field reflect.StructField, method, baseURL string) (*endpoint, error) {
+	ft := field.Type
+	if ft.Kind() != reflect.Func {
+		return nil, errors.New("field must be a func")
+	}
+	ep := &endpoint{method: strings.ToUpper(method), path: field.Tag.Get(TagPath)}
+	if !IsValidMethod(ep.method) {
+		return nil, fmt.Errorf("invalid method %q", method)
+	}
+	if !strings.Contains(ep.path, "://") && baseURL != "" {
+		ep.path = baseURL + "/" + strings.TrimPrefix(ep.path, "/")
+	}
+
+	// 参数：[ctx], [req]
+	in := 0
+	if in < ft.NumIn() && ft.In(in) == contextType {
+		ep.hasCtx = true
+		in++
+	}
+	if in < ft.NumIn() {
+		ep.argType = ft.In(in)
+		in++
+	}
+	if in != ft.NumIn() || ft.IsVariadic() {
+		return nil, errors.New("func must be func([context.Context], [request struct]) ([result], error)")
+	}
+	if ep.argType != nil {
+		params, err := parseParams(ep.argType)
+		if err != nil {
+			return nil, err
+		}
+		ep.params = params
+	}
+
+	// 返回值：[T], error
+	switch {
+	case ft.NumOut() == 1 && ft.Out(0) == errorType:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+		ep.outType = ft.Out(0)
+	default:
+		return nil, errors.New("func must return ([result], error)")
+	}
+
+	// 路径占位符必须都有对应的参数字段
+	for _, match := range pathParamRegex.FindAllStringSubmatch(ep.path, -1) {
+		if !ep.hasPathParam(match[1]) {
+			return nil, fmt.Errorf("path parameter {%s} has no field tagged path:%q", match[1], match[1])
+		}
+	}
+	return ep, nil
+}
+
+// parseParams 解析参数结构体中带标签的字段
+func parseParams(t reflect.Type) ([]paramField, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("request argument must be a struct or pointer to struct, got %s", t)
+	}
+
+	var params []paramField
+	hasBody := false
+	for i := range t.NumField() {
+		field := t.Field(i)
+		for _, kind := range []paramKind{paramPath, paramQuery, paramHeader, paramBody} {
+			tag, ok := field.Tag.Lookup(kind.tag())
+			if !ok {
+				continue
+			}
+			if !field.IsExported() {
+				return nil, fmt.Errorf("field %s must be exported", field.Name)
+			}
+			name, option, _ := strings.Cut(tag, ",")
+			p := paramField{index: field.Index, kind: kind, name: name, omitEmpty: option == "omitempty"}
+			if kind == paramBody {
+				if hasBody {
+					return nil, errors.New("multiple body fields")
+				}
+				hasBody = true
+			} else if name == "" {
+				p.name = field.Name
+			}
+			params = append(params, p)
+		}
+	}
+	return params, nil
+}
+
+// tag 返回参数用途对应的标签名
+func (k paramKind) tag() string {
+	switch k {
+	case paramPath:
+		return TagPath
+	case paramQuery:
+		return TagQuery
+	case paramHeader:
+		return TagHeader
+	default:
+		return TagBody
+	}
+}
+
+// hasPathParam 判断是否声明了路径参数 name
+func (ep *endpoint) hasPathParam(name string) bool {
+	for _, p := range ep.params {
+		if p.kind == paramPath && p.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// call 执行请求并按函数签名构造返回值
+func (ep *endpoint) call(client *Client, args []reflect.Value) []reflect.Value {
+	result, err := ep.do(client, args)
+	errValue := reflect.Zero(errorType)
+	if err != nil {
+		result = reflect.Value{}
+		errValue = reflect.ValueOf(&err).Elem()
+	}
+	if ep.outType == nil {
+		return []reflect.Value{errValue}
+	}
+	if !result.IsValid() {
+		result = reflect.Zero(ep.outType)
+	}
+	return []reflect.Value{result, errValue}
+}
+
+// do 构建请求、发送并解码响应
+func (ep *endpoint) do(client *Client, args []reflect.Value) (reflect.Value, error) {
+	req, err := ep.build(client, args)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	resp, err := req.Send()
+	if err != nil {
+		return reflect.Value{}, err
+	}
+
+	switch ep.outType {
+	case responseType:
+		return reflect.ValueOf(resp), nil
+	case reflect.PointerTo(responseType):
+		return reflect.ValueOf(&resp), nil
+	}
+	defer resp.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return reflect.Value{}, errorx.NewError(ErrRequestStatusCode, resp.Status)
+	}
+	if ep.outType == nil {
+		return reflect.Value{}, nil
+	}
+
+	switch ep.outType.Kind() {
+	case reflect.String:
+		body, err := resp.String()
+		return reflect.ValueOf(body).Convert(ep.outType), err
+	case reflect.Slice:
+		if ep.outType.Elem().Kind() == reflect.Uint8 {
+			body, err := resp.Bytes()
+			return reflect.ValueOf(body).Convert(ep.outType), err
+		}
+	}
+
+	dst := reflect.New(ep.outType)
+	if err := resp.JSON(dst.Interface()); err != nil && !errors.Is(err, io.EOF) { // 空响应体返回零值
+		return reflect.Value{}, err
+	}
+	return dst.Elem(), nil
+}
+
+// build 根据调用参数构建请求
+func (ep *endpoint) build(client *Client, args []reflect.Value) (*Request, error) {
+	var ctx context.Context
+	if ep.hasCtx {
+		ctx, _ = args[0].Interface().(context.Context)
+		args = args[1:]
+	}
+
+	var arg reflect.Value
+	if len(args) > 0 {
+		arg = args[0]
+		if arg.Kind() == reflect.Pointer {
+			arg = arg.Elem() // nil 指针视为无参数
+		}
+	}
+
+	path := ep.path
+	var fill []func(*Request) *Request
+	for _, p := range ep.params {
+		if !arg.IsValid() {
+			break
+		}
+		fv, ok := fieldByIndex(arg, p.index)
+		if !ok || (p.omitEmpty && validator.IsEmptyValue(fv)) {
+			continue
+		}
+		switch p.kind {
+		case paramPath:
+			path = strings.ReplaceAll(path, "{"+p.name+"}", url.PathEscape(convert.MustString(fv.Interface())))
+		case paramQuery:
+			name, values := p.name, formatValues(fv)
+			fill = append(fill, func(r *Request) *Request {
+				for _, value := range values {
+					r.AddQuery(name, value)
+				}
+				return r
+			})
+		case paramHeader:
+			name, values := p.name, formatValues(fv)
+			fill = append(fill, func(r *Request) *Request {
+				for _, value := range values {
+					r.AddHeader(name, value)
+				}
+				return r
+			})
+		case paramBody:
+			body := fv
+			fill = append(fill, func(r *Request) *Request { return setBindBody(r, body) })
+		}
+	}
+	if match := pathParamRegex.FindString(path); match != "" {
+		return nil, errorx.NewError(ErrInvalidBinding, ep.method+" "+ep.path, "missing path parameter "+match)
+	}
+
+	req := client.NewRequest(ep.method, path)
+	if ctx != nil {
+		req = req.WithContext(ctx)
+	}
+	for _, f := range fill {
+		req = f(req)
+	}
+	return req, nil
+}
+
+// fieldByIndex 按索引获取字段，途经 nil 指针时返回 false
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	fv, err := v.FieldByIndexErr(index)
+	if err != nil {
+		return reflect.Value{}, false
+	}
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return reflect.Value{}, false
+		}
+		return fv.Elem(), true
+	}
+	return fv, true
+}
+
+// formatValues 将字段值格式化为查询参数或请求头的值，切片和数组展开为多个值
+func formatValues(v reflect.Value) []string {
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		values := make([]string, 0, v.Len())
+		for i := range v.Len() {
+			values = append(values, convert.MustString(v.Index(i).Interface()))
+		}
+		return values
+	}
+	return []string{convert.MustString(v.Interface())}
+}
+
+// setBindBody 根据字段类型设置请求体：url.Values 为表单，[]byte/string 原样发送，io.Reader 流式发送，其他编码为 JSON
+func setBindBody(r *Request, body reflect.Value) *Request {
+	switch value := body.Interface().(type) {
+	case url.Values:
+		return r.SetBodyForm(value)
+	case []byte:
+		return r.SetBodyRaw(value)
+	case string:
+		return r.SetBodyString(value)
+	case io.Reader:
+		return r.SetBody(value)
+	default:
+		return r.SetBodyJSON(value)
+	}
+}
@@ -0,0 +1,184 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-01-03 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-01-03 10:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\binder_test.go
+ * @Description: 声明式 API 客户端测试
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package httpx
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+	"github.com/kamalyes/go-toolbox/pkg/json"
+	"github.com/stretchr/testify/assert"
+)
+
+type bindUser struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type getUserReq struct {
+	ID     int64    `path:"id"`
+	Fields []string `query:"fields,omitempty"`
+	Tenant string   `header:"X-Tenant,omitempty"`
+}
+
+type createUserReq struct {
+	Tenant string    `header:"X-Tenant"`
+	User   *bindUser `body:""`
+}
+
+type searchReq struct {
+	Form url.Values `body:""`
+}
+
+type userAPI struct {
+	GetUser    func(ctx context.Context, req getUserReq) (*bindUser, error)    `method:"GET" path:"/users/{id}"`
+	ListUsers  func(ctx context.Context) ([]bindUser, error)                   `method:"GET" path:"users"`
+	CreateUser func(ctx context.Context, req *createUserReq) (bindUser, error) `method:"post" path:"/users"`
+	DeleteUser func(req getUserReq) error                                      `method:"DELETE" path:"/users/{id}"`
+	Search     func(req searchReq) (string, error)                             `method:"POST" path:"/search"`
+	Raw        func(req getUserReq) (*Response, error)                         `method:"GET" path:"/users/{id}"`
+	helper     func()                                                          // 无标签字段不处理
+}
+
+// bindServer 模拟用户服务，回显收到的请求信息
+func bindServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Echo-Query", r.URL.RawQuery)
+		w.Header().Set("X-Echo-Tenant", r.Header.Get("X-Tenant"))
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/users":
+			json.NewEncoder(w).Encode([]bindUser{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}})
+		case r.Method == http.MethodGet && r.URL.Path == "/users/1":
+			json.NewEncoder(w).Encode(bindUser{ID: 1, Name: "a"})
+		case r.Method == http.MethodPost && r.URL.Path == "/users":
+			var user bindUser
+			json.NewDecoder(r.Body).Decode(&user)
+			user.ID = 3
+			user.Name += "@" + r.Header.Get("X-Tenant")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(user)
+		case r.Method == http.MethodDelete && r.URL.Path == "/users/1":
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && r.URL.Path == "/search":
+			body, _ := io.ReadAll(r.Body)
+			io.WriteString(w, r.Header.Get(HeaderContentType)+" "+string(body))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBind(t *testing.T) {
+	server := bindServer(t)
+	var api userAPI
+	assert.NoError(t, Bind(nil, &api, WithBindBaseURL(server.URL+"/")))
+	assert.Nil(t, api.helper)
+	ctx := context.Background()
+
+	user, err := api.GetUser(ctx, getUserReq{ID: 1, Fields: []string{"id", "name"}, Tenant: "t1"})
+	assert.NoError(t, err)
+	assert.Equal(t, &bindUser{ID: 1, Name: "a"}, user)
+
+	users, err := api.ListUsers(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	created, err := api.CreateUser(ctx, &createUserReq{Tenant: "t1", User: &bindUser{Name: "c"}})
+	assert.NoError(t, err)
+	assert.Equal(t, bindUser{ID: 3, Name: "c@t1"}, created)
+
+	assert.NoError(t, api.DeleteUser(getUserReq{ID: 1}))
+
+	body, err := api.Search(searchReq{Form: url.Values{"q": {"go"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, ContentTypeWWWFormURLEncoded+" q=go", body)
+
+	// 原始响应，查询参数和请求头
+	resp, err := api.Raw(getUserReq{ID: 1, Fields: []string{"id", "name"}, Tenant: "t1"})
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, "fields=id&fields=name", resp.Header.Get("X-Echo-Query"))
+	assert.Equal(t, "t1", resp.Header.Get("X-Echo-Tenant"))
+
+	resp, err = api.Raw(getUserReq{ID: 1})
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Empty(t, resp.Header.Get("X-Echo-Query")) // omitempty
+}
+
+func TestBind_StatusError(t *testing.T) {
+	server := bindServer(t)
+	var api userAPI
+	assert.NoError(t, Bind(NewClient(), &api, WithBindBaseURL(server.URL)))
+
+	user, err := api.GetUser(context.Background(), getUserReq{ID: 404})
+	assert.Nil(t, user)
+	assert.Equal(t, ErrRequestStatusCode, errorx.ClassifyError(err))
+	assert.Equal(t, ErrRequestStatusCode, errorx.ClassifyError(api.DeleteUser(getUserReq{ID: 2})))
+
+	// 原始响应不检查状态码
+	resp, err := api.Raw(getUserReq{ID: 404})
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// 上下文取消
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = api.ListUsers(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBind_Invalid(t *testing.T) {
+	var api userAPI
+	assert.Equal(t, ErrInvalidBinding, errorx.ClassifyError(Bind(nil, api)))
+	assert.Equal(t, ErrInvalidBinding, errorx.ClassifyError(Bind(nil, (*userAPI)(nil))))
+
+	invalid := []any{
+		&struct {
+			F string `method:"GET" path:"/"`
+		}{},
+		&struct {
+			F func() error `method:"FETCH" path:"/"`
+		}{},
+		&struct {
+			F func() `method:"GET" path:"/"`
+		}{},
+		&struct {
+			F func(int) error `method:"GET" path:"/"`
+		}{},
+		&struct {
+			F func(getUserReq, getUserReq) error `method:"GET" path:"/"`
+		}{},
+		&struct {
+			F func() error `method:"GET" path:"/users/{id}"`
+		}{},
+		&struct {
+			F func(struct {
+				A string `body:""`
+				B string `body:""`
+			}) error `method:"POST" path:"/"`
+		}{},
+		&struct {
+			f func() error `method:"GET" path:"/"`
+		}{},
+	}
+	for _, api := range invalid {
+		assert.Equal(t, ErrInvalidBinding, errorx.ClassifyError(Bind(nil, api)), "%T", api)
+	}
+}
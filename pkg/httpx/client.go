@@ -16,13 +16,19 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"slices"
 	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/breaker"
 )
 
 // Client 是一个封装 http.Client 的结构体
 type Client struct {
-	ctx    context.Context // 请求上下文
-	client *http.Client    // 底层 http.Client 实例
+	ctx         context.Context // 请求上下文
+	client      *http.Client    // 底层 http.Client 实例
+	middlewares []Middleware    // 中间件，作用于该客户端创建的所有请求
+	retryPolicy *RetryPolicy    // 重试策略，为 nil 时不重试
+	circuits    *circuitGroup   // 按主机划分的熔断器，为 nil 时不启用
 }
 
 // ClientOption 客户端选项函数
@@ -38,6 +44,8 @@ type clientConfig struct {
 	tlsHandshakeTimeout time.Duration
 	insecureSkipVerify  bool
 	ctx                 context.Context
+	retryPolicy         *RetryPolicy
+	circuitConfig       *breaker.Config
 }
 
 // defaultClientConfig 返回默认配置
@@ -84,7 +92,11 @@ func NewClient(opts ...ClientOption) *Client {
 		},
 	}
 
-	return newClient(cfg.ctx, httpClient)
+	client := newClient(cfg.ctx, httpClient).SetRetryPolicy(cfg.retryPolicy)
+	if cfg.circuitConfig != nil {
+		client.SetCircuitBreaker(*cfg.circuitConfig)
+	}
+	return client
 }
 
 // WithTimeout 设置请求超时时间
@@ -143,6 +155,20 @@ func WithContext(ctx context.Context) ClientOption {
 	}
 }
 
+// WithRetryPolicy 设置客户端默认重试策略
+func WithRetryPolicy(policy *RetryPolicy) ClientOption {
+	return func(c *clientConfig) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithCircuitBreaker 启用按主机划分的熔断器
+func WithCircuitBreaker(config breaker.Config) ClientOption {
+	return func(c *clientConfig) {
+		c.circuitConfig = &config
+	}
+}
+
 // NewHttpClient 创建一个使用自定义 http.Client 的 Client 实例，默认上下文为 context.Background()
 func NewHttpClient(client *http.Client) *Client {
 	return newClient(context.Background(), client)
@@ -199,6 +225,42 @@ func newClient(ctx context.Context, client *http.Client) *Client {
 	}
 }
 
+// Use 注册中间件，按注册顺序由外到内包装发送链路，只影响之后创建的请求
+//
+// 示例：
+//
+//	client := httpx.NewClient().Use(
+//	    httpx.TraceMiddleware(idgen.NewDefaultIDGenerator()),
+//	    httpx.SignMiddleware(sign.NewGenerator(&sign.GeneratorConfig{Enabled: true, SecretKey: secret})),
+//	)
+func (c *Client) Use(middlewares ...Middleware) *Client {
+	c.middlewares = append(c.middlewares, middlewares...)
+	return c
+}
+
+// SetRetryPolicy 设置默认重试策略，只影响之后创建的请求，nil 表示不重试
+func (c *Client) SetRetryPolicy(policy *RetryPolicy) *Client {
+	c.retryPolicy = policy
+	return c
+}
+
+// SetCircuitBreaker 启用按主机划分的熔断器，每个主机使用独立的 breaker.Circuit
+// 网络错误和 5xx 响应计为失败，熔断打开时请求返回 breaker.ErrOpen
+func (c *Client) SetCircuitBreaker(config breaker.Config) *Client {
+	c.circuits = newCircuitGroup(config)
+	return c
+}
+
+// Circuit 返回主机(host[:port])对应的熔断器，未启用熔断时返回 nil
+func (c *Client) Circuit(host string) *breaker.Circuit {
+	return c.circuits.get(host)
+}
+
+// Middlewares 返回已注册的中间件
+func (c *Client) Middlewares() []Middleware {
+	return c.middlewares
+}
+
 // NewRequest 创建一个新的 HTTP 请求封装 Request 实例
 func (c *Client) NewRequest(method, endpoint string) *Request {
 	return &Request{
@@ -208,6 +270,9 @@ func (c *Client) NewRequest(method, endpoint string) *Request {
 		endpoint:    endpoint,
 		queryValues: make(url.Values),
 		headers:     make(http.Header),
+		middlewares: slices.Clone(c.middlewares),
+		retryPolicy: c.retryPolicy,
+		circuits:    c.circuits,
 	}
 }
 
@@ -1,8 +1,23 @@
 package httpx
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"net"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/json"
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+	"golang.org/x/net/publicsuffix"
 )
 
 func GetCookies(url string) ([]*http.Cookie, error) {
@@ -20,3 +35,235 @@ func GetCookies(url string) ([]*http.Cookie, error) {
 	cookies := resp.Cookies()
 	return cookies, nil
 }
+
+// CookieJar 可持久化的 Cookie 容器，实现 http.CookieJar
+// 基于 net/http/cookiejar 并使用公共后缀列表校验 Domain，拒绝为 com、co.uk 等公共后缀设置 Cookie
+type CookieJar struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	records map[string]cookieRecord
+}
+
+// cookieRecord 持久化的 Cookie
+type cookieRecord struct {
+	URL      string        `json:"url"` // 设置 Cookie 的来源 URL，加载时用于校验 Domain
+	Name     string        `json:"name"`
+	Value    string        `json:"value"`
+	Domain   string        `json:"domain,omitempty"`
+	HostOnly bool          `json:"hostOnly,omitempty"` // 仅发送到 Domain 主机本身，不发送到子域名
+	Path     string        `json:"path,omitempty"`
+	Expires  *time.Time    `json:"expires,omitempty"` // 为空表示会话 Cookie
+	Secure   bool          `json:"secure,omitempty"`
+	HttpOnly bool          `json:"httpOnly,omitempty"`
+	SameSite http.SameSite `json:"sameSite,omitempty"`
+}
+
+// NewCookieJar 创建空的 Cookie 容器
+func NewCookieJar() *CookieJar {
+	return &CookieJar{jar: newPublicSuffixJar(), records: make(map[string]cookieRecord)}
+}
+
+// LoadCookieJar 从 JSON 文件加载 Cookie 容器，文件不存在时返回空容器
+func LoadCookieJar(path string) (*CookieJar, error) {
+	j := NewCookieJar()
+	if err := j.Load(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return j, nil
+}
+
+// newPublicSuffixJar 创建使用公共后缀列表的 cookiejar
+func newPublicSuffixJar() *cookiejar.Jar {
+	jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	return jar
+}
+
+// SetCookies 实现 http.CookieJar 接口
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar.SetCookies(u, cookies)
+
+	now := time.Now()
+	for _, c := range cookies {
+		domain, cookiePath, hostOnly, ok := cookieScope(u, c)
+		if !ok {
+			continue // 公共后缀、其他域名等被 cookiejar 拒绝的 Cookie 既不保存也不删除已有记录
+		}
+		key := cookieKey(domain, cookiePath, hostOnly, c.Name)
+		// cookiejar 按 Domain、Path、Name 区分 Cookie，同名的主机 Cookie 与域 Cookie 相互覆盖
+		delete(j.records, cookieKey(domain, cookiePath, !hostOnly, c.Name))
+		if c.MaxAge < 0 || (!c.Expires.IsZero() && !c.Expires.After(now)) {
+			delete(j.records, key) // 删除 Cookie
+			continue
+		}
+		if !j.accepted(domain, cookiePath, c) {
+			continue
+		}
+		record := cookieRecord{
+			URL:      (&url.URL{Scheme: u.Scheme, Host: u.Host, Path: u.Path}).String(),
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   domain,
+			HostOnly: hostOnly,
+			Path:     c.Path,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: c.SameSite,
+		}
+		switch {
+		case c.MaxAge > 0:
+			expires := now.Add(time.Duration(c.MaxAge) * time.Second)
+			record.Expires = &expires
+		case !c.Expires.IsZero():
+			expires := c.Expires
+			record.Expires = &expires
+		}
+		j.records[key] = record
+	}
+}
+
+// accepted 判断 Cookie 是否已被 cookiejar 接受
+func (j *CookieJar) accepted(domain, cookiePath string, c *http.Cookie) bool {
+	if strings.Contains(domain, ":") {
+		domain = "[" + domain + "]" // IPv6 地址
+	}
+	// 使用 https 查询，Secure Cookie 也会返回
+	for _, stored := range j.jar.Cookies(&url.URL{Scheme: "https", Host: domain, Path: cookiePath}) {
+		if stored.Name == c.Name && stored.Value == c.Value {
+			return true
+		}
+	}
+	return false
+}
+
+// Cookies 实现 http.CookieJar 接口
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.jar.Cookies(u)
+}
+
+// AllCookies 返回容器中所有未过期的 Cookie
+func (j *CookieJar) AllCookies() []*http.Cookie {
+	records := j.liveRecords()
+	cookies := make([]*http.Cookie, 0, len(records))
+	for _, record := range records {
+		cookies = append(cookies, record.cookie())
+	}
+	return cookies
+}
+
+// Clear 清空所有 Cookie
+func (j *CookieJar) Clear() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.jar = newPublicSuffixJar()
+	j.records = make(map[string]cookieRecord)
+}
+
+// Save 将未过期的 Cookie(包括会话 Cookie)保存为 JSON 文件
+func (j *CookieJar) Save(path string) error {
+	data, err := json.MarshalIndent(j.liveRecords(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// Load 从 JSON 文件加载 Cookie 并合并到容器，已过期的 Cookie 被忽略
+func (j *CookieJar) Load(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var records []cookieRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return err
+	}
+	for _, record := range records {
+		u, err := url.Parse(record.URL)
+		if err != nil {
+			return err
+		}
+		j.SetCookies(u, []*http.Cookie{record.cookie()})
+	}
+	return nil
+}
+
+// liveRecords 返回按 Domain、Path、Name 排序的未过期记录
+func (j *CookieJar) liveRecords() []cookieRecord {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	keys := make([]string, 0, len(j.records))
+	for key, record := range j.records {
+		if record.Expires == nil || record.Expires.After(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	records := make([]cookieRecord, 0, len(keys))
+	for _, key := range keys {
+		records = append(records, j.records[key])
+	}
+	return records
+}
+
+// cookie 转换为 http.Cookie
+func (r cookieRecord) cookie() *http.Cookie {
+	c := &http.Cookie{
+		Name:     r.Name,
+		Value:    r.Value,
+		Domain:   mathx.IF(r.HostOnly, "", r.Domain), // 主机 Cookie 不设置 Domain，由来源 URL 决定
+		Path:     r.Path,
+		Secure:   r.Secure,
+		HttpOnly: r.HttpOnly,
+		SameSite: r.SameSite,
+	}
+	if r.Expires != nil {
+		c.Expires = *r.Expires
+	}
+	return c
+}
+
+// cookieKey 生成 Cookie 的唯一键(Domain、是否主机 Cookie、Path、Name)
+func cookieKey(domain, cookiePath string, hostOnly bool, name string) string {
+	return domain + ";" + mathx.IF(hostOnly, "host", "domain") + ";" + cookiePath + ";" + name
+}
+
+// cookieScope 按 cookiejar 的规则返回 Cookie 生效的域名、路径以及是否为主机 Cookie，
+// ok 为 false 表示 cookiejar 会拒绝该 Cookie(如为公共后缀或其他域名设置)
+func cookieScope(u *url.URL, c *http.Cookie) (domain, cookiePath string, hostOnly, ok bool) {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", false, false
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	cookiePath = c.Path
+	if cookiePath == "" || cookiePath[0] != '/' {
+		// 与 cookiejar 一致，默认使用请求路径所在目录
+		cookiePath = "/"
+		if i := strings.LastIndex(u.Path, "/"); i > 0 {
+			cookiePath = u.Path[:i]
+		}
+	}
+
+	domain = strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+	switch {
+	case c.Domain == "":
+		return host, cookiePath, true, true
+	case net.ParseIP(host) != nil:
+		// IP 地址只能设置为自身的主机 Cookie
+		return host, cookiePath, true, c.Domain == host
+	case domain == "" || domain[0] == '.' || domain[len(domain)-1] == '.':
+		return "", "", false, false
+	}
+	if ps, _ := publicsuffix.PublicSuffix(domain); ps == domain {
+		// 公共后缀只能由其自身设置为主机 Cookie
+		return host, cookiePath, true, host == domain
+	}
+	return domain, cookiePath, false, host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -3,7 +3,10 @@ package httpx
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert" // 引入 testify/assert 包
 )
@@ -47,3 +50,112 @@ func TestGetCookies(t *testing.T) {
 		assert.Equal(t, expectedCookies[cookie.Name], cookie.Value)
 	}
 }
+
+// TestCookieJar 测试 Cookie 容器的公共后缀校验、删除与持久化
+func TestCookieJar(t *testing.T) {
+	jar := NewCookieJar()
+	u, _ := url.Parse("https://www.example.co.uk/account/login")
+
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "s1"},
+		{Name: "theme", Value: "dark", Domain: ".example.co.uk", Path: "/", MaxAge: 3600},
+		{Name: "tracker", Value: "x", Domain: "co.uk"},    // 公共后缀，被拒绝
+		{Name: "foreign", Value: "x", Domain: "evil.com"}, // 其他域名，被拒绝
+		{Name: "expired", Value: "old", Expires: time.Now().Add(-time.Hour)},
+	})
+
+	names := func(cookies []*http.Cookie) []string {
+		var result []string
+		for _, c := range cookies {
+			result = append(result, c.Name)
+		}
+		return result
+	}
+	other, _ := url.Parse("https://shop.example.co.uk/")
+	assert.ElementsMatch(t, []string{"session", "theme"}, names(jar.Cookies(u)))
+	assert.ElementsMatch(t, []string{"theme"}, names(jar.Cookies(other)))
+	assert.ElementsMatch(t, []string{"session", "theme"}, names(jar.AllCookies())) // 被拒绝的 Cookie 不保存
+
+	// 删除 Cookie
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", MaxAge: -1}})
+	assert.ElementsMatch(t, []string{"theme"}, names(jar.Cookies(u)))
+
+	// 保存并重新加载
+	jar.SetCookies(u, []*http.Cookie{{Name: "session", Value: "s2"}})
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	assert.NoError(t, jar.Save(path))
+
+	loaded, err := LoadCookieJar(path)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, names(jar.Cookies(u)), names(loaded.Cookies(u)))
+	assert.ElementsMatch(t, []string{"theme"}, names(loaded.Cookies(other)))
+	for _, c := range loaded.AllCookies() {
+		if c.Name == "theme" {
+			assert.WithinDuration(t, time.Now().Add(time.Hour), c.Expires, time.Minute) // Max-Age 转换为过期时间
+		}
+	}
+
+	loaded.Clear()
+	assert.Empty(t, loaded.AllCookies())
+	assert.Empty(t, loaded.Cookies(u))
+
+	// 文件不存在时返回空容器
+	empty, err := LoadCookieJar(filepath.Join(t.TempDir(), "missing.json"))
+	assert.NoError(t, err)
+	assert.Empty(t, empty.AllCookies())
+}
+
+// TestCookieJarForeignDelete 其他域名无法删除已保存的 Cookie
+func TestCookieJarForeignDelete(t *testing.T) {
+	jar := NewCookieJar()
+	bank, _ := url.Parse("https://bank.com/")
+	evil, _ := url.Parse("https://evil.com/")
+	jar.SetCookies(bank, []*http.Cookie{{Name: "session", Value: "abc", Path: "/"}})
+
+	jar.SetCookies(evil, []*http.Cookie{
+		{Name: "session", Domain: "bank.com", Path: "/", MaxAge: -1},
+		{Name: "session", Domain: ".bank.com", Path: "/", Expires: time.Now().Add(-time.Hour)},
+	})
+	assert.Len(t, jar.Cookies(bank), 1)
+	all := jar.AllCookies()
+	assert.Len(t, all, 1)
+	assert.Equal(t, "abc", all[0].Value)
+
+	// 本域名可以删除
+	jar.SetCookies(bank, []*http.Cookie{{Name: "session", Path: "/", MaxAge: -1}})
+	assert.Empty(t, jar.Cookies(bank))
+	assert.Empty(t, jar.AllCookies())
+}
+
+// TestCookieJarHostOnly 主机 Cookie 与域 Cookie 持久化后保持作用范围
+func TestCookieJarHostOnly(t *testing.T) {
+	jar := NewCookieJar()
+	host, _ := url.Parse("https://example.com/")
+	sub, _ := url.Parse("https://www.example.com/")
+	jar.SetCookies(host, []*http.Cookie{
+		{Name: "sid", Value: "host", Path: "/"},
+		{Name: "lang", Value: "zh", Domain: "example.com", Path: "/"},
+	})
+
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	assert.NoError(t, jar.Save(path))
+	loaded, err := LoadCookieJar(path)
+	assert.NoError(t, err)
+	for _, j := range []*CookieJar{jar, loaded} {
+		assert.Len(t, j.Cookies(host), 2)
+		subCookies := j.Cookies(sub)
+		assert.Len(t, subCookies, 1) // 主机 Cookie 不发送到子域名
+		assert.Equal(t, "lang", subCookies[0].Name)
+	}
+	for _, c := range loaded.AllCookies() {
+		assert.Equal(t, map[string]string{"sid": "", "lang": "example.com"}[c.Name], c.Domain)
+	}
+
+	// 同名的域 Cookie 覆盖主机 Cookie，与 cookiejar 一致只保留一条记录
+	jar.SetCookies(host, []*http.Cookie{{Name: "sid", Value: "domain", Domain: "example.com", Path: "/"}})
+	assert.Len(t, jar.AllCookies(), 2)
+	assert.NoError(t, jar.Save(path))
+	loaded, err = LoadCookieJar(path)
+	assert.NoError(t, err)
+	assert.Len(t, loaded.Cookies(sub), 2)
+}
@@ -18,6 +18,11 @@ const (
 	ErrBodyEncodeFuncNotSet                                    // 请求体编码函数未设置
 	ErrExpectedDestinationType                                 // 期望的目标类型不匹配
 	ErrRequestStatusCode                                       // 请求状态码错误
+	ErrUnsupportedChecksum                                     // 不支持的校验算法
+	ErrChecksumMismatch                                        // 校验和不一致
+	ErrUnexpectedContentRange                                  // 续传响应的 Content-Range 不匹配
+	ErrNotLoggedIn                                             // 会话未登录
+	ErrInvalidBinding                                          // 声明式 API 定义无效
 )
 
 // 注册所有错误类型和消息
@@ -27,4 +32,9 @@ func init() {
 	errorx.RegisterError(ErrBodyEncodeFuncNotSet, "body encode function is not set")
 	errorx.RegisterError(ErrExpectedDestinationType, "expected dst to be *string, but got %T")
 	errorx.RegisterError(ErrRequestStatusCode, "request failed with status: %s")
+	errorx.RegisterError(ErrUnsupportedChecksum, "unsupported checksum algorithm: %s")
+	errorx.RegisterError(ErrChecksumMismatch, "checksum mismatch: expected %s, got %s")
+	errorx.RegisterError(ErrUnexpectedContentRange, "unexpected Content-Range %q for resume offset %d")
+	errorx.RegisterError(ErrNotLoggedIn, "session is not logged in")
+	errorx.RegisterError(ErrInvalidBinding, "invalid api binding %s: %s")
 }
@@ -0,0 +1,205 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-31 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-31 14:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\httpxtest\recorder.go
+ * @Description: 录制/回放模式，将真实交互保存为 golden 文件
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package httpxtest
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kamalyes/go-toolbox/pkg/json"
+)
+
+// EnvRecord 设置该环境变量(非空)时 NewRecorder 强制重新录制
+const EnvRecord = "HTTPXTEST_RECORD"
+
+// skipRecordHeaders 录制响应时忽略的响应头
+var skipRecordHeaders = []string{"Date", "Content-Length"}
+
+// Interaction 一次录制的请求与响应
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest 录制的请求
+type RecordedRequest struct {
+	Method string `json:"method"`
+	URI    string `json:"uri"` // 路径和查询参数
+	Body   string `json:"body,omitempty"`
+}
+
+// RecordedResponse 录制的响应
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// recorder 将未命中桩的请求转发到真实服务并记录交互
+type recorder struct {
+	upstream     string
+	golden       string
+	client       *http.Client
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecorder 创建录制/回放测试服务：
+// golden 文件不存在或设置了 HTTPXTEST_RECORD 环境变量时将请求转发到 upstream 并录制，否则从 golden 文件回放
+func NewRecorder(t testing.TB, upstream, golden string) *Server {
+	t.Helper()
+	s := NewServer(t)
+	if _, err := os.Stat(golden); err != nil || os.Getenv(EnvRecord) != "" {
+		return s.Record(upstream, golden)
+	}
+	return s.Replay(golden)
+}
+
+// Record 将未命中桩的请求转发到 upstream 并录制，测试结束时写入 golden 文件
+func (s *Server) Record(upstream, golden string) *Server {
+	rec := &recorder{
+		upstream: strings.TrimSuffix(upstream, "/"),
+		golden:   golden,
+		client: &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse // 重定向原样录制
+		}},
+	}
+	s.mu.Lock()
+	s.recorder = rec
+	s.mu.Unlock()
+
+	s.t.Cleanup(func() {
+		if err := rec.save(); err != nil {
+			s.t.Errorf("httpxtest: save golden file: %v", err)
+		}
+	})
+	return s
+}
+
+// Replay 从 golden 文件加载交互并注册为桩，每条交互只命中一次，相同请求按录制顺序返回
+func (s *Server) Replay(golden string) *Server {
+	s.t.Helper()
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		s.t.Fatalf("httpxtest: read golden file: %v", err)
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		s.t.Fatalf("httpxtest: decode golden file %s: %v", golden, err)
+	}
+
+	for _, interaction := range interactions {
+		recorded := interaction.Request
+		path, rawQuery, _ := strings.Cut(recorded.URI, "?")
+		stub := s.On(recorded.Method, path).Times(1).MatchFunc(func(r *http.Request, body []byte) bool {
+			return sameQuery(r.URL.RawQuery, rawQuery) && sameBody(body, recorded.Body)
+		})
+		stub.replyHeader = interaction.Response.Header.Clone()
+		stub.Reply(interaction.Response.Status, interaction.Response.Body)
+	}
+	return s
+}
+
+// Interactions 返回已录制的交互
+func (s *Server) Interactions() []Interaction {
+	s.mu.Lock()
+	rec := s.recorder
+	s.mu.Unlock()
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]Interaction(nil), rec.interactions...)
+}
+
+// forward 转发请求到真实服务并录制
+func (rec *recorder) forward(w http.ResponseWriter, r *http.Request, body []byte) {
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, rec.upstream+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, "httpxtest: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	req.Header = r.Header.Clone()
+	req.Header.Del("Accept-Encoding") // 由 Transport 自动解压，录制明文响应体
+
+	resp, err := rec.client.Do(req)
+	if err != nil {
+		http.Error(w, "httpxtest: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, "httpxtest: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	header := resp.Header.Clone()
+	for _, key := range skipRecordHeaders {
+		header.Del(key)
+	}
+	rec.mu.Lock()
+	rec.interactions = append(rec.interactions, Interaction{
+		Request:  RecordedRequest{Method: r.Method, URI: r.URL.RequestURI(), Body: string(body)},
+		Response: RecordedResponse{Status: resp.StatusCode, Header: header, Body: string(respBody)},
+	})
+	rec.mu.Unlock()
+
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// save 写入 golden 文件
+func (rec *recorder) save() error {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	data, err := json.MarshalIndent(append([]Interaction{}, rec.interactions...), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(rec.golden), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(rec.golden, data, 0o644)
+}
+
+// sameQuery 比较查询参数(忽略顺序)
+func sameQuery(actual, expected string) bool {
+	if actual == expected {
+		return true
+	}
+	a, errA := url.ParseQuery(actual)
+	b, errB := url.ParseQuery(expected)
+	return errA == nil && errB == nil && reflect.DeepEqual(a, b)
+}
+
+// sameBody 比较请求体，JSON 请求体忽略字段顺序和空白
+func sameBody(actual []byte, expected string) bool {
+	if string(actual) == expected {
+		return true
+	}
+	a, errA := normalizeJSON(actual)
+	b, errB := normalizeJSON(expected)
+	return errA == nil && errB == nil && reflect.DeepEqual(a, b)
+}
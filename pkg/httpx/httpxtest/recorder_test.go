@@ -0,0 +1,83 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-31 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-31 14:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\httpxtest\recorder_test.go
+ * @Description: 录制/回放测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package httpxtest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kamalyes/go-toolbox/pkg/httpx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	var upstreamCalls atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := upstreamCalls.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Call", string(rune('0'+n)))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(r.Method + " " + r.URL.RequestURI() + " " + string(body)))
+	}))
+	defer upstream.Close()
+
+	golden := filepath.Join(t.TempDir(), "testdata", "api.json")
+	send := func(srv *Server) []string {
+		client := httpx.NewClient()
+		var bodies []string
+		for _, req := range []*httpx.Request{
+			client.Get(srv.URL + "/items?b=2&a=1"),
+			client.Post(srv.URL + "/items").SetBodyJSON(map[string]any{"x": 1, "y": 2}),
+			client.Get(srv.URL + "/items?b=2&a=1"),
+		} {
+			resp, err := req.Send()
+			assert.NoError(t, err)
+			body, _ := resp.String()
+			bodies = append(bodies, resp.Header.Get("X-Call")+" "+body)
+		}
+		return bodies
+	}
+
+	var recorded []string
+	t.Run("record", func(t *testing.T) {
+		srv := NewRecorder(t, upstream.URL, golden)
+		recorded = send(srv)
+		assert.Len(t, srv.Interactions(), 3)
+	})
+	assert.FileExists(t, golden)
+	assert.Equal(t, int32(3), upstreamCalls.Load())
+
+	t.Run("replay", func(t *testing.T) {
+		srv := NewRecorder(t, upstream.URL, golden)
+		assert.Equal(t, recorded, send(srv))
+		assert.True(t, srv.AssertNoUnmatched())
+		assert.Nil(t, srv.Interactions())
+	})
+	assert.Equal(t, int32(3), upstreamCalls.Load()) // 回放不访问真实服务
+
+	// 强制重新录制
+	t.Run("rerecord", func(t *testing.T) {
+		t.Setenv(EnvRecord, "1")
+		NewRecorder(t, upstream.URL, golden)
+	})
+}
+
+func TestSameBodyAndQuery(t *testing.T) {
+	assert.True(t, sameQuery("a=1&b=2", "b=2&a=1"))
+	assert.False(t, sameQuery("a=1", "a=2"))
+	assert.True(t, sameBody([]byte(`{"a":1,"b":2}`), `{"b": 2, "a": 1}`))
+	assert.True(t, sameBody(nil, ""))
+	assert.False(t, sameBody([]byte("plain"), "other"))
+}
@@ -0,0 +1,416 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-31 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-31 14:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\httpxtest\server.go
+ * @Description: 可编程的 HTTP 测试服务，用于测试基于 httpx 的客户端
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package httpxtest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/httpx"
+	"github.com/kamalyes/go-toolbox/pkg/json"
+	"github.com/kamalyes/go-toolbox/pkg/matcher"
+)
+
+// Call 服务收到的请求
+type Call struct {
+	Method  string      // 请求方法
+	Path    string      // 请求路径
+	Query   url.Values  // 查询参数
+	Header  http.Header // 请求头
+	Body    []byte      // 请求体
+	Matched bool        // 是否命中桩
+}
+
+// TemplateData 模板响应可使用的请求数据
+type TemplateData struct {
+	Method string      // 请求方法
+	Path   string      // 请求路径
+	Query  url.Values  // 查询参数，如 {{.Query.Get "id"}}
+	Header http.Header // 请求头，如 {{.Header.Get "X-User"}}
+	Body   string      // 原始请求体
+	JSON   any         // 按 JSON 解析的请求体，解析失败时为 nil，如 {{.JSON.name}}
+}
+
+// Server 可编程的 HTTP 测试服务，按注册顺序匹配桩，未命中时返回 501
+//
+// 示例：
+//
+//	srv := httpxtest.NewServer(t)
+//	srv.On(http.MethodPost, "/users").MatchJSON(map[string]any{"name": "kamal"}).ReplyJSON(201, user)
+//	srv.On(http.MethodGet, "/slow").Delay(time.Second).FailTimes(2, 503).Reply(200, "ok")
+//	resp, err := httpx.NewClient().Post(srv.URL + "/users").SetBodyJSON(req).Send()
+//	srv.AssertCalled(http.MethodPost, "/users", 1)
+type Server struct {
+	*httptest.Server
+	t        testing.TB
+	mu       sync.Mutex
+	stubs    []*Stub
+	calls    []Call
+	recorder *recorder
+}
+
+// NewServer 创建并启动测试服务，测试结束时自动关闭
+func NewServer(t testing.TB) *Server {
+	t.Helper()
+	s := &Server{t: t}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// On 注册精确匹配路径的桩，method 为空时匹配任意方法
+func (s *Server) On(method, path string) *Stub {
+	pm, _ := matcher.NewPathMatcher(matcher.PathMatchExact, path)
+	return s.OnPath(method, pm)
+}
+
+// OnPath 使用 matcher.PathMatcher 注册桩，支持前缀、Glob、正则等匹配方式
+func (s *Server) OnPath(method string, pm *matcher.PathMatcher) *Stub {
+	stub := &Stub{server: s, method: method, path: pm, status: http.StatusOK, header: make(http.Header)}
+	s.mu.Lock()
+	s.stubs = append(s.stubs, stub)
+	s.mu.Unlock()
+	return stub
+}
+
+// Calls 返回收到的全部请求
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+// CallCount 返回指定方法和路径的请求次数，method 为空时统计任意方法
+func (s *Server) CallCount(method, path string) int {
+	count := 0
+	for _, call := range s.Calls() {
+		if (method == "" || call.Method == method) && call.Path == path {
+			count++
+		}
+	}
+	return count
+}
+
+// AssertCalled 断言指定方法和路径的请求次数
+func (s *Server) AssertCalled(method, path string, times int) bool {
+	s.t.Helper()
+	if count := s.CallCount(method, path); count != times {
+		s.t.Errorf("httpxtest: expected %d call(s) to %s %s, got %d", times, method, path, count)
+		return false
+	}
+	return true
+}
+
+// AssertAllCalled 断言每个桩至少被命中一次
+func (s *Server) AssertAllCalled() bool {
+	s.t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ok := true
+	for _, stub := range s.stubs {
+		if stub.calls == 0 {
+			s.t.Errorf("httpxtest: stub %s was never called", stub)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertNoUnmatched 断言没有未命中桩的请求
+func (s *Server) AssertNoUnmatched() bool {
+	s.t.Helper()
+	ok := true
+	for _, call := range s.Calls() {
+		if !call.Matched {
+			s.t.Errorf("httpxtest: unmatched request %s %s", call.Method, call.Path)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// Reset 清空已注册的桩和请求记录
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stubs, s.calls = nil, nil
+}
+
+// serveHTTP 匹配桩并写入响应
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	call := Call{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header.Clone(), Body: body}
+
+	s.mu.Lock()
+	var stub *Stub
+	var hits int
+	for _, candidate := range s.stubs {
+		if candidate.matches(r, body) {
+			stub = candidate
+			stub.calls++
+			hits = stub.calls
+			break
+		}
+	}
+	call.Matched = stub != nil
+	s.calls = append(s.calls, call)
+	recorder := s.recorder
+	s.mu.Unlock()
+
+	switch {
+	case stub != nil:
+		stub.serve(w, r, body, hits)
+	case recorder != nil:
+		recorder.forward(w, r, body)
+	default:
+		http.Error(w, fmt.Sprintf("httpxtest: no stub for %s %s", r.Method, r.URL.RequestURI()), http.StatusNotImplemented)
+	}
+}
+
+// Stub 请求桩，匹配条件与响应均通过链式方法设置
+type Stub struct {
+	server   *Server
+	method   string
+	path     *matcher.PathMatcher
+	header   http.Header
+	query    url.Values
+	jsonBody any
+	match    func(r *http.Request, body []byte) bool
+	times    int
+
+	status      int
+	replyHeader http.Header
+	body        []byte
+	tmpl        *template.Template
+	handler     http.HandlerFunc
+	delay       time.Duration
+	abort       bool
+	failures    int
+	failCode    int
+
+	calls int // 命中次数，受 server.mu 保护
+}
+
+// String 返回桩的描述
+func (st *Stub) String() string {
+	method := st.method
+	if method == "" {
+		method = "*"
+	}
+	return method + " " + st.path.Pattern()
+}
+
+// MatchHeader 要求请求头等于指定值
+func (st *Stub) MatchHeader(key, value string) *Stub {
+	st.header.Set(key, value)
+	return st
+}
+
+// MatchQuery 要求查询参数等于指定值
+func (st *Stub) MatchQuery(key, value string) *Stub {
+	if st.query == nil {
+		st.query = make(url.Values)
+	}
+	st.query.Set(key, value)
+	return st
+}
+
+// MatchJSON 要求请求体按 JSON 解析后与 v 相等(忽略字段顺序和空白)
+func (st *Stub) MatchJSON(v any) *Stub {
+	st.server.t.Helper()
+	normalized, err := normalizeJSON(v)
+	if err != nil {
+		st.server.t.Fatalf("httpxtest: invalid JSON matcher: %v", err)
+	}
+	st.jsonBody = normalized
+	return st
+}
+
+// MatchFunc 自定义匹配条件
+func (st *Stub) MatchFunc(fn func(r *http.Request, body []byte) bool) *Stub {
+	st.match = fn
+	return st
+}
+
+// Times 限制桩最多命中 n 次，之后的请求继续匹配后续桩
+func (st *Stub) Times(n int) *Stub {
+	st.times = n
+	return st
+}
+
+// Reply 设置响应状态码和响应体
+func (st *Stub) Reply(status int, body string) *Stub {
+	st.status, st.body = status, []byte(body)
+	return st
+}
+
+// ReplyJSON 设置 JSON 响应
+func (st *Stub) ReplyJSON(status int, v any) *Stub {
+	st.server.t.Helper()
+	body, err := json.Marshal(v)
+	if err != nil {
+		st.server.t.Fatalf("httpxtest: marshal reply: %v", err)
+	}
+	return st.ReplyHeader(httpx.HeaderContentType, httpx.ContentTypeApplicationJSON).Reply(status, string(body))
+}
+
+// ReplyTemplate 设置模板响应，模板数据见 TemplateData
+func (st *Stub) ReplyTemplate(status int, text string) *Stub {
+	st.server.t.Helper()
+	tmpl, err := template.New(st.String()).Parse(text)
+	if err != nil {
+		st.server.t.Fatalf("httpxtest: parse reply template: %v", err)
+	}
+	st.status, st.tmpl = status, tmpl
+	return st
+}
+
+// ReplyHeader 设置响应头
+func (st *Stub) ReplyHeader(key, value string) *Stub {
+	if st.replyHeader == nil {
+		st.replyHeader = make(http.Header)
+	}
+	st.replyHeader.Set(key, value)
+	return st
+}
+
+// ReplyFunc 使用自定义处理函数响应，请求体已被读取并还原
+func (st *Stub) ReplyFunc(fn http.HandlerFunc) *Stub {
+	st.handler = fn
+	return st
+}
+
+// Delay 响应前等待指定时间，客户端取消请求时提前结束
+func (st *Stub) Delay(d time.Duration) *Stub {
+	st.delay = d
+	return st
+}
+
+// Abort 不返回响应直接断开连接，模拟网络故障
+func (st *Stub) Abort() *Stub {
+	st.abort = true
+	return st
+}
+
+// FailTimes 前 n 次命中返回指定状态码，之后返回正常响应，用于测试重试
+func (st *Stub) FailTimes(n, status int) *Stub {
+	st.failures, st.failCode = n, status
+	return st
+}
+
+// CallCount 返回桩的命中次数
+func (st *Stub) CallCount() int {
+	st.server.mu.Lock()
+	defer st.server.mu.Unlock()
+	return st.calls
+}
+
+// matches 判断请求是否命中桩，调用方持有 server.mu
+func (st *Stub) matches(r *http.Request, body []byte) bool {
+	if st.times > 0 && st.calls >= st.times {
+		return false
+	}
+	if st.method != "" && st.method != r.Method {
+		return false
+	}
+	if !st.path.Match(r.URL.Path) {
+		return false
+	}
+	for key := range st.header {
+		if r.Header.Get(key) != st.header.Get(key) {
+			return false
+		}
+	}
+	query := r.URL.Query()
+	for key := range st.query {
+		if query.Get(key) != st.query.Get(key) {
+			return false
+		}
+	}
+	if st.jsonBody != nil {
+		var actual any
+		if json.Unmarshal(body, &actual) != nil || !reflect.DeepEqual(actual, st.jsonBody) {
+			return false
+		}
+	}
+	return st.match == nil || st.match(r, body)
+}
+
+// serve 写入桩响应，hits 为包含本次在内的命中次数
+func (st *Stub) serve(w http.ResponseWriter, r *http.Request, body []byte, hits int) {
+	if st.delay > 0 {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(st.delay):
+		}
+	}
+	if st.abort {
+		panic(http.ErrAbortHandler)
+	}
+
+	if hits <= st.failures {
+		w.WriteHeader(st.failCode)
+		return
+	}
+
+	if st.handler != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		st.handler(w, r)
+		return
+	}
+
+	for key, values := range st.replyHeader {
+		w.Header()[key] = values
+	}
+	if st.tmpl == nil {
+		w.WriteHeader(st.status)
+		w.Write(st.body)
+		return
+	}
+
+	data := TemplateData{Method: r.Method, Path: r.URL.Path, Query: r.URL.Query(), Header: r.Header, Body: string(body)}
+	json.Unmarshal(body, &data.JSON)
+	var buf bytes.Buffer
+	if err := st.tmpl.Execute(&buf, data); err != nil {
+		http.Error(w, "httpxtest: execute reply template: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(st.status)
+	w.Write(buf.Bytes())
+}
+
+// normalizeJSON 将值转换为 JSON 解析后的通用结构，便于比较，[]byte 和 string 视为 JSON 文本
+func normalizeJSON(v any) (normalized any, err error) {
+	var data []byte
+	switch value := v.(type) {
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		if data, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+	err = json.Unmarshal(data, &normalized)
+	return normalized, err
+}
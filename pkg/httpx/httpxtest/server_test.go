@@ -0,0 +1,150 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-31 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-31 14:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\httpxtest\server_test.go
+ * @Description: 测试服务测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package httpxtest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/httpx"
+	"github.com/kamalyes/go-toolbox/pkg/matcher"
+	"github.com/kamalyes/go-toolbox/pkg/syncx"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeT 记录断言失败而不终止测试
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...any) {
+	f.errors = append(f.errors, format)
+}
+
+func TestServerMatch(t *testing.T) {
+	srv := NewServer(t)
+	srv.On(http.MethodPost, "/users").
+		MatchHeader("X-Tenant", "a").
+		MatchJSON(`{"name": "kamal", "age": 18}`).
+		ReplyJSON(http.StatusCreated, map[string]any{"id": 1})
+	srv.On(http.MethodGet, "/users").MatchQuery("page", "2").Reply(http.StatusOK, "page 2")
+	globPath, _ := matcher.NewPathMatcher(matcher.PathMatchGlob, "/files/*")
+	srv.OnPath("", globPath).Reply(http.StatusOK, "file")
+
+	client := httpx.NewClient()
+	resp, err := client.Post(srv.URL+"/users").
+		SetHeader("X-Tenant", "a").
+		SetBodyJSON(map[string]any{"age": 18, "name": "kamal"}).
+		Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, httpx.ContentTypeApplicationJSON, resp.Header.Get(httpx.HeaderContentType))
+	assert.JSONEq(t, `{"id":1}`, body)
+
+	resp, err = client.Get(srv.URL+"/users").SetQuery("page", "2").Send()
+	assert.NoError(t, err)
+	body, _ = resp.String()
+	assert.Equal(t, "page 2", body)
+
+	resp, err = client.Delete(srv.URL + "/files/a/b.txt").Send()
+	assert.NoError(t, err)
+	body, _ = resp.String()
+	assert.Equal(t, "file", body)
+
+	// 请求体不匹配时返回 501
+	resp, err = client.Post(srv.URL+"/users").SetHeader("X-Tenant", "a").SetBodyJSON(map[string]any{"name": "other"}).Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, http.StatusNotImplemented, resp.StatusCode)
+
+	assert.True(t, srv.AssertCalled(http.MethodPost, "/users", 2))
+	assert.True(t, srv.AssertAllCalled())
+	assert.Len(t, srv.Calls(), 4)
+
+	ft := &fakeT{TB: t}
+	srv.t = ft
+	assert.False(t, srv.AssertNoUnmatched())
+	assert.False(t, srv.AssertCalled("", "/users", 1))
+	assert.Len(t, ft.errors, 2)
+}
+
+func TestServerTemplateAndFunc(t *testing.T) {
+	srv := NewServer(t)
+	srv.On(http.MethodPost, "/echo").ReplyTemplate(http.StatusOK, `{{.Method}} {{.Query.Get "id"}} {{.Header.Get "X-User"}} {{.JSON.name}}`)
+	srv.On("", "/func").ReplyFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Method", r.Method)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	resp, err := httpx.NewClient().Post(srv.URL+"/echo").
+		SetQuery("id", "7").
+		SetHeader("X-User", "u1").
+		SetBodyJSON(map[string]string{"name": "kamal"}).
+		Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Equal(t, "POST 7 u1 kamal", body)
+
+	resp, err = httpx.NewClient().Put(srv.URL + "/func").Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	assert.Equal(t, http.MethodPut, resp.Header.Get("X-Method"))
+}
+
+func TestServerTimes(t *testing.T) {
+	srv := NewServer(t)
+	first := srv.On(http.MethodGet, "/seq").Times(1).Reply(http.StatusOK, "first")
+	srv.On(http.MethodGet, "/seq").Reply(http.StatusOK, "rest")
+
+	client := httpx.NewClient()
+	var bodies []string
+	for range 3 {
+		resp, err := client.Get(srv.URL + "/seq").Send()
+		assert.NoError(t, err)
+		body, _ := resp.String()
+		bodies = append(bodies, body)
+	}
+	assert.Equal(t, []string{"first", "rest", "rest"}, bodies)
+	assert.Equal(t, 1, first.CallCount())
+
+	srv.Reset()
+	assert.Empty(t, srv.Calls())
+}
+
+func TestServerFaultInjection(t *testing.T) {
+	srv := NewServer(t)
+	srv.On(http.MethodGet, "/flaky").FailTimes(2, http.StatusServiceUnavailable).Reply(http.StatusOK, "ok")
+	srv.On(http.MethodGet, "/slow").Delay(time.Second).Reply(http.StatusOK, "slow")
+	srv.On(http.MethodGet, "/broken").Abort()
+
+	// 配合 httpx 重试策略
+	policy := &httpx.RetryPolicy{MaxAttempts: 3, Backoff: syncx.ConstantBackoff(0)}
+	resp, err := httpx.NewClient(httpx.WithRetryPolicy(policy)).Get(srv.URL + "/flaky").Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Equal(t, "ok", body)
+	assert.Equal(t, 3, srv.CallCount(http.MethodGet, "/flaky"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = httpx.NewClient().Get(srv.URL + "/slow").WithContext(ctx).Send()
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = httpx.NewClient().Get(srv.URL + "/broken").Send()
+	assert.Error(t, err)
+}
@@ -0,0 +1,273 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-01-04 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-01-04 10:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\logging.go
+ * @Description: 请求/响应日志中间件，支持敏感信息脱敏、请求体截断和 curl 命令输出
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+
+package httpx
+
+import (
+	"io"
+	"log"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/desensitize"
+	"github.com/kamalyes/go-toolbox/pkg/json"
+)
+
+// HTTPLog 一次请求/响应的结构化日志，请求头、URL 查询参数和请求/响应体均已脱敏
+type HTTPLog struct {
+	Method            string        `json:"method"`
+	URL               string        `json:"url"`
+	RequestHeader     http.Header   `json:"request_header,omitempty"`
+	RequestBody       string        `json:"request_body,omitempty"`
+	RequestTruncated  bool          `json:"request_truncated,omitempty"` // 请求体超过 MaxBodySize 被截断
+	Status            int           `json:"status,omitempty"`
+	ResponseHeader    http.Header   `json:"response_header,omitempty"`
+	ResponseBody      string        `json:"response_body,omitempty"`
+	ResponseTruncated bool          `json:"response_truncated,omitempty"` // 响应体超过 MaxBodySize 被截断
+	Latency           time.Duration `json:"latency"`                      // 发出请求到收到响应头的耗时(纳秒)
+	Error             string        `json:"error,omitempty"`
+	Curl              string        `json:"curl,omitempty"` // 等价的 curl 命令，启用 WithLogCurl 时输出
+}
+
+// LogFunc 日志输出函数
+type LogFunc func(entry *HTTPLog)
+
+// LogOption 日志中间件选项
+type LogOption func(*logConfig)
+
+// logConfig 日志中间件配置
+type logConfig struct {
+	masker  *desensitize.DataMasker
+	logFunc LogFunc
+	body    bool
+	curl    bool
+}
+
+// WithLogMasker 设置脱敏器，默认使用 desensitize.NewMasker()
+func WithLogMasker(masker *desensitize.DataMasker) LogOption {
+	return func(c *logConfig) {
+		c.masker = masker
+	}
+}
+
+// WithLogFunc 设置日志输出函数，默认以 JSON 格式输出到标准库 log
+func WithLogFunc(fn LogFunc) LogOption {
+	return func(c *logConfig) {
+		c.logFunc = fn
+	}
+}
+
+// WithLogBody 设置是否记录请求体和响应体，默认记录
+func WithLogBody(enable bool) LogOption {
+	return func(c *logConfig) {
+		c.body = enable
+	}
+}
+
+// WithLogCurl 设置是否输出等价的 curl 命令，默认不输出
+func WithLogCurl(enable bool) LogOption {
+	return func(c *logConfig) {
+		c.curl = enable
+	}
+}
+
+// LogMiddleware 记录请求和响应的日志中间件
+// 敏感请求头、查询参数和 JSON/文本请求体字段通过 desensitize.DataMasker 脱敏，请求/响应体最多记录 MaxBodySize 字节
+// 只记录文本类型(JSON、XML、表单、text/*)的请求体和响应体，SSE、NDJSON 等流式响应不读取
+//
+// 示例：
+//
+//	client := httpx.NewClient().Use(httpx.LogMiddleware(
+//	    httpx.WithLogMasker(desensitize.NewMasker().AddSensitiveKeys("phone")),
+//	    httpx.WithLogCurl(true),
+//	))
+func LogMiddleware(opts ...LogOption) Middleware {
+	config := &logConfig{body: true}
+	for _, opt := range opts {
+		opt(config)
+	}
+	if config.masker == nil {
+		config.masker = desensitize.NewMasker()
+	}
+	if config.logFunc == nil {
+		config.logFunc = defaultLogFunc
+	}
+	masker := config.masker
+
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			entry := &HTTPLog{
+				Method:        req.Method,
+				URL:           maskURL(req.URL, masker),
+				RequestHeader: maskHeader(req.Header, masker),
+			}
+			if config.body {
+				body, truncated, err := peekRequestBody(req, masker.MaxBodySize())
+				if err != nil {
+					return nil, err
+				}
+				entry.RequestBody, entry.RequestTruncated = maskBody(body, req.Header, masker), truncated
+			}
+			if config.curl {
+				entry.Curl = renderCurl(entry.Method, entry.URL, entry.RequestHeader, entry.RequestBody)
+			}
+
+			start := time.Now()
+			resp, err := next(req)
+			entry.Latency = time.Since(start)
+			if err != nil {
+				entry.Error = err.Error()
+				config.logFunc(entry)
+				return resp, err
+			}
+
+			entry.Status = resp.StatusCode
+			entry.ResponseHeader = maskHeader(resp.Header, masker)
+			if config.body && resp.Body != nil && isLoggableContentType(resp.Header.Get(HeaderContentType)) {
+				var body []byte
+				body, resp.Body = peekPrefix(resp.Body, masker.MaxBodySize()+1)
+				entry.ResponseTruncated = len(body) > masker.MaxBodySize()
+				entry.ResponseBody = masker.Mask(body)
+			}
+			config.logFunc(entry)
+			return resp, nil
+		}
+	}
+}
+
+// CurlCommand 将请求渲染为等价的 curl 命令，会读取并还原请求体，不做脱敏
+func CurlCommand(req *http.Request) (string, error) {
+	body, err := peekBody(req)
+	if err != nil {
+		return "", err
+	}
+	return renderCurl(req.Method, req.URL.String(), req.Header, string(body)), nil
+}
+
+// defaultLogFunc 以 JSON 格式输出到标准库 log
+func defaultLogFunc(entry *HTTPLog) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("httpx: marshal log entry: %v", err)
+		return
+	}
+	log.Printf("httpx: %s", data)
+}
+
+// peekRequestBody 读取请求体的前 limit 字节，请求体可重读时不影响原请求体，否则读取后还原
+func peekRequestBody(req *http.Request, limit int) ([]byte, bool, error) {
+	if req.Body == nil || req.Body == http.NoBody || !isLoggableContentType(req.Header.Get(HeaderContentType)) {
+		return nil, false, nil
+	}
+
+	var body []byte
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, false, err
+		}
+		defer rc.Close()
+		if body, err = io.ReadAll(io.LimitReader(rc, int64(limit)+1)); err != nil {
+			return nil, false, err
+		}
+	} else {
+		body, req.Body = peekPrefix(req.Body, limit+1)
+	}
+	if len(body) > limit {
+		return body[:limit], true, nil
+	}
+	return body, false, nil
+}
+
+// maskBody 脱敏请求体，表单请求体按字段脱敏
+func maskBody(body []byte, header http.Header, masker *desensitize.DataMasker) string {
+	mediaType, _, _ := mime.ParseMediaType(header.Get(HeaderContentType))
+	if mediaType == ContentTypeWWWFormURLEncoded {
+		return maskQuery(string(body), masker)
+	}
+	return masker.Mask(body)
+}
+
+// maskHeader 返回脱敏后的请求头副本，请求头名称中的 - 视为 _ 匹配敏感字段
+func maskHeader(header http.Header, masker *desensitize.DataMasker) http.Header {
+	masked := header.Clone()
+	for key := range masked {
+		if masker.IsSensitiveKey(strings.ReplaceAll(key, "-", "_")) {
+			masked[key] = []string{masker.MaskValue()}
+		}
+	}
+	return masked
+}
+
+// maskURL 返回脱敏查询参数和用户密码后的 URL
+func maskURL(u *url.URL, masker *desensitize.DataMasker) string {
+	masked := *u
+	masked.RawQuery = maskQuery(u.RawQuery, masker)
+	return masked.Redacted()
+}
+
+// maskQuery 脱敏查询字符串中敏感参数的值，保留参数顺序
+func maskQuery(query string, masker *desensitize.DataMasker) string {
+	if query == "" {
+		return ""
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		key, _, ok := strings.Cut(pair, "=")
+		if name, err := url.QueryUnescape(key); ok && err == nil && masker.IsSensitiveKey(name) {
+			pairs[i] = key + "=" + masker.MaskValue()
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// isLoggableContentType 判断是否为可记录的文本内容类型，未设置 Content-Type 时视为可记录
+func isLoggableContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == ContentTypeTextEventStream, mediaType == ContentTypeApplicationXNDJSON:
+		return false
+	case strings.HasPrefix(mediaType, "text/"), mediaType == ContentTypeWWWFormURLEncoded:
+		return true
+	}
+	return strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml")
+}
+
+// renderCurl 渲染 curl 命令，请求头按名称排序
+func renderCurl(method, rawURL string, header http.Header, body string) string {
+	var sb strings.Builder
+	sb.WriteString("curl -X " + method + " " + shellQuote(rawURL))
+	for _, key := range slices.Sorted(maps.Keys(header)) {
+		for _, value := range header[key] {
+			sb.WriteString(" -H " + shellQuote(key+": "+value))
+		}
+	}
+	if body != "" {
+		sb.WriteString(" --data-raw " + shellQuote(body))
+	}
+	return sb.String()
+}
+
+// shellQuote 使用单引号转义 shell 参数
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
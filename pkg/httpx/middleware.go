@@ -0,0 +1,163 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-30 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-30 10:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\middleware.go
+ * @Description: 请求发送链路的中间件(拦截器)
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package httpx
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/kamalyes/go-toolbox/pkg/idgen"
+	"github.com/kamalyes/go-toolbox/pkg/sign"
+)
+
+// Handler 发送 HTTP 请求的处理函数，链路末端为 http.Client.Do
+type Handler func(req *http.Request) (*http.Response, error)
+
+// Middleware 中间件，包装发送链路，可在请求发出前修改请求、在响应返回后处理响应
+//
+// 示例：
+//
+//	func Metrics(next httpx.Handler) httpx.Handler {
+//	    return func(req *http.Request) (*http.Response, error) {
+//	        start := time.Now()
+//	        resp, err := next(req)
+//	        observe(req.Method, req.URL.Host, time.Since(start), err)
+//	        return resp, err
+//	    }
+//	}
+type Middleware func(next Handler) Handler
+
+// Chain 将中间件按顺序组合，第一个中间件位于最外层
+func Chain(handler Handler, middlewares ...Middleware) Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// HeaderMiddleware 为每个请求设置请求头(已存在的请求头不覆盖)
+func HeaderMiddleware(headers map[string]string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			for k, v := range headers {
+				if req.Header.Get(k) == "" {
+					req.Header.Set(k, v)
+				}
+			}
+			return next(req)
+		}
+	}
+}
+
+// TokenSource 提供访问令牌，forceRefresh 为 true 时需要重新获取令牌
+type TokenSource func(req *http.Request, forceRefresh bool) (string, error)
+
+// AuthMiddleware 设置 Authorization 请求头，响应 401 时刷新令牌并重试一次
+// 重试需要重新读取请求体，请求体不可重读(req.GetBody 为 nil)时不重试
+func AuthMiddleware(source TokenSource) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			token, err := source(req, false)
+			if err != nil {
+				return nil, err
+			}
+			req.Header.Set(HeaderAuthorization, token)
+			resp, err := next(req)
+			if err != nil || resp.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+				return resp, err
+			}
+
+			if token, err = source(req, true); err != nil {
+				return resp, nil
+			}
+			retry := req.Clone(req.Context())
+			if req.GetBody != nil {
+				if retry.Body, err = req.GetBody(); err != nil {
+					return resp, nil
+				}
+			}
+			resp.Body.Close()
+			retry.Header.Set(HeaderAuthorization, token)
+			return next(retry)
+		}
+	}
+}
+
+// SignMiddleware 使用 sign.Generator 为请求生成签名请求头
+// 签名包含请求体时会读取并还原请求体
+func SignMiddleware(generator *sign.Generator) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			body, err := peekBody(req)
+			if err != nil {
+				return nil, err
+			}
+			headers := make(map[string]string, len(req.Header))
+			for k := range req.Header {
+				headers[k] = req.Header.Get(k)
+			}
+			for k, v := range generator.GenerateHeaders(req.Method, req.URL.Path, string(body), headers, req.URL.Query()) {
+				req.Header.Set(k, v)
+			}
+			return next(req)
+		}
+	}
+}
+
+// TraceMiddleware 使用 idgen 生成追踪请求头，已存在的追踪 ID 不覆盖，请求 ID 每次请求重新生成
+func TraceMiddleware(generator idgen.IDGenerator) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get(HeaderXTraceID) == "" {
+				req.Header.Set(HeaderXTraceID, generator.GenerateTraceID())
+			}
+			req.Header.Set(HeaderXRequestID, generator.GenerateRequestID())
+			return next(req)
+		}
+	}
+}
+
+// peekBody 读取请求体并还原，使后续处理仍可读取
+func peekBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return body, nil
+}
+
+// peekPrefix 读取 body 的前 limit 字节，返回读取的内容和还原后的 body
+func peekPrefix(body io.ReadCloser, limit int) ([]byte, io.ReadCloser) {
+	prefix, _ := io.ReadAll(io.LimitReader(body, int64(limit)))
+	return prefix, struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), body), body}
+}
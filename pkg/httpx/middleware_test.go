@@ -0,0 +1,158 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-30 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-30 10:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\middleware_test.go
+ * @Description: 中间件测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package httpx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kamalyes/go-toolbox/pkg/idgen"
+	"github.com/kamalyes/go-toolbox/pkg/sign"
+	"github.com/stretchr/testify/assert"
+)
+
+// echoServer 将请求头和请求体原样写回
+func echoServer(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for k, v := range r.Header {
+			w.Header()["Echo-"+k] = v
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// orderMiddleware 记录执行顺序
+func orderMiddleware(name string, order *[]string) Middleware {
+	return func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			*order = append(*order, name+">")
+			resp, err := next(req)
+			*order = append(*order, "<"+name)
+			return resp, err
+		}
+	}
+}
+
+func TestClientUse(t *testing.T) {
+	server := echoServer(t)
+
+	var order []string
+	client := NewClient().Use(orderMiddleware("a", &order), orderMiddleware("b", &order))
+	req := client.Get(server.URL).Use(orderMiddleware("req", &order)).SetHeader("X-Origin", "1")
+
+	resp, err := req.Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, []string{"a>", "b>", "req>", "<req", "<b", "<a"}, order)
+	assert.Len(t, client.Middlewares(), 2)
+
+	// 请求级中间件不影响客户端
+	order = nil
+	resp, err = client.Get(server.URL).Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, []string{"a>", "b>", "<b", "<a"}, order)
+}
+
+func TestHeaderMiddleware(t *testing.T) {
+	server := echoServer(t)
+	client := NewClient().Use(HeaderMiddleware(map[string]string{"X-App": "toolbox", "X-Env": "test"}))
+
+	resp, err := client.Get(server.URL).SetHeader("X-Env", "prod").Send()
+	assert.NoError(t, err)
+	defer resp.Close()
+	assert.Equal(t, "toolbox", resp.Header.Get("Echo-X-App"))
+	assert.Equal(t, "prod", resp.Header.Get("Echo-X-Env"))
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(HeaderAuthorization) != "Bearer fresh" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	var refreshed atomic.Int32
+	client := NewClient().Use(AuthMiddleware(func(req *http.Request, forceRefresh bool) (string, error) {
+		if forceRefresh {
+			refreshed.Add(1)
+			return "Bearer fresh", nil
+		}
+		return "Bearer stale", nil
+	}))
+
+	resp, err := client.Post(server.URL).SetBodyJSON(map[string]string{"k": "v"}).Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.JSONEq(t, `{"k":"v"}`, body)
+	assert.Equal(t, int32(1), refreshed.Load())
+}
+
+func TestSignMiddleware(t *testing.T) {
+	server := echoServer(t)
+	generator := sign.NewGenerator(&sign.GeneratorConfig{Enabled: true, SecretKey: "secret", IncludeBody: true})
+
+	resp, err := NewClient().Use(SignMiddleware(generator)).Post(server.URL + "/orders").SetBodyString(`{"id":1}`).Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Equal(t, `{"id":1}`, body) // 签名后请求体仍完整发送
+	assert.NotEmpty(t, resp.Header.Get("Echo-X-Sign"))
+	assert.NotEmpty(t, resp.Header.Get("Echo-X-Timestamp"))
+	assert.NotEmpty(t, resp.Header.Get("Echo-X-Nonce"))
+}
+
+func TestTraceMiddleware(t *testing.T) {
+	server := echoServer(t)
+	client := NewClient().Use(TraceMiddleware(idgen.NewDefaultIDGenerator()))
+
+	resp, err := client.Get(server.URL).Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.NotEmpty(t, resp.Header.Get("Echo-X-Trace-Id"))
+	assert.NotEmpty(t, resp.Header.Get("Echo-X-Request-Id"))
+
+	// 已有追踪 ID 时沿用
+	req := client.Get(server.URL).SetHeader(HeaderXTraceID, "trace-1")
+	resp, err = req.Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, "trace-1", resp.Header.Get("Echo-X-Trace-Id"))
+	// 中间件修改请求头不影响原请求
+	assert.Empty(t, req.Header().Get(HeaderXRequestID))
+}
+
+func TestPeekBody(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, testURL, io.NopCloser(strings.NewReader("payload")))
+	body, err := peekBody(req)
+	assert.NoError(t, err)
+	assert.Equal(t, "payload", string(body))
+
+	again, _ := io.ReadAll(req.Body)
+	assert.Equal(t, "payload", string(again))
+	assert.NotNil(t, req.GetBody)
+
+	req, _ = http.NewRequest(http.MethodGet, testURL, nil)
+	body, err = peekBody(req)
+	assert.NoError(t, err)
+	assert.Nil(t, body)
+}
@@ -18,6 +18,7 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/kamalyes/go-toolbox/pkg/errorx"
@@ -26,14 +27,20 @@ import (
 // Request 结构体用于封装 HTTP 请求的相关信息
 type Request struct {
 	ctx            context.Context
-	client         *http.Client   // HTTP 客户端
-	endpoint       string         // 请求的 URL
-	method         string         // 请求方法（GET, POST, etc.）
-	headers        http.Header    // 请求头
-	queryValues    url.Values     // 查询参数
-	body           any            // 请求体，可以是任意类型
-	bodyBytes      io.Reader      // 请求体的字节流
-	bodyEncodeFunc BodyEncodeFunc // 自定义的请求体编码函数
+	client         *http.Client                  // HTTP 客户端
+	endpoint       string                        // 请求的 URL
+	method         string                        // 请求方法（GET, POST, etc.）
+	headers        http.Header                   // 请求头
+	queryValues    url.Values                    // 查询参数
+	body           any                           // 请求体，可以是任意类型
+	bodyBytes      io.Reader                     // 请求体的字节流
+	bodyEncodeFunc BodyEncodeFunc                // 自定义的请求体编码函数
+	bodyOpener     func() (io.ReadCloser, error) // 流式请求体，每次发送时打开
+	bodyRewindable bool                          // 流式请求体是否可重新打开
+	uploadProgress ProgressFunc                  // 上传进度回调
+	middlewares    []Middleware                  // 中间件，客户端中间件在前
+	retryPolicy    *RetryPolicy                  // 重试策略
+	circuits       *circuitGroup                 // 按主机划分的熔断器
 	err            error
 }
 
@@ -132,6 +139,18 @@ func (r *Request) GetBodyEncodeFunc() BodyEncodeFunc {
 	return r.bodyEncodeFunc
 }
 
+// Use 为当前请求追加中间件，位于客户端中间件之内
+func (r *Request) Use(middlewares ...Middleware) *Request {
+	r.middlewares = append(r.middlewares, middlewares...)
+	return r
+}
+
+// SetRetryPolicy 设置当前请求的重试策略，覆盖客户端默认策略，nil 表示不重试
+func (r *Request) SetRetryPolicy(policy *RetryPolicy) *Request {
+	r.retryPolicy = policy
+	return r
+}
+
 // GetError 返回错误信息
 func (r *Request) GetError() error {
 	return r.err
@@ -408,6 +427,12 @@ func (r *Request) Clone() *Request {
 		queryValues:    make(url.Values),
 		body:           r.body,
 		bodyEncodeFunc: r.bodyEncodeFunc,
+		bodyOpener:     r.bodyOpener,
+		bodyRewindable: r.bodyRewindable,
+		uploadProgress: r.uploadProgress,
+		middlewares:    slices.Clone(r.middlewares),
+		retryPolicy:    r.retryPolicy,
+		circuits:       r.circuits,
 		err:            r.err,
 	}
 
@@ -450,7 +475,10 @@ func (r *Request) Send() (Response, error) {
 	if err != nil {
 		return Response{}, err // 如果请求创建失败，记录错误并返回
 	}
-	req.Header = r.headers // 设置请求头
+	req.Header = r.headers.Clone() // 设置请求头，中间件修改请求头不影响原请求
+	if err := r.attachBody(req); err != nil {
+		return Response{}, err // 流式请求体打开失败
+	}
 
 	// 合并 URL 参数和 queryValues（queryValues 优先级更高）
 	if req.URL.RawQuery != "" || len(r.queryValues) > 0 {
@@ -470,14 +498,13 @@ func (r *Request) Send() (Response, error) {
 		req.URL.RawQuery = finalParams.Encode()
 	}
 
-	// 执行请求
-
-	resp, err := r.client.Do(req)
+	// 经过中间件链执行请求，每次重试都会重新经过中间件
+	resp, err := r.execute(req, Chain(r.client.Do, r.middlewares...))
 	if err != nil {
 		return Response{}, err // 如果请求执行出错，记录错误并返回
 	} // 将原始 HTTP 响应赋值给 Response 结构体
 
-	return Response{Response: resp}, nil
+	return Response{Response: resp, request: r, getBody: replayBody(req)}, nil
 }
 
 // Do 执行 HTTP 请求并返回响应字节数据（简化版本，用于快速获取响应体）
@@ -23,8 +23,10 @@ import (
 
 // Response 结构体用于封装 HTTP 响应
 type Response struct {
-	*http.Response       // 原始 HTTP 响应
-	err            error // 处理过程中可能出现的错误
+	*http.Response                               // 原始 HTTP 响应
+	err            error                         // 处理过程中可能出现的错误
+	request        *Request                      // 发出该响应的请求，用于 SSE 断线重连
+	getBody        func() (io.ReadCloser, error) // 重新读取请求体，用于 SSE 断线重连，请求体不可重读时为 nil
 }
 
 // IsError 检查响应是否有错误
@@ -0,0 +1,236 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-30 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-30 14:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\retry.go
+ * @Description: 请求重试策略与按主机熔断
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/breaker"
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+	"github.com/kamalyes/go-toolbox/pkg/retry"
+	"github.com/kamalyes/go-toolbox/pkg/syncx"
+)
+
+const (
+	HeaderRetryAfter     = "Retry-After"     // 响应头中的重试等待时间
+	HeaderIdempotencyKey = "Idempotency-Key" // 幂等键，带该请求头的非幂等请求也允许重试
+
+	drainBodyLimit = 4 << 10 // 重试前丢弃响应体的最大字节数，便于连接复用
+)
+
+var (
+	// DefaultRetryStatusCodes 默认重试的状态码
+	DefaultRetryStatusCodes = []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+
+	// defaultRetryBackoff 默认退避：100ms 起指数退避，最大 5s，均等抖动
+	defaultRetryBackoff = syncx.EqualJitterBackoff(syncx.ExponentialBackoff(100*time.Millisecond, 2, 5*time.Second))
+
+	// errServerStatus 5xx 响应，仅用于熔断器计为失败
+	errServerStatus = errors.New("server error status")
+)
+
+// RetryPolicy 请求重试策略，基于 retry.DoValue 实现
+// 默认只重试幂等请求(GET/HEAD/OPTIONS/TRACE/PUT/DELETE)或带 Idempotency-Key 请求头的请求，
+// 重试时会重新读取请求体，不可重读的请求体会先缓存到内存
+type RetryPolicy struct {
+	MaxAttempts        int                                       // 最大尝试次数(含首次)
+	Backoff            syncx.Backoff                             // 退避策略，为 nil 时使用 100ms 起的指数退避(最大 5s)
+	StatusCodes        []int                                     // 需要重试的状态码，为 nil 时使用 DefaultRetryStatusCodes
+	RetryNonIdempotent bool                                      // 是否重试 POST/PATCH 等非幂等请求
+	MaxRetryAfter      time.Duration                             // Retry-After 的最大等待时间，0 表示不限制
+	Budget             *retry.Budget                             // 重试预算，为 nil 时不限制
+	ShouldRetry        func(resp *http.Response, err error) bool // 自定义重试判定，设置后替代状态码和网络错误判定
+}
+
+// DefaultRetryPolicy 返回默认重试策略：最多 3 次尝试，重试网络错误和 429/502/503/504，Retry-After 最多等待 30s
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: 3, MaxRetryAfter: 30 * time.Second}
+}
+
+// retryableStatusError 需要重试的响应，携带 Retry-After 供 retry.DoValue 使用
+type retryableStatusError struct {
+	resp       *http.Response
+	retryAfter time.Duration
+}
+
+// Error 实现 error 接口
+func (e *retryableStatusError) Error() string {
+	return fmt.Sprintf("retryable response status: %s", e.resp.Status)
+}
+
+// GetRetryAfter 返回 Retry-After 指定的等待时间
+func (e *retryableStatusError) GetRetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+// allows 判断请求是否允许重试
+func (p *RetryPolicy) allows(req *http.Request) bool {
+	return p.MaxAttempts > 1 && (p.RetryNonIdempotent || isIdempotent(req))
+}
+
+// retryable 判断响应或错误是否需要重试
+func (p *RetryPolicy) retryable(resp *http.Response, err error) bool {
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(resp, err)
+	}
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) &&
+			!errors.Is(err, breaker.ErrOpen) && !errors.Is(err, breaker.ErrTooManyHalfOpenRequests)
+	}
+	return slices.Contains(mathx.IF(p.StatusCodes == nil, DefaultRetryStatusCodes, p.StatusCodes), resp.StatusCode)
+}
+
+// check 将需要重试的响应转换为错误
+func (p *RetryPolicy) check(resp *http.Response, err error) (*http.Response, error) {
+	if err != nil || !p.retryable(resp, nil) {
+		return resp, err
+	}
+	retryAfter := parseRetryAfter(resp.Header.Get(HeaderRetryAfter), time.Now())
+	if p.MaxRetryAfter > 0 && retryAfter > p.MaxRetryAfter {
+		retryAfter = p.MaxRetryAfter
+	}
+	return nil, &retryableStatusError{resp: resp, retryAfter: retryAfter}
+}
+
+// policy 转换为 retry.Policy
+func (p *RetryPolicy) policy() *retry.Policy {
+	return &retry.Policy{
+		MaxAttempts: p.MaxAttempts,
+		Backoff:     mathx.IF(p.Backoff == nil, defaultRetryBackoff, p.Backoff),
+		Budget:      p.Budget,
+		Condition: func(err error) bool {
+			var statusErr *retryableStatusError
+			return errors.As(err, &statusErr) || p.retryable(nil, err)
+		},
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			// 丢弃即将重试的响应
+			var statusErr *retryableStatusError
+			if errors.As(err, &statusErr) {
+				io.CopyN(io.Discard, statusErr.resp.Body, drainBodyLimit)
+				statusErr.resp.Body.Close()
+			}
+		},
+	}
+}
+
+// isIdempotent 判断请求是否幂等
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(HeaderIdempotencyKey) != ""
+}
+
+// parseRetryAfter 解析 Retry-After(秒数或 HTTP 日期)，无效时返回 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// circuitGroup 按主机划分的熔断器
+type circuitGroup struct {
+	config   breaker.Config
+	mu       sync.Mutex
+	circuits map[string]*breaker.Circuit
+}
+
+// newCircuitGroup 创建按主机划分的熔断器组
+func newCircuitGroup(config breaker.Config) *circuitGroup {
+	return &circuitGroup{config: config, circuits: make(map[string]*breaker.Circuit)}
+}
+
+// get 获取主机对应的熔断器，不存在时创建
+func (g *circuitGroup) get(host string) *breaker.Circuit {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c, ok := g.circuits[host]
+	if !ok {
+		c = breaker.New(host, g.config)
+		g.circuits[host] = c
+	}
+	return c
+}
+
+// sendWithCircuit 经熔断器发送请求，网络错误和 5xx 响应计为失败
+func sendWithCircuit(circuit *breaker.Circuit, handler Handler, req *http.Request) (*http.Response, error) {
+	if circuit == nil {
+		return handler(req)
+	}
+	var resp *http.Response
+	err := circuit.Execute(func() error {
+		var err error
+		if resp, err = handler(req); err != nil {
+			return err
+		}
+		return mathx.IF(resp.StatusCode >= http.StatusInternalServerError, errServerStatus, nil)
+	})
+	if errors.Is(err, errServerStatus) {
+		return resp, nil
+	}
+	return resp, err
+}
+
+// execute 按重试策略和熔断器执行请求
+func (r *Request) execute(req *http.Request, handler Handler) (*http.Response, error) {
+	circuit := r.circuits.get(req.URL.Host)
+	policy := r.retryPolicy
+	if policy == nil || !policy.allows(req) {
+		return sendWithCircuit(circuit, handler, r.trackUpload(req))
+	}
+
+	// 确保请求体可重读：可重读时每次尝试通过 GetBody 重新打开，关闭原请求体；否则缓存到内存
+	if req.GetBody != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+	} else if _, err := peekBody(req); err != nil {
+		return nil, err
+	}
+	resp, err := retry.DoValue(req.Context(), policy.policy(), func(ctx context.Context) (*http.Response, error) {
+		attempt := req.Clone(ctx)
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attempt.Body = body
+		}
+		return policy.check(sendWithCircuit(circuit, handler, r.trackUpload(attempt)))
+	})
+
+	// 重试耗尽时返回最后一次响应
+	var statusErr *retryableStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.resp, nil
+	}
+	return resp, err
+}
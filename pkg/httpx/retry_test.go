@@ -0,0 +1,167 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-30 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-30 14:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\retry_test.go
+ * @Description: 请求重试与熔断测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package httpx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/breaker"
+	"github.com/kamalyes/go-toolbox/pkg/syncx"
+	"github.com/stretchr/testify/assert"
+)
+
+// fastRetryPolicy 测试用的无等待重试策略
+func fastRetryPolicy(attempts int) *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: attempts, Backoff: syncx.ConstantBackoff(0)}
+}
+
+// flakyServer 前 failures 次返回 status，之后回显请求体
+func flakyServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if calls.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRequestRetry_StatusCodes(t *testing.T) {
+	server, calls := flakyServer(t, 2, http.StatusServiceUnavailable, nil)
+
+	resp, err := NewClient(WithRetryPolicy(fastRetryPolicy(3))).Get(server.URL).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Close()
+	assert.Equal(t, int32(3), calls.Load())
+
+	// 重试耗尽时返回最后一次响应
+	server, calls = flakyServer(t, 5, http.StatusBadGateway, nil)
+	resp, err = NewClient(WithRetryPolicy(fastRetryPolicy(2))).Get(server.URL).Send()
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	resp.Close()
+	assert.Equal(t, int32(2), calls.Load())
+
+	// 不在重试列表中的状态码不重试
+	server, calls = flakyServer(t, 5, http.StatusInternalServerError, nil)
+	resp, err = NewClient(WithRetryPolicy(fastRetryPolicy(3))).Get(server.URL).Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRequestRetry_Idempotency(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+	client := NewClient().SetRetryPolicy(fastRetryPolicy(3))
+
+	// POST 默认不重试
+	resp, err := client.Post(server.URL).SetBodyString("payload").Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 带幂等键的 POST 重试，且每次都发送完整请求体
+	server, calls = flakyServer(t, 2, http.StatusServiceUnavailable, nil)
+	resp, err = client.Post(server.URL).
+		SetHeader(HeaderIdempotencyKey, "order-1").
+		SetBody(io.NopCloser(strings.NewReader("payload"))). // 不可重读的请求体
+		Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Equal(t, "payload", body)
+	assert.Equal(t, int32(3), calls.Load())
+
+	// 允许重试非幂等请求
+	server, calls = flakyServer(t, 1, http.StatusTooManyRequests, nil)
+	policy := fastRetryPolicy(2)
+	policy.RetryNonIdempotent = true
+	resp, err = client.Post(server.URL).SetRetryPolicy(policy).SetBodyJSON(map[string]int{"id": 1}).Send()
+	assert.NoError(t, err)
+	body, _ = resp.String()
+	assert.JSONEq(t, `{"id":1}`, body)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestRequestRetry_RetryAfter(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusTooManyRequests, http.Header{HeaderRetryAfter: {"1"}})
+	policy := fastRetryPolicy(2)
+	policy.MaxRetryAfter = 20 * time.Millisecond
+
+	start := time.Now()
+	resp, err := NewClient().Get(server.URL).SetRetryPolicy(policy).Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, int32(2), calls.Load())
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 20*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 3*time.Second, parseRetryAfter("3", now))
+	assert.Equal(t, 10*time.Second, parseRetryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("soon", now))
+	assert.Equal(t, time.Duration(0), parseRetryAfter("-1", now))
+}
+
+func TestRequestRetry_NetworkError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	var attempts atomic.Int32
+	counter := func(next Handler) Handler {
+		return func(req *http.Request) (*http.Response, error) {
+			attempts.Add(1)
+			return next(req)
+		}
+	}
+	_, err := NewClient(WithRetryPolicy(fastRetryPolicy(3))).Use(counter).Get(url).Send()
+	assert.Error(t, err)
+	// 每次重试都经过中间件
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestClientCircuitBreaker(t *testing.T) {
+	server, calls := flakyServer(t, 100, http.StatusInternalServerError, nil)
+	client := NewClient(WithCircuitBreaker(breaker.Config{MaxFailures: 2, ResetTimeout: time.Hour}))
+
+	for range 2 {
+		resp, err := client.Get(server.URL).Send()
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		resp.Close()
+	}
+
+	// 熔断后不再发出请求，且不重试
+	_, err := client.Get(server.URL).SetRetryPolicy(fastRetryPolicy(3)).Send()
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.Equal(t, int32(2), calls.Load())
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	assert.Equal(t, breaker.StateOpen, client.Circuit(host).GetState())
+	assert.Equal(t, breaker.StateClosed, client.Circuit("other:80").GetState())
+	assert.Nil(t, NewClient().Circuit(host))
+}
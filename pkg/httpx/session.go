@@ -0,0 +1,332 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-01-02 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-01-02 10:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\session.go
+ * @Description: 会话客户端，持久化 Cookie、默认请求头、基础 URL、CSRF 令牌与登录状态
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+
+package httpx
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+)
+
+const (
+	DefaultCSRFHeader = "X-CSRF-Token" // 默认 CSRF 请求头
+
+	csrfScanLimit = 1 << 20 // 从 HTML 响应中提取 CSRF 令牌时读取的最大字节数
+)
+
+var (
+	// CSRFFieldNames 从 HTML 的 <meta name> 或 <input name> 中提取 CSRF 令牌时识别的名称
+	CSRFFieldNames = []string{"csrf-token", "csrf_token", "_csrf", "_token", "csrfmiddlewaretoken", "authenticity_token"}
+
+	htmlTagRegex  = regexp.MustCompile(`(?is)<(?:meta|input)\b[^>]*>`)
+	htmlAttrRegex = regexp.MustCompile(`(?is)([\w-]+)\s*=\s*["']([^"']*)["']`)
+)
+
+// Session 会话客户端，基于 Client 维护跨请求状态：
+// 持久化 Cookie(CookieJar)、默认请求头、基础 URL、CSRF 令牌和登录状态
+// 设置基础 URL 后，默认请求头和 CSRF 令牌只发送到基础 URL 所在的主机，其他主机的请求不做处理
+//
+// 示例：
+//
+//	session := httpx.NewSession(nil).SetBaseURL("https://example.com").EnableCSRF("", "csrftoken")
+//	session.LoadCookies("cookies.json")
+//	err := session.SetLoginCookie("sessionid").EnsureLogin(func(s *httpx.Session) error {
+//	    _, err := s.Post("/login").SetBodyForm(form).Send()
+//	    return err
+//	})
+//	defer session.SaveCookies("cookies.json")
+type Session struct {
+	client *Client
+	jar    *CookieJar
+
+	mu          sync.RWMutex
+	baseURL     string
+	baseHost    string // 基础 URL 的主机(含端口)，为空时不限制主机
+	headers     http.Header
+	csrfHeader  string // CSRF 请求头，为空时不处理 CSRF
+	csrfCookie  string // 保存 CSRF 令牌的 Cookie 名称
+	csrfToken   string // 最近一次从响应中提取的 CSRF 令牌
+	loginCookie string // 表示已登录的 Cookie 名称
+}
+
+// NewSession 基于 client 创建会话，client 为 nil 时使用 NewClient()
+// 会话使用 client 的副本，不影响原客户端
+func NewSession(client *Client) *Session {
+	if client == nil {
+		client = NewClient()
+	}
+	s := &Session{jar: NewCookieJar(), headers: make(http.Header)}
+
+	httpClient := *client.client
+	httpClient.Jar = s.jar
+	sessionClient := *client
+	sessionClient.client = &httpClient
+	sessionClient.middlewares = append([]Middleware{s.middleware}, slices.Clone(client.middlewares)...)
+	s.client = &sessionClient
+	return s
+}
+
+// Client 返回会话使用的客户端
+func (s *Session) Client() *Client {
+	return s.client
+}
+
+// Jar 返回会话的 Cookie 容器
+func (s *Session) Jar() *CookieJar {
+	return s.jar
+}
+
+// SetBaseURL 设置基础 URL，相对路径的请求基于该 URL 拼接，默认请求头和 CSRF 令牌只发送到该 URL 的主机
+func (s *Session) SetBaseURL(baseURL string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.baseURL = strings.TrimSuffix(NormalizeBaseURL(baseURL), "/")
+	s.baseHost = ""
+	if u, err := url.Parse(s.baseURL); err == nil {
+		s.baseHost = u.Host
+	}
+	return s
+}
+
+// BaseURL 返回基础 URL
+func (s *Session) BaseURL() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.baseURL
+}
+
+// SetHeader 设置默认请求头，请求中已设置的同名请求头优先
+func (s *Session) SetHeader(key, value string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.headers.Set(key, value)
+	return s
+}
+
+// Header 返回默认请求头的副本
+func (s *Session) Header() http.Header {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.headers.Clone()
+}
+
+// EnableCSRF 启用 CSRF 令牌处理，header 为空时使用 DefaultCSRFHeader
+// 令牌来源依次为：响应头 header、HTML 响应中的 <meta>/<input>(见 CSRFFieldNames)、名为 cookie 的 Cookie
+// 非安全方法(POST/PUT/PATCH/DELETE 等)的请求自动携带令牌
+func (s *Session) EnableCSRF(header, cookie string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.csrfHeader = mathx.IfEmpty(header, DefaultCSRFHeader)
+	s.csrfCookie = cookie
+	return s
+}
+
+// SetCSRFToken 手动设置 CSRF 令牌
+func (s *Session) SetCSRFToken(token string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.csrfToken = token
+	return s
+}
+
+// CSRFToken 返回最近一次提取的 CSRF 令牌
+func (s *Session) CSRFToken() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.csrfToken
+}
+
+// SetLoginCookie 设置表示已登录的 Cookie 名称
+func (s *Session) SetLoginCookie(name string) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loginCookie = name
+	return s
+}
+
+// LoggedIn 判断登录 Cookie 是否存在且未过期
+// 设置基础 URL 时以 Cookie 容器实际发送到基础 URL 的 Cookie 为准，否则检查容器中的全部 Cookie
+func (s *Session) LoggedIn() bool {
+	s.mu.RLock()
+	name, baseURL := s.loginCookie, s.baseURL
+	s.mu.RUnlock()
+	if name == "" {
+		return false
+	}
+	cookies := s.jar.AllCookies()
+	if u, err := url.Parse(baseURL); err == nil && baseURL != "" {
+		cookies = s.jar.Cookies(u)
+	}
+	for _, c := range cookies {
+		if c.Name == name && c.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// EnsureLogin 未登录时执行 login，执行后仍未登录时返回 ErrNotLoggedIn
+func (s *Session) EnsureLogin(login func(s *Session) error) error {
+	if s.LoggedIn() {
+		return nil
+	}
+	if err := login(s); err != nil {
+		return err
+	}
+	if !s.LoggedIn() {
+		return errorx.NewError(ErrNotLoggedIn)
+	}
+	return nil
+}
+
+// Logout 清空 Cookie 和 CSRF 令牌
+func (s *Session) Logout() *Session {
+	s.jar.Clear()
+	return s.SetCSRFToken("")
+}
+
+// SaveCookies 将 Cookie 保存为 JSON 文件
+func (s *Session) SaveCookies(path string) error {
+	return s.jar.Save(path)
+}
+
+// LoadCookies 从 JSON 文件加载 Cookie
+func (s *Session) LoadCookies(path string) error {
+	return s.jar.Load(path)
+}
+
+// NewRequest 创建请求，相对路径基于基础 URL 拼接
+func (s *Session) NewRequest(method, path string) *Request {
+	return s.client.NewRequest(method, s.resolve(path))
+}
+
+// Get 创建 GET 请求
+func (s *Session) Get(path string) *Request {
+	return s.NewRequest(http.MethodGet, path)
+}
+
+// Post 创建 POST 请求
+func (s *Session) Post(path string) *Request {
+	return s.NewRequest(http.MethodPost, path)
+}
+
+// Put 创建 PUT 请求
+func (s *Session) Put(path string) *Request {
+	return s.NewRequest(http.MethodPut, path)
+}
+
+// Patch 创建 PATCH 请求
+func (s *Session) Patch(path string) *Request {
+	return s.NewRequest(http.MethodPatch, path)
+}
+
+// Delete 创建 DELETE 请求
+func (s *Session) Delete(path string) *Request {
+	return s.NewRequest(http.MethodDelete, path)
+}
+
+// resolve 拼接基础 URL
+func (s *Session) resolve(path string) string {
+	base := s.BaseURL()
+	if base == "" || strings.Contains(path, "://") {
+		return path
+	}
+	if path == "" {
+		return base
+	}
+	return base + "/" + strings.TrimPrefix(path, "/")
+}
+
+// middleware 设置默认请求头和 CSRF 令牌，并从响应中提取 CSRF 令牌，不处理基础 URL 之外主机的请求
+func (s *Session) middleware(next Handler) Handler {
+	return func(req *http.Request) (*http.Response, error) {
+		s.mu.RLock()
+		if s.baseHost != "" && !strings.EqualFold(req.URL.Host, s.baseHost) {
+			s.mu.RUnlock()
+			return next(req)
+		}
+		for k, v := range s.headers {
+			if req.Header.Get(k) == "" {
+				req.Header[k] = slices.Clone(v)
+			}
+		}
+		header, cookie, token := s.csrfHeader, s.csrfCookie, s.csrfToken
+		s.mu.RUnlock()
+
+		if header != "" && !isSafeMethod(req.Method) && req.Header.Get(header) == "" {
+			if token == "" && cookie != "" {
+				for _, c := range s.jar.Cookies(req.URL) {
+					if c.Name == cookie {
+						token = c.Value
+					}
+				}
+			}
+			if token != "" {
+				req.Header.Set(header, token)
+			}
+		}
+
+		resp, err := next(req)
+		if err == nil && header != "" {
+			if token := extractCSRFToken(resp, header); token != "" {
+				s.SetCSRFToken(token)
+			}
+		}
+		return resp, err
+	}
+}
+
+// isSafeMethod 判断是否为安全方法(不需要 CSRF 令牌)
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// extractCSRFToken 从响应头或 HTML 响应体中提取 CSRF 令牌，读取的响应体会还原
+func extractCSRFToken(resp *http.Response, header string) string {
+	if token := resp.Header.Get(header); token != "" {
+		return token
+	}
+	if !strings.Contains(resp.Header.Get(HeaderContentType), ContentTypeTextHTML) {
+		return ""
+	}
+
+	var prefix []byte
+	prefix, resp.Body = peekPrefix(resp.Body, csrfScanLimit)
+	return findCSRFToken(prefix)
+}
+
+// findCSRFToken 从 HTML 的 <meta name content> 或 <input name value> 中查找 CSRF 令牌
+func findCSRFToken(html []byte) string {
+	for _, tag := range htmlTagRegex.FindAll(html, -1) {
+		attrs := make(map[string]string)
+		for _, match := range htmlAttrRegex.FindAllSubmatch(tag, -1) {
+			attrs[strings.ToLower(string(match[1]))] = string(match[2])
+		}
+		if !slices.Contains(CSRFFieldNames, strings.ToLower(attrs["name"])) {
+			continue
+		}
+		if token := mathx.IfEmpty(attrs["content"], attrs["value"]); token != "" {
+			return token
+		}
+	}
+	return ""
+}
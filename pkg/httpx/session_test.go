@@ -0,0 +1,200 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2026-01-02 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2026-01-02 10:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\session_test.go
+ * @Description: 会话客户端测试
+ *
+ * Copyright (c) 2026 by kamalyes, All Rights Reserved.
+ */
+package httpx
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+	"github.com/stretchr/testify/assert"
+)
+
+// loginServer 模拟需要登录和 CSRF 校验的站点
+func loginServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/form", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderContentType, ContentTypeTextHTMLCharacterUTF8)
+		io.WriteString(w, `<html><head><meta name="csrf-token" content="meta-token"></head><body>form</body></html>`)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(DefaultCSRFHeader) != "meta-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "sid", Value: "user-1", Path: "/"})
+		w.Header().Set(DefaultCSRFHeader, "rotated-token")
+	})
+	mux.HandleFunc("/api/profile", func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("sid")
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, c.Value+"|"+r.Header.Get("X-App")+"|"+r.Header.Get(DefaultCSRFHeader))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSession(t *testing.T) {
+	server := loginServer(t)
+	session := NewSession(nil).SetBaseURL(server.URL+"/").SetHeader("X-App", "toolbox").EnableCSRF("", "").SetLoginCookie("sid")
+	assert.False(t, session.LoggedIn())
+
+	login := func(s *Session) error {
+		resp, err := s.Get("/form").Send()
+		if err != nil {
+			return err
+		}
+		body, _ := resp.String()
+		assert.Contains(t, body, "form") // 提取令牌后响应体仍完整
+		assert.Equal(t, "meta-token", s.CSRFToken())
+
+		resp, err = s.Post("login").Send()
+		if err != nil {
+			return err
+		}
+		resp.Close()
+		return nil
+	}
+	assert.NoError(t, session.EnsureLogin(login))
+	assert.True(t, session.LoggedIn())
+	assert.Equal(t, "rotated-token", session.CSRFToken())
+
+	// 已登录时不再执行登录
+	assert.NoError(t, session.EnsureLogin(func(*Session) error {
+		t.Fatal("login should not be called")
+		return nil
+	}))
+
+	resp, err := session.Put("/api/profile").SetHeader("X-App", "custom").Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Equal(t, "user-1|custom|rotated-token", body)
+
+	// GET 请求不携带 CSRF 令牌
+	resp, err = session.Get("/api/profile").Send()
+	assert.NoError(t, err)
+	body, _ = resp.String()
+	assert.Equal(t, "user-1|toolbox|", body)
+
+	// Cookie 持久化后在新会话中恢复登录状态
+	path := filepath.Join(t.TempDir(), "cookies.json")
+	assert.NoError(t, session.SaveCookies(path))
+	restored := NewSession(nil).SetBaseURL(server.URL).SetLoginCookie("sid")
+	assert.NoError(t, restored.LoadCookies(path))
+	assert.True(t, restored.LoggedIn())
+	resp, err = restored.Get(server.URL + "/api/profile").Send()
+	assert.NoError(t, err)
+	body, _ = resp.String()
+	assert.Equal(t, "user-1||", body)
+
+	// 其他主机的请求不携带默认请求头和 CSRF 令牌，也不从其响应中提取令牌
+	var echoed http.Header
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		echoed = r.Header.Clone()
+		w.Header().Set(DefaultCSRFHeader, "other-token")
+	}))
+	defer other.Close()
+	resp, err = session.Post(other.URL + "/collect").Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Empty(t, echoed.Get("X-App"))
+	assert.Empty(t, echoed.Get(DefaultCSRFHeader))
+	assert.Equal(t, "rotated-token", session.CSRFToken())
+
+	session.Logout()
+	assert.False(t, session.LoggedIn())
+	assert.Empty(t, session.CSRFToken())
+}
+
+func TestSession_LoginFailed(t *testing.T) {
+	server := loginServer(t)
+	session := NewSession(nil).SetBaseURL(server.URL).SetLoginCookie("sid")
+
+	// 未携带 CSRF 令牌，登录失败
+	err := session.EnsureLogin(func(s *Session) error {
+		resp, err := s.Post("/login").Send()
+		if err == nil {
+			resp.Close()
+		}
+		return err
+	})
+	assert.Equal(t, ErrNotLoggedIn, errorx.ClassifyError(err))
+}
+
+func TestSession_LoggedIn(t *testing.T) {
+	session := NewSession(nil).SetBaseURL("https://bank.com").SetLoginCookie("sid")
+	bank, _ := url.Parse("https://bank.com/")
+	evil, _ := url.Parse("https://evil.com/")
+
+	// 其他站点的登录 Cookie 不算登录
+	session.Jar().SetCookies(evil, []*http.Cookie{{Name: "sid", Value: "e1"}})
+	assert.False(t, session.LoggedIn())
+
+	// 其他站点无法删除基础 URL 的登录 Cookie
+	session.Jar().SetCookies(bank, []*http.Cookie{{Name: "sid", Value: "b1"}})
+	session.Jar().SetCookies(evil, []*http.Cookie{{Name: "sid", Domain: "bank.com", MaxAge: -1}})
+	assert.True(t, session.LoggedIn())
+
+	session.Jar().SetCookies(bank, []*http.Cookie{{Name: "sid", MaxAge: -1}})
+	assert.False(t, session.LoggedIn())
+}
+
+func TestSession_CSRFCookie(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			http.SetCookie(w, &http.Cookie{Name: "csrftoken", Value: "cookie-token"})
+			return
+		}
+		io.WriteString(w, r.Header.Get("X-CSRFToken"))
+	}))
+	defer server.Close()
+
+	client := NewClient()
+	session := NewSession(client).SetBaseURL(server.URL).EnableCSRF("X-CSRFToken", "csrftoken")
+	resp, err := session.Get("/").Send()
+	assert.NoError(t, err)
+	resp.Close()
+
+	resp, err = session.Delete("/").Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Equal(t, "cookie-token", body)
+
+	// 会话不影响原客户端
+	assert.Nil(t, client.client.Jar)
+	assert.Empty(t, client.Middlewares())
+}
+
+func TestFindCSRFToken(t *testing.T) {
+	assert.Equal(t, "a", findCSRFToken([]byte(`<meta content="a" name="csrf-token">`)))
+	assert.Equal(t, "b", findCSRFToken([]byte(`<form><INPUT type='hidden' name='csrfmiddlewaretoken' value='b'></form>`)))
+	assert.Equal(t, "", findCSRFToken([]byte(`<meta name="description" content="x">`)))
+}
+
+func TestSessionResolve(t *testing.T) {
+	session := NewSession(nil)
+	assert.Equal(t, "/users", session.resolve("/users"))
+
+	session.SetBaseURL("api.example.com/v1/")
+	assert.Equal(t, "https://api.example.com/v1", session.BaseURL())
+	assert.Equal(t, "https://api.example.com/v1/users", session.resolve("/users"))
+	assert.Equal(t, "https://api.example.com/v1/users", session.resolve("users"))
+	assert.Equal(t, "http://other.com/x", session.resolve("http://other.com/x"))
+	assert.Equal(t, "https://api.example.com/v1", session.resolve(""))
+}
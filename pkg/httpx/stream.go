@@ -0,0 +1,287 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-31 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-31 10:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\stream.go
+ * @Description: SSE(text/event-stream) 与 NDJSON 流式响应读取
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package httpx
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+	"github.com/kamalyes/go-toolbox/pkg/json"
+)
+
+const (
+	HeaderLastEventID = "Last-Event-ID" // SSE 重连时携带的最后事件 ID
+
+	defaultSSERetry         = 3 * time.Second // SSE 默认重连间隔
+	defaultSSEMaxReconnects = 3               // SSE 默认连续重连次数
+)
+
+// SSEEvent SSE 事件
+type SSEEvent struct {
+	ID    string        // 最后一次收到的事件 ID，未设置的事件沿用之前的 ID
+	Event string        // 事件类型，为空表示 message
+	Data  string        // 事件数据，多行 data 以换行连接
+	Retry time.Duration // 服务端通过 retry 字段指定的重连间隔，未指定时为 0
+}
+
+// SSEOption SSE 读取选项
+type SSEOption func(*sseConfig)
+
+// sseConfig SSE 读取配置
+type sseConfig struct {
+	maxReconnects int
+	retry         time.Duration
+}
+
+// WithSSEMaxReconnects 设置连接异常断开时的最大连续重连次数，0 表示不重连，收到事件后重新计数
+func WithSSEMaxReconnects(n int) SSEOption {
+	return func(c *sseConfig) {
+		c.maxReconnects = n
+	}
+}
+
+// WithSSERetryDelay 设置默认重连间隔，服务端通过 retry 字段指定时以服务端为准
+func WithSSERetryDelay(delay time.Duration) SSEOption {
+	return func(c *sseConfig) {
+		c.retry = delay
+	}
+}
+
+// sseState 跨连接保留的解析状态
+type sseState struct {
+	lastID string
+	retry  time.Duration
+}
+
+// SSE 以迭代器形式读取 text/event-stream 响应，迭代结束后关闭响应体
+// 连接异常断开(非正常结束)时按重连间隔重新发送请求并携带 Last-Event-ID 请求头，
+// 重连响应为 204 时结束，其他非 2xx 状态返回 ErrRequestStatusCode；
+// 重连时重新发送原请求体，请求体不可重读(如 SetBody 传入自定义 io.Reader 且未启用重试)时不重连，直接返回读取错误
+//
+// 示例：
+//
+//	resp, err := client.Post(url).SetHeader(httpx.HeaderAccept, httpx.ContentTypeTextEventStream).Send()
+//	for event, err := range resp.SSE() {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(event.Event, event.Data)
+//	}
+func (r *Response) SSE(opts ...SSEOption) iter.Seq2[SSEEvent, error] {
+	cfg := &sseConfig{maxReconnects: defaultSSEMaxReconnects, retry: defaultSSERetry}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(yield func(SSEEvent, error) bool) {
+		if r.IsError() {
+			yield(SSEEvent{}, r.Error())
+			return
+		}
+
+		state := &sseState{retry: cfg.retry}
+		reconnects := 0
+		resp := r.Response
+		for resp != nil {
+			stopped, err := readSSE(resp.Body, state, func(event SSEEvent) bool {
+				reconnects = 0
+				return yield(event, nil)
+			})
+			resp.Body.Close()
+			if stopped || err == nil {
+				return
+			}
+
+			// 连接异常断开，尝试重连
+			for {
+				if r.request == nil || r.getBody == nil || reconnects >= cfg.maxReconnects || !sleepRetry(r.request, state.retry) {
+					yield(SSEEvent{}, err)
+					return
+				}
+				reconnects++
+				var done bool
+				if resp, done, err = r.reconnectSSE(state.lastID); done || err == nil {
+					break
+				}
+			}
+		}
+	}
+}
+
+// sleepRetry 等待重连间隔，请求上下文结束时返回 false
+func sleepRetry(req *Request, delay time.Duration) bool {
+	ctx := req.Context()
+	if ctx.Err() != nil {
+		return false
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// reconnectSSE 携带 Last-Event-ID 重新发送请求，done 表示服务端要求停止重连
+func (r *Response) reconnectSSE(lastID string) (resp *http.Response, done bool, err error) {
+	body, err := r.getBody()
+	if err != nil {
+		return nil, false, err
+	}
+	req := r.request.Clone()
+	req.body, req.bodyBytes, req.bodyEncodeFunc, req.bodyOpener = nil, nil, nil, nil
+	if body != http.NoBody {
+		req.bodyBytes = body
+	}
+	if lastID != "" {
+		req.SetHeader(HeaderLastEventID, lastID)
+	}
+	next, err := req.Send()
+	if err != nil {
+		return nil, false, err
+	}
+	switch {
+	case next.StatusCode == http.StatusNoContent:
+		next.Close()
+		return nil, true, nil
+	case next.StatusCode >= http.StatusMultipleChoices:
+		next.Close()
+		return nil, false, errorx.NewError(ErrRequestStatusCode, next.Status)
+	}
+	return next.Response, false, nil
+}
+
+// replayBody 返回重新读取请求体的函数，无请求体时返回空请求体，请求体不可重读时返回 nil
+func replayBody(req *http.Request) func() (io.ReadCloser, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return func() (io.ReadCloser, error) { return http.NoBody, nil }
+	}
+	return req.GetBody
+}
+
+// readSSE 解析事件流直到结束，正常结束时返回 nil，stopped 表示调用方停止迭代
+func readSSE(body io.Reader, state *sseState, emit func(SSEEvent) bool) (stopped bool, err error) {
+	reader := bufio.NewReader(body)
+	var event SSEEvent
+	var data strings.Builder
+	first := true
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// 未以空行结束的事件丢弃
+			return false, ignoreEOF(err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if first {
+			line = strings.TrimPrefix(line, "\uFEFF") // 去除 UTF-8 BOM
+			first = false
+		}
+
+		if line == "" {
+			if data.Len() > 0 {
+				event.ID = state.lastID
+				event.Data = strings.TrimSuffix(data.String(), "\n")
+				if !emit(event) {
+					return true, nil
+				}
+			}
+			event, data = SSEEvent{}, strings.Builder{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // 注释行
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				state.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				state.retry = time.Duration(ms) * time.Millisecond
+				event.Retry = state.retry
+			}
+		}
+	}
+}
+
+// ignoreEOF 将 io.EOF 视为正常结束
+func ignoreEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+// NDJSON 以迭代器形式逐行解码 NDJSON(application/x-ndjson) 响应，迭代结束后关闭响应体
+// 单行解码失败时返回带行号的错误，调用方可选择跳过继续读取；读取失败时结束迭代
+// Go 不支持泛型方法，因此以函数形式提供
+//
+// 示例：
+//
+//	for chunk, err := range httpx.NDJSON[Chunk](&resp) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Print(chunk.Text)
+//	}
+func NDJSON[T any](r *Response) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		if r.IsError() {
+			yield(zero, r.Error())
+			return
+		}
+		defer r.Close()
+
+		reader := bufio.NewReader(r.Response.Body)
+		for lineNo := 1; ; lineNo++ {
+			line, err := reader.ReadBytes('\n')
+			if err = ignoreEOF(err); err != nil {
+				yield(zero, err) // 读取失败时丢弃不完整的行
+				return
+			}
+			if text := strings.TrimSpace(string(line)); text != "" {
+				var value T
+				if decodeErr := json.Unmarshal([]byte(text), &value); decodeErr != nil {
+					if !yield(zero, fmt.Errorf("ndjson line %d: %w", lineNo, decodeErr)) {
+						return
+					}
+				} else if !yield(value, nil) {
+					return
+				}
+			}
+			if len(line) == 0 || line[len(line)-1] != '\n' {
+				return // 已读到末尾
+			}
+		}
+	}
+}
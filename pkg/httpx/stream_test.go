@@ -0,0 +1,268 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-31 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-31 10:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\stream_test.go
+ * @Description: SSE 与 NDJSON 流式响应测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package httpx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+	"github.com/stretchr/testify/assert"
+)
+
+// collectSSE 收集全部事件和最后一个错误
+func collectSSE(resp Response, opts ...SSEOption) ([]SSEEvent, error) {
+	var events []SSEEvent
+	var lastErr error
+	for event, err := range resp.SSE(opts...) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, lastErr
+}
+
+func TestReadSSE(t *testing.T) {
+	stream := "\uFEFF: comment\r\n" +
+		"event: greeting\r\n" +
+		"data: hello\r\n" +
+		"data:  world\r\n" +
+		"id: 1\r\n" +
+		"\r\n" +
+		"data: second\n" +
+		"retry: 1500\n" +
+		"\n" +
+		"id\n" +
+		"\n" + // 没有 data 的事件不分发
+		"data: reset id\n" +
+		"\n" +
+		"data: incomplete"
+
+	state := &sseState{}
+	var events []SSEEvent
+	stopped, err := readSSE(strings.NewReader(stream), state, func(event SSEEvent) bool {
+		events = append(events, event)
+		return true
+	})
+	assert.False(t, stopped)
+	assert.NoError(t, err)
+	assert.Equal(t, []SSEEvent{
+		{ID: "1", Event: "greeting", Data: "hello\n world"},
+		{ID: "1", Data: "second", Retry: 1500 * time.Millisecond},
+		{ID: "", Data: "reset id"},
+	}, events)
+	assert.Equal(t, 1500*time.Millisecond, state.retry)
+
+	// 调用方停止迭代
+	stopped, err = readSSE(strings.NewReader(stream), &sseState{}, func(SSEEvent) bool { return false })
+	assert.True(t, stopped)
+	assert.NoError(t, err)
+}
+
+func TestResponseSSE(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderContentType, ContentTypeTextEventStream)
+		for i := range 3 {
+			fmt.Fprintf(w, "id: %d\nevent: tick\ndata: %d\n\n", i, i*10)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL).SetHeader(HeaderAccept, ContentTypeTextEventStream).Send()
+	assert.NoError(t, err)
+	events, err := collectSSE(resp)
+	assert.NoError(t, err)
+	assert.Len(t, events, 3)
+	assert.Equal(t, SSEEvent{ID: "2", Event: "tick", Data: "20"}, events[2])
+
+	// 提前结束迭代
+	resp, err = NewClient().Get(server.URL).Send()
+	assert.NoError(t, err)
+	for event := range resp.SSE() {
+		assert.Equal(t, "0", event.Data)
+		break
+	}
+}
+
+func TestResponseSSE_Reconnect(t *testing.T) {
+	var calls atomic.Int32
+	var lastEventID atomic.Value
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch calls.Add(1) {
+		case 1:
+			fmt.Fprint(w, "retry: 1\nid: a\ndata: first\n\n")
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler) // 异常断开连接
+		case 2:
+			lastEventID.Store(r.Header.Get(HeaderLastEventID))
+			fmt.Fprint(w, "id: b\ndata: second\n\n")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL).Send()
+	assert.NoError(t, err)
+	events, err := collectSSE(resp)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, []string{events[0].Data, events[1].Data})
+	assert.Equal(t, "a", lastEventID.Load())
+	assert.Equal(t, int32(2), calls.Load()) // 正常结束不重连
+}
+
+func TestResponseSSE_ReconnectExhausted(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "data: only\n\n")
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL).Send()
+	assert.NoError(t, err)
+	events, err := collectSSE(resp, WithSSEMaxReconnects(2), WithSSERetryDelay(time.Millisecond))
+	assert.Len(t, events, 1)
+	assert.Equal(t, ErrRequestStatusCode, errorx.ClassifyError(err))
+	assert.Equal(t, int32(3), calls.Load())
+
+	// 不重连时直接返回读取错误
+	calls.Store(0)
+	resp, err = NewClient().Get(server.URL).Send()
+	assert.NoError(t, err)
+	_, err = collectSSE(resp, WithSSEMaxReconnects(0))
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 上下文取消后不再重连
+	calls.Store(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resp, err = NewClient().Get(server.URL).WithContext(ctx).Send()
+	assert.NoError(t, err)
+	for _, err := range resp.SSE(WithSSERetryDelay(time.Hour)) {
+		if err == nil {
+			cancel()
+		}
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestResponseSSE_ReconnectWithBody(t *testing.T) {
+	var calls atomic.Int32
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		if calls.Add(1)%2 == 1 {
+			fmt.Fprint(w, "retry: 1\ndata: first\n\n")
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+		fmt.Fprint(w, "data: second\n\n")
+	}))
+	defer server.Close()
+
+	// 重连时重新发送原请求体
+	for name, req := range map[string]*Request{
+		"raw":    NewClient().Post(server.URL).SetBodyRaw([]byte(`{"q":"raw"}`)),
+		"string": NewClient().Post(server.URL).SetBodyString(`{"q":"string"}`),
+		"json":   NewClient().Post(server.URL).SetBodyJSON(map[string]string{"q": "json"}),
+		"reader": NewClient().Post(server.URL).SetBody(strings.NewReader(`{"q":"reader"}`)),
+	} {
+		calls.Store(0)
+		bodies = nil
+		resp, err := req.Send()
+		assert.NoError(t, err, name)
+		events, err := collectSSE(resp)
+		assert.NoError(t, err, name)
+		assert.Len(t, events, 2, name)
+		assert.Len(t, bodies, 2, name)
+		assert.Equal(t, bodies[0], bodies[1], name)
+		assert.Contains(t, bodies[1], name)
+	}
+
+	// 不可重读的请求体不重连，直接返回读取错误
+	calls.Store(0)
+	resp, err := NewClient().Post(server.URL).SetBody(io.NopCloser(strings.NewReader("once"))).Send()
+	assert.NoError(t, err)
+	events, err := collectSSE(resp)
+	assert.Error(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, int32(1), calls.Load())
+
+	// 启用重试时请求体已缓存，可以重连
+	calls.Store(0)
+	bodies = nil
+	resp, err = NewClient(WithRetryPolicy(fastRetryPolicy(2))).Post(server.URL).
+		SetHeader(HeaderIdempotencyKey, "k-1").
+		SetBody(io.NopCloser(strings.NewReader("cached"))).
+		Send()
+	assert.NoError(t, err)
+	events, err = collectSSE(resp)
+	assert.NoError(t, err)
+	assert.Len(t, events, 2)
+	assert.Equal(t, []string{"cached", "cached"}, bodies)
+}
+
+type ndjsonChunk struct {
+	Index int    `json:"index"`
+	Text  string `json:"text"`
+}
+
+func TestNDJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(HeaderContentType, ContentTypeApplicationXNDJSON)
+		io.WriteString(w, "{\"index\":0,\"text\":\"a\"}\n\n{invalid}\r\n{\"index\":2,\"text\":\"c\"}")
+	}))
+	defer server.Close()
+
+	resp, err := NewClient().Get(server.URL).Send()
+	assert.NoError(t, err)
+
+	var chunks []ndjsonChunk
+	var errs []error
+	for chunk, err := range NDJSON[ndjsonChunk](&resp) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		chunks = append(chunks, chunk)
+	}
+	assert.Equal(t, []ndjsonChunk{{0, "a"}, {2, "c"}}, chunks)
+	assert.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "ndjson line 3")
+
+	// 提前结束迭代
+	resp, err = NewClient().Get(server.URL).Send()
+	assert.NoError(t, err)
+	count := 0
+	for range NDJSON[map[string]any](&resp) {
+		count++
+		break
+	}
+	assert.Equal(t, 1, count)
+}
@@ -0,0 +1,385 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-30 18:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-30 18:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\transfer.go
+ * @Description: 流式下载(进度、断点续传、校验)与流式 multipart 上传
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package httpx
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+	"github.com/kamalyes/go-toolbox/pkg/sign"
+)
+
+const (
+	HeaderRange        = "Range"         // 请求头中的 Range
+	HeaderContentRange = "Content-Range" // 响应头中的 Content-Range
+)
+
+// ProgressFunc 传输进度回调，transferred 为已传输字节数(续传时包含已有部分)，total 未知时为 -1
+type ProgressFunc func(transferred, total int64)
+
+// TransferOption 下载选项
+type TransferOption func(*transferConfig)
+
+// transferConfig 下载配置
+type transferConfig struct {
+	progress  ProgressFunc
+	resume    bool
+	algorithm sign.HashCryptoFunc
+	checksum  string
+}
+
+// WithProgress 设置进度回调
+func WithProgress(fn ProgressFunc) TransferOption {
+	return func(c *transferConfig) {
+		c.progress = fn
+	}
+}
+
+// WithResume 已存在部分文件时通过 Range 请求续传，仅对 Request.Download 生效
+func WithResume() TransferOption {
+	return func(c *transferConfig) {
+		c.resume = true
+	}
+}
+
+// WithChecksum 下载完成后校验整个文件的摘要，algorithm 取值见 sign.SupportHMACCryptoFunc
+// expected 为十六进制摘要(不区分大小写)，校验失败时删除文件并返回 ErrChecksumMismatch
+func WithChecksum(algorithm sign.HashCryptoFunc, expected string) TransferOption {
+	return func(c *transferConfig) {
+		c.algorithm = algorithm
+		c.checksum = strings.ToLower(expected)
+	}
+}
+
+// newTransferConfig 应用下载选项
+func newTransferConfig(opts []TransferOption) *transferConfig {
+	cfg := &transferConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// newHash 创建校验使用的哈希，未设置校验时返回 nil
+func (c *transferConfig) newHash() (hash.Hash, error) {
+	if c.checksum == "" {
+		return nil, nil
+	}
+	newFunc, ok := sign.SupportHMACCryptoFunc[c.algorithm]
+	if !ok {
+		return nil, errorx.NewError(ErrUnsupportedChecksum, c.algorithm)
+	}
+	return newFunc(), nil
+}
+
+// progressWriter 统计写入字节数并回调进度
+type progressWriter struct {
+	written  int64
+	total    int64
+	progress ProgressFunc
+}
+
+// Write 实现 io.Writer 接口
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	w.progress(w.written, w.total)
+	return len(p), nil
+}
+
+// SaveToFile 将响应体流式写入文件(覆盖已有文件)并关闭响应体，返回写入的字节数
+// 支持 WithProgress 和 WithChecksum 选项
+func (r *Response) SaveToFile(path string, opts ...TransferOption) (int64, error) {
+	if r.IsError() {
+		return 0, r.Error()
+	}
+	defer r.Close()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	return writeDownload(f, path, r.Response, 0, newTransferConfig(opts))
+}
+
+// Download 发送请求并将响应流式写入文件，返回文件总大小
+// 设置 WithResume 且文件已存在时发送 Range 请求续传：
+// 服务端返回 206 时追加写入，返回 200 时重新下载，返回 416 时视为已下载完成
+//
+// 示例：
+//
+//	size, err := client.Get(url).Download("app.tar.gz",
+//	    httpx.WithResume(),
+//	    httpx.WithProgress(func(n, total int64) { bar.Set(n, total) }),
+//	    httpx.WithChecksum(sign.AlgorithmSHA256, sha256Hex),
+//	)
+func (r *Request) Download(path string, opts ...TransferOption) (int64, error) {
+	cfg := newTransferConfig(opts)
+
+	var offset int64
+	if cfg.resume {
+		if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+			offset = info.Size()
+			r = r.Clone().SetHeader(HeaderRange, fmt.Sprintf("bytes=%d-", offset))
+		}
+	}
+
+	resp, err := r.Send()
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	switch {
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0:
+		// 文件已完整，仅做校验
+		return offset, verifyFile(path, cfg)
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if start := contentRangeStart(resp.Header.Get(HeaderContentRange)); start != offset {
+			return 0, errorx.NewError(ErrUnexpectedContentRange, resp.Header.Get(HeaderContentRange), offset)
+		}
+		flags = os.O_WRONLY | os.O_APPEND
+	case resp.StatusCode >= http.StatusMultipleChoices:
+		return 0, errorx.NewError(ErrRequestStatusCode, resp.Status)
+	default:
+		offset = 0 // 服务端不支持 Range，重新下载
+	}
+
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	return writeDownload(f, path, resp.Response, offset, cfg)
+}
+
+// writeDownload 将响应体写入已打开的文件，offset 为文件中已有的字节数
+func writeDownload(f *os.File, path string, resp *http.Response, offset int64, cfg *transferConfig) (int64, error) {
+	h, err := cfg.newHash()
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+
+	writers := []io.Writer{f}
+	if h != nil {
+		writers = append(writers, h)
+		// 续传时先计算已有部分的摘要
+		if offset > 0 {
+			if err := hashFile(h, path); err != nil {
+				f.Close()
+				return 0, err
+			}
+		}
+	}
+	if cfg.progress != nil {
+		total := int64(-1)
+		if resp.ContentLength >= 0 {
+			total = offset + resp.ContentLength
+		}
+		writers = append(writers, &progressWriter{written: offset, total: total, progress: cfg.progress})
+	}
+
+	n, err := io.Copy(io.MultiWriter(writers...), resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return offset + n, err
+	}
+	if h != nil {
+		if err := matchChecksum(path, h, cfg.checksum); err != nil {
+			return offset + n, err
+		}
+	}
+	return offset + n, nil
+}
+
+// verifyFile 校验已存在文件的摘要
+func verifyFile(path string, cfg *transferConfig) error {
+	h, err := cfg.newHash()
+	if err != nil || h == nil {
+		return err
+	}
+	if err := hashFile(h, path); err != nil {
+		return err
+	}
+	return matchChecksum(path, h, cfg.checksum)
+}
+
+// hashFile 将文件内容写入哈希
+func hashFile(h hash.Hash, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(h, f)
+	return err
+}
+
+// matchChecksum 比较摘要，不一致时删除文件
+func matchChecksum(path string, h hash.Hash, expected string) error {
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		os.Remove(path)
+		return errorx.NewError(ErrChecksumMismatch, expected, actual)
+	}
+	return nil
+}
+
+// contentRangeStart 解析 Content-Range: bytes start-end/size 中的起始位置，无效时返回 -1
+func contentRangeStart(value string) int64 {
+	var start, end int64
+	var size string
+	if _, err := fmt.Sscanf(value, "bytes %d-%d/%s", &start, &end, &size); err != nil {
+		return -1
+	}
+	return start
+}
+
+// MultipartFile 流式上传的文件，Path 与 Reader 二选一
+type MultipartFile struct {
+	FieldName string    // 表单字段名
+	FileName  string    // 文件名，为空时使用 Path 的文件名
+	Path      string    // 文件路径，上传时打开，重试时可重新读取
+	Reader    io.Reader // 文件内容，只能读取一次
+}
+
+// SetBodyMultipartStream 设置流式 multipart/form-data 请求体，边读取文件边发送，不在内存中缓存整个文件
+// 全部文件都通过 Path 指定时请求体可重读(重试时重新打开文件)
+func (r *Request) SetBodyMultipartStream(fields map[string]string, files ...MultipartFile) *Request {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	rewindable := true
+	for _, file := range files {
+		rewindable = rewindable && file.Reader == nil
+	}
+
+	r.body, r.bodyBytes, r.bodyEncodeFunc = nil, nil, nil
+	r.bodyRewindable = rewindable
+	r.bodyOpener = func() (io.ReadCloser, error) {
+		pr, pw := io.Pipe()
+		go func() {
+			pw.CloseWithError(writeMultipart(pw, boundary, fields, files))
+		}()
+		return pr, nil
+	}
+	return r.SetHeader(HeaderContentType, ContentTypeMultipartFormData+"; boundary="+boundary)
+}
+
+// writeMultipart 按固定边界写入 multipart 内容
+func writeMultipart(w io.Writer, boundary string, fields map[string]string, files []MultipartFile) error {
+	mw := multipart.NewWriter(w)
+	if err := mw.SetBoundary(boundary); err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := mw.WriteField(k, fields[k]); err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		if err := writeMultipartFile(mw, file); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// writeMultipartFile 写入单个文件
+func writeMultipartFile(mw *multipart.Writer, file MultipartFile) error {
+	src := file.Reader
+	name := file.FileName
+	if src == nil {
+		f, err := os.Open(file.Path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		src = f
+		if name == "" {
+			name = filepath.Base(file.Path)
+		}
+	}
+
+	part, err := mw.CreateFormFile(file.FieldName, name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(part, src)
+	return err
+}
+
+// SetUploadProgress 设置上传进度回调，total 为请求的 Content-Length，未知时为 -1
+func (r *Request) SetUploadProgress(fn ProgressFunc) *Request {
+	r.uploadProgress = fn
+	return r
+}
+
+// progressReadCloser 统计读取字节数并回调进度
+type progressReadCloser struct {
+	io.ReadCloser
+	read     int64
+	total    int64
+	progress ProgressFunc
+}
+
+// Read 实现 io.Reader 接口
+func (r *progressReadCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.read += int64(n)
+		r.progress(r.read, r.total)
+	}
+	return n, err
+}
+
+// attachBody 设置流式请求体，全部文件可重新打开时设置 GetBody
+func (r *Request) attachBody(req *http.Request) error {
+	if r.bodyOpener == nil {
+		return nil
+	}
+	body, err := r.bodyOpener()
+	if err != nil {
+		return err
+	}
+	req.Body, req.ContentLength = body, -1
+	if r.bodyRewindable {
+		req.GetBody = r.bodyOpener
+	}
+	return nil
+}
+
+// trackUpload 为单次尝试的请求体设置上传进度回调，进度按实际发送的字节统计，重试时重新计数
+func (r *Request) trackUpload(req *http.Request) *http.Request {
+	if r.uploadProgress == nil || req.Body == nil || req.Body == http.NoBody {
+		return req
+	}
+	total := mathx.IF(req.ContentLength > 0, req.ContentLength, -1)
+	req.Body = &progressReadCloser{ReadCloser: req.Body, total: total, progress: r.uploadProgress}
+	return req
+}
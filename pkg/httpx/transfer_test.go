@@ -0,0 +1,261 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-30 18:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-30 18:00:00
+ * @FilePath: \go-toolbox\pkg\httpx\transfer_test.go
+ * @Description: 流式下载与上传测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package httpx
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+	"github.com/kamalyes/go-toolbox/pkg/sign"
+	"github.com/stretchr/testify/assert"
+)
+
+// transferContent 测试下载内容
+var transferContent = bytes.Repeat([]byte("0123456789"), 1000)
+
+// sha256Hex 计算十六进制 SHA256 摘要
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// rangeServer 支持 Range 请求的文件服务，记录最后一次 Range 请求头
+func rangeServer(t *testing.T) (*httptest.Server, *atomic.Value) {
+	var lastRange atomic.Value
+	lastRange.Store("")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRange.Store(r.Header.Get(HeaderRange))
+		http.ServeContent(w, r, "data.bin", time.Time{}, bytes.NewReader(transferContent))
+	}))
+	t.Cleanup(server.Close)
+	return server, &lastRange
+}
+
+func TestResponseSaveToFile(t *testing.T) {
+	server, _ := rangeServer(t)
+	path := filepath.Join(t.TempDir(), "data.bin")
+
+	resp, err := NewClient().Get(server.URL).Send()
+	assert.NoError(t, err)
+
+	var last, total int64
+	n, err := resp.SaveToFile(path,
+		WithProgress(func(transferred, size int64) { last, total = transferred, size }),
+		WithChecksum(sign.AlgorithmSHA256, strings.ToUpper(sha256Hex(transferContent))),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(transferContent)), n)
+	assert.Equal(t, n, last)
+	assert.Equal(t, n, total)
+
+	saved, _ := os.ReadFile(path)
+	assert.Equal(t, transferContent, saved)
+}
+
+func TestResponseSaveToFile_ChecksumMismatch(t *testing.T) {
+	server, _ := rangeServer(t)
+	path := filepath.Join(t.TempDir(), "data.bin")
+
+	resp, err := NewClient().Get(server.URL).Send()
+	assert.NoError(t, err)
+	_, err = resp.SaveToFile(path, WithChecksum(sign.AlgorithmMD5, "deadbeef"))
+	assert.Equal(t, ErrChecksumMismatch, errorx.ClassifyError(err))
+	assert.NoFileExists(t, path)
+
+	resp, err = NewClient().Get(server.URL).Send()
+	assert.NoError(t, err)
+	_, err = resp.SaveToFile(path, WithChecksum("CRC32", "00"))
+	assert.Equal(t, ErrUnsupportedChecksum, errorx.ClassifyError(err))
+}
+
+func TestRequestDownload_Resume(t *testing.T) {
+	server, lastRange := rangeServer(t)
+	path := filepath.Join(t.TempDir(), "data.bin")
+	half := len(transferContent) / 2
+	assert.NoError(t, os.WriteFile(path, transferContent[:half], 0o644))
+
+	var first, last int64 = -1, 0
+	n, err := NewClient().Get(server.URL).Download(path,
+		WithResume(),
+		WithProgress(func(transferred, _ int64) {
+			if first < 0 {
+				first = transferred
+			}
+			last = transferred
+		}),
+		WithChecksum(sign.AlgorithmSHA256, sha256Hex(transferContent)),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(transferContent)), n)
+	assert.Equal(t, "bytes=5000-", lastRange.Load())
+	assert.Greater(t, first, int64(half)) // 进度从已有部分开始累计
+	assert.Equal(t, n, last)
+
+	saved, _ := os.ReadFile(path)
+	assert.Equal(t, transferContent, saved)
+
+	// 文件已完整时服务端返回 416
+	n, err = NewClient().Get(server.URL).Download(path, WithResume(), WithChecksum(sign.AlgorithmSHA256, sha256Hex(transferContent)))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(transferContent)), n)
+
+	// 未设置续传时覆盖下载
+	assert.NoError(t, os.WriteFile(path, []byte("stale"), 0o644))
+	n, err = NewClient().Get(server.URL).Download(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "", lastRange.Load())
+	assert.Equal(t, int64(len(transferContent)), n)
+}
+
+func TestRequestDownload_RangeUnsupported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(transferContent)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "data.bin")
+	assert.NoError(t, os.WriteFile(path, []byte("partial"), 0o644))
+
+	n, err := NewClient().Get(server.URL).Download(path, WithResume())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(transferContent)), n)
+	saved, _ := os.ReadFile(path)
+	assert.Equal(t, transferContent, saved)
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	_, err = NewClient().Get(notFound.URL).Download(path)
+	assert.Equal(t, ErrRequestStatusCode, errorx.ClassifyError(err))
+}
+
+func TestContentRangeStart(t *testing.T) {
+	assert.Equal(t, int64(100), contentRangeStart("bytes 100-199/200"))
+	assert.Equal(t, int64(0), contentRangeStart("bytes 0-9/*"))
+	assert.Equal(t, int64(-1), contentRangeStart("invalid"))
+}
+
+func TestRequestSetBodyMultipartStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(r.FormValue("name") + "|"))
+		for _, field := range []string{"doc", "stream"} {
+			file, header, err := r.FormFile(field)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			content, _ := io.ReadAll(file)
+			w.Write([]byte(header.Filename + ":" + string(content) + "|"))
+		}
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("file content"), 0o644))
+
+	var uploaded, total int64
+	resp, err := NewClient().Post(server.URL).
+		SetBodyMultipartStream(map[string]string{"name": "toolbox"},
+			MultipartFile{FieldName: "doc", Path: path},
+			MultipartFile{FieldName: "stream", FileName: "s.txt", Reader: strings.NewReader("stream content")},
+		).
+		SetUploadProgress(func(transferred, size int64) { uploaded, total = transferred, size }).
+		Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "toolbox|doc.txt:file content|s.txt:stream content|", body)
+	assert.Greater(t, uploaded, int64(0))
+	assert.Equal(t, int64(-1), total)
+}
+
+func TestRequestSetBodyMultipartStream_Retry(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("file content"), 0o644))
+
+	// 文件路径上传可在重试时重新打开
+	resp, err := NewClient(WithRetryPolicy(fastRetryPolicy(2))).Put(server.URL).
+		SetBodyMultipartStream(nil, MultipartFile{FieldName: "doc", Path: path}).
+		Send()
+	assert.NoError(t, err)
+	body, _ := resp.String()
+	assert.Contains(t, body, "file content")
+	assert.Equal(t, int32(2), calls.Load())
+
+	// 文件不存在时返回错误
+	_, err = NewClient().Put(server.URL).
+		SetBodyMultipartStream(nil, MultipartFile{FieldName: "doc", Path: path + ".missing"}).
+		Send()
+	assert.Error(t, err)
+}
+
+func TestRequestSetBodyMultipartStream_RetryNoLeak(t *testing.T) {
+	server, calls := flakyServer(t, 1, http.StatusServiceUnavailable, nil)
+	path := filepath.Join(t.TempDir(), "doc.txt")
+	assert.NoError(t, os.WriteFile(path, bytes.Repeat([]byte("x"), 64<<10), 0o644))
+
+	// writers 统计仍在运行的 multipart 写入协程
+	writers := func() int {
+		buf := make([]byte, 1<<20)
+		return strings.Count(string(buf[:runtime.Stack(buf, true)]), "httpx.writeMultipart(")
+	}
+
+	client := NewClient(WithRetryPolicy(fastRetryPolicy(3)))
+	for range 5 {
+		calls.Store(0)
+		var attempts int
+		var last int64
+		resp, err := client.Put(server.URL).
+			SetBodyMultipartStream(nil, MultipartFile{FieldName: "doc", Path: path}).
+			SetUploadProgress(func(transferred, size int64) {
+				if transferred <= last {
+					attempts++ // 进度归零表示新一次尝试
+				}
+				last = transferred
+			}).
+			Send()
+		assert.NoError(t, err)
+		body, _ := resp.String()
+		assert.Equal(t, int64(len(body)), last) // 进度按实际发送的字节统计
+		assert.Equal(t, int(calls.Load()), attempts+1)
+	}
+	assert.Eventually(t, func() bool { return writers() == 0 }, time.Second, 10*time.Millisecond)
+}
+
+func TestRequestSetUploadProgress(t *testing.T) {
+	server := echoServer(t)
+
+	var uploaded, total int64
+	resp, err := NewClient().Post(server.URL).
+		SetBodyString("payload").
+		SetUploadProgress(func(transferred, size int64) { uploaded, total = transferred, size }).
+		Send()
+	assert.NoError(t, err)
+	resp.Close()
+	assert.Equal(t, int64(7), uploaded)
+	assert.Equal(t, int64(7), total)
+}
@@ -20,6 +20,8 @@ const (
 	HeaderAuthorization = "Authorization" // HTTP 请求头中的 Authorization
 	HeaderUserAgent     = "User-Agent"    // HTTP 请求头中的 User-Agent
 	HeaderCookie        = "Cookie"        // HTTP 请求头中的 Cookie
+	HeaderXTraceID      = "X-Trace-ID"    // 链路追踪 ID
+	HeaderXRequestID    = "X-Request-ID"  // 请求 ID
 
 	// 常见的 Content-Type
 	ContentTypeTextPlain                    = "text/plain"                        // 纯文本格式
@@ -36,6 +38,7 @@ const (
 	ContentTypeTextXMLCharacterUTF8         = "text/xml; charset=utf-8"           // UTF-8 编码的 XML 文本
 	ContentTypeTextCSV                      = "text/csv"                          // CSV 文本格式
 	ContentTypeTextCSVCharacterUTF8         = "text/csv; charset=utf-8"           // UTF-8 编码的 CSV 文本
+	ContentTypeTextEventStream              = "text/event-stream"                 // SSE 事件流格式
 	ContentTypeImageJPEG                    = "image/jpeg"                        // JPEG 图片格式
 	ContentTypeImagePNG                     = "image/png"                         // PNG 图片格式
 	ContentTypeImageGIF                     = "image/gif"                         // GIF 图片格式
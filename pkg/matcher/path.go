@@ -81,6 +81,11 @@ func NewPathMatcher(matchType PathMatcherType, pattern string) (*PathMatcher, er
 	return pm, nil
 }
 
+// Pattern 返回匹配模式
+func (pm *PathMatcher) Pattern() string {
+	return pm.pattern
+}
+
 // Match 执行路径匹配
 func (pm *PathMatcher) Match(path string) bool {
 	switch pm.matchType {
@@ -43,6 +43,7 @@ func TestPathMatcher(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			pm, err := NewPathMatcher(tt.matchType, tt.pattern)
 			assert.NoError(t, err, "创建路径匹配器失败")
+			assert.Equal(t, tt.pattern, pm.Pattern())
 
 			result := pm.Match(tt.path)
 			assert.Equal(t, tt.expected, result, "路径匹配结果不符合预期")
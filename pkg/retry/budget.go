@@ -0,0 +1,65 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-29 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-29 10:00:00
+ * @FilePath: \go-toolbox\pkg\retry\budget.go
+ * @Description: 基于令牌的重试预算，限制重试占总调用的比例，防止重试风暴
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package retry
+
+import (
+	"sync"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+)
+
+// Budget 重试预算，可在多个协程、多个 Policy 之间共享
+// 每次调用存入 ratio 个令牌，每次重试消耗 1 个令牌，令牌不足时不再重试
+// 长期来看重试次数不超过 ratio × 调用次数 + burst
+//
+// 示例：重试不超过调用量的 10%，允许 10 次突发重试
+//
+//	budget := retry.NewBudget(0.1, 10)
+type Budget struct {
+	mu     sync.Mutex
+	ratio  float64 // 每次调用存入的令牌数
+	tokens float64 // 当前令牌数
+	burst  float64 // 令牌上限
+}
+
+// NewBudget 创建重试预算
+// ratio 为重试占调用的比例(0-1)，burst 为初始及最多可累积的重试次数
+func NewBudget(ratio float64, burst int) *Budget {
+	ratio = mathx.IF(ratio < 0, 0, ratio)
+	capacity := float64(mathx.IF(burst < 1, 1, burst))
+	return &Budget{ratio: ratio, tokens: capacity, burst: capacity}
+}
+
+// Deposit 记录一次调用，存入令牌
+func (b *Budget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.burst)
+}
+
+// Withdraw 尝试为一次重试消耗令牌，预算不足时返回 false
+func (b *Budget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Tokens 返回当前剩余令牌数
+func (b *Budget) Tokens() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
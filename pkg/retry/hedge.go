@@ -0,0 +1,316 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-29 18:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-29 18:00:00
+ * @FilePath: \go-toolbox\pkg\retry\hedge.go
+ * @Description: 对冲请求执行器，降低尾延迟
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package retry
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+	"github.com/kamalyes/go-toolbox/pkg/syncx"
+)
+
+const (
+	hedgeSampleSize    = 128 // 用于计算自适应对冲延迟的延迟样本数
+	hedgeMinSamples    = 10  // 启用自适应对冲延迟所需的最少样本数
+	defaultHedgeDelay  = 100 * time.Millisecond
+	defaultHedgeCount  = 1
+	defaultHedgeTarget = 95.0
+)
+
+// HedgeStats 对冲统计
+type HedgeStats struct {
+	Calls     int64 // 调用次数
+	Hedges    int64 // 发起的对冲次数
+	HedgeWins int64 // 由对冲请求(而非首次请求)返回成功结果的次数
+}
+
+// Hedge 对冲请求执行器
+// 先发起一次请求，超过对冲延迟仍未返回时并行发起额外请求，取第一个成功的结果并取消其余请求
+// 某次请求失败时立即发起下一次请求；所有请求都失败时返回最后一个错误
+//
+// 示例：
+//
+//	hedge := retry.NewHedge[*User]().AdaptiveDelay(95).MaxHedges(2).MaxInFlight(100)
+//	user, err := hedge.Run(ctx, func(ctx context.Context) (*User, error) {
+//	    return client.GetUser(ctx, id)
+//	})
+type Hedge[T any] struct {
+	mu          sync.RWMutex
+	delay       time.Duration             // 固定对冲延迟，自适应模式下样本不足时使用
+	percentile  float64                   // 自适应对冲延迟的百分位(0-100)，0 表示使用固定延迟
+	maxHedges   int                       // 单次调用最多发起的对冲次数
+	maxInFlight int64                     // 全局同时进行中的对冲请求上限，0 表示不限制
+	onSuccess   func(result T, err error) // 成功回调
+	onError     func(result T, err error) // 失败回调
+	onHedgeWin  func(result T, attempt int)
+
+	inFlight  atomic.Int64
+	calls     atomic.Int64
+	hedges    atomic.Int64
+	hedgeWins atomic.Int64
+
+	samplesMu sync.Mutex
+	samples   []float64 // 成功请求的延迟样本(环形缓冲)
+	sampleIdx int
+}
+
+// NewHedge 创建对冲执行器，默认对冲延迟 100ms，最多对冲 1 次
+func NewHedge[T any]() *Hedge[T] {
+	return &Hedge[T]{
+		delay:     defaultHedgeDelay,
+		maxHedges: defaultHedgeCount,
+		samples:   make([]float64, 0, hedgeSampleSize),
+	}
+}
+
+// Delay 设置固定对冲延迟
+func (h *Hedge[T]) Delay(d time.Duration) *Hedge[T] {
+	return syncx.WithLockReturnValue(&h.mu, func() *Hedge[T] {
+		h.delay = d
+		return h
+	})
+}
+
+// AdaptiveDelay 使用观测到的成功请求延迟的百分位作为对冲延迟，如 95 表示 p95
+// 样本不足时使用 Delay 设置的固定延迟
+func (h *Hedge[T]) AdaptiveDelay(percentile float64) *Hedge[T] {
+	return syncx.WithLockReturnValue(&h.mu, func() *Hedge[T] {
+		h.percentile = mathx.IF(percentile <= 0 || percentile > 100, defaultHedgeTarget, percentile)
+		return h
+	})
+}
+
+// MaxHedges 设置单次调用最多发起的对冲次数(不含首次请求)
+func (h *Hedge[T]) MaxHedges(n int) *Hedge[T] {
+	return syncx.WithLockReturnValue(&h.mu, func() *Hedge[T] {
+		h.maxHedges = mathx.IF(n < 0, 0, n)
+		return h
+	})
+}
+
+// MaxInFlight 设置全局同时进行中的对冲请求上限，达到上限时暂缓对冲，防止放大下游压力
+func (h *Hedge[T]) MaxInFlight(n int) *Hedge[T] {
+	return syncx.WithLockReturnValue(&h.mu, func() *Hedge[T] {
+		h.maxInFlight = int64(mathx.IF(n < 0, 0, n))
+		return h
+	})
+}
+
+// OnSuccess 设置成功回调
+func (h *Hedge[T]) OnSuccess(fn func(result T, err error)) *Hedge[T] {
+	return syncx.WithLockReturnValue(&h.mu, func() *Hedge[T] {
+		h.onSuccess = fn
+		return h
+	})
+}
+
+// OnError 设置失败回调
+func (h *Hedge[T]) OnError(fn func(result T, err error)) *Hedge[T] {
+	return syncx.WithLockReturnValue(&h.mu, func() *Hedge[T] {
+		h.onError = fn
+		return h
+	})
+}
+
+// OnHedgeWin 设置对冲请求胜出时的回调，attempt 为胜出请求的序号(首次请求为 0)
+func (h *Hedge[T]) OnHedgeWin(fn func(result T, attempt int)) *Hedge[T] {
+	return syncx.WithLockReturnValue(&h.mu, func() *Hedge[T] {
+		h.onHedgeWin = fn
+		return h
+	})
+}
+
+// GetDelay 获取当前生效的对冲延迟
+func (h *Hedge[T]) GetDelay() time.Duration {
+	h.mu.RLock()
+	delay, percentile := h.delay, h.percentile
+	h.mu.RUnlock()
+	if percentile == 0 {
+		return delay
+	}
+
+	h.samplesMu.Lock()
+	defer h.samplesMu.Unlock()
+	if len(h.samples) < hedgeMinSamples {
+		return delay
+	}
+	return time.Duration(mathx.Percentile(h.samples, percentile))
+}
+
+// Stats 获取对冲统计
+func (h *Hedge[T]) Stats() HedgeStats {
+	return HedgeStats{
+		Calls:     h.calls.Load(),
+		Hedges:    h.hedges.Load(),
+		HedgeWins: h.hedgeWins.Load(),
+	}
+}
+
+// hedgeResult 单次请求结果
+type hedgeResult[T any] struct {
+	attempt int
+	result  T
+	err     error
+	latency time.Duration
+}
+
+// Run 执行 fn，按对冲策略并行发起请求，返回第一个成功的结果
+// fn 应当响应 ctx 取消，已有结果后其余请求的 ctx 会被取消
+func (h *Hedge[T]) Run(ctx context.Context, fn func(ctx context.Context) (T, error)) (result T, err error) {
+	if fn == nil {
+		return result, ErrFunIsNil
+	}
+	h.calls.Add(1)
+
+	h.mu.RLock()
+	maxHedges, maxInFlight := h.maxHedges, h.maxInFlight
+	h.mu.RUnlock()
+	delay := h.GetDelay()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// 缓冲足够容纳全部请求结果，被取消的请求不会阻塞
+	results := make(chan hedgeResult[T], maxHedges+1)
+	launched, pending := 0, 0
+	launch := func(hedged bool) {
+		if hedged {
+			h.hedges.Add(1)
+		}
+		attempt := launched
+		launched++
+		pending++
+		go func() {
+			if hedged {
+				defer h.inFlight.Add(-1)
+			}
+			results <- h.runAttempt(ctx, attempt, fn)
+		}()
+	}
+	// tryHedge 还能发起对冲请求且预占到全局并发名额时发起对冲请求
+	tryHedge := func() bool {
+		if launched > maxHedges || !h.acquireInFlight(maxInFlight) {
+			return false
+		}
+		launch(true)
+		return true
+	}
+
+	launch(false)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			h.callCallbacks(result, err)
+			return result, err
+
+		case <-timer.C:
+			tryHedge()
+			if launched <= maxHedges {
+				timer.Reset(delay)
+			}
+
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				h.recordLatency(res.latency)
+				if res.attempt > 0 {
+					h.hedgeWins.Add(1)
+					h.mu.RLock()
+					onHedgeWin := h.onHedgeWin
+					h.mu.RUnlock()
+					if onHedgeWin != nil {
+						onHedgeWin(res.result, res.attempt)
+					}
+				}
+				h.callCallbacks(res.result, nil)
+				return res.result, nil
+			}
+
+			err = res.err
+			// 失败后立即发起下一次请求
+			if tryHedge() {
+				continue
+			}
+			if pending == 0 && launched > maxHedges {
+				h.callCallbacks(result, err)
+				return result, err
+			}
+			// 等待在途请求或对冲并发额度
+			if pending == 0 {
+				timer.Reset(delay)
+			}
+		}
+	}
+}
+
+// acquireInFlight 预占一个全局对冲并发名额，maxInFlight 为 0 时不限制
+// 检查与计数在同一次 CompareAndSwap 中完成，并发调用不会超出上限
+func (h *Hedge[T]) acquireInFlight(maxInFlight int64) bool {
+	for {
+		n := h.inFlight.Load()
+		if maxInFlight > 0 && n >= maxInFlight {
+			return false
+		}
+		if h.inFlight.CompareAndSwap(n, n+1) {
+			return true
+		}
+	}
+}
+
+// runAttempt 执行单次请求并捕获 panic
+func (h *Hedge[T]) runAttempt(ctx context.Context, attempt int, fn func(ctx context.Context) (T, error)) (res hedgeResult[T]) {
+	start := time.Now()
+	res.attempt = attempt
+	defer func() {
+		if rec := recover(); rec != nil {
+			var zero T
+			res.result, res.err = zero, fmt.Errorf("%s: %v", ErrPanic, rec)
+		}
+		res.latency = time.Since(start)
+	}()
+	res.result, res.err = fn(ctx)
+	return res
+}
+
+// recordLatency 记录成功请求的延迟样本
+func (h *Hedge[T]) recordLatency(latency time.Duration) {
+	h.samplesMu.Lock()
+	defer h.samplesMu.Unlock()
+	if len(h.samples) < hedgeSampleSize {
+		h.samples = append(h.samples, float64(latency))
+		return
+	}
+	h.samples[h.sampleIdx] = float64(latency)
+	h.sampleIdx = (h.sampleIdx + 1) % hedgeSampleSize
+}
+
+// callCallbacks 调用成功或失败回调
+func (h *Hedge[T]) callCallbacks(result T, err error) {
+	h.mu.RLock()
+	onSuccess, onError := h.onSuccess, h.onError
+	h.mu.RUnlock()
+
+	switch {
+	case err != nil && onError != nil:
+		onError(result, err)
+	case err == nil && onSuccess != nil:
+		onSuccess(result, nil)
+	}
+}
@@ -0,0 +1,187 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-29 18:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-29 18:00:00
+ * @FilePath: \go-toolbox\pkg\retry\hedge_test.go
+ * @Description: 对冲请求执行器测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHedge_FirstAttemptWins(t *testing.T) {
+	var calls atomic.Int32
+	h := NewHedge[int]().Delay(50 * time.Millisecond)
+	result, err := h.Run(context.Background(), func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		return 1, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, result)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, HedgeStats{Calls: 1}, h.Stats())
+}
+
+func TestHedge_HedgeWins(t *testing.T) {
+	var canceled atomic.Bool
+	var wonAttempt int
+	var successCalled bool
+	h := NewHedge[int]().
+		Delay(10 * time.Millisecond).
+		OnHedgeWin(func(result int, attempt int) { wonAttempt = attempt }).
+		OnSuccess(func(result int, err error) { successCalled = true })
+
+	var attempts atomic.Int32
+	start := time.Now()
+	result, err := h.Run(context.Background(), func(ctx context.Context) (int, error) {
+		if attempts.Add(1) == 1 {
+			// 首次请求很慢，直到被取消
+			<-ctx.Done()
+			canceled.Store(true)
+			return 0, ctx.Err()
+		}
+		return 2, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, wonAttempt)
+	assert.True(t, successCalled)
+	assert.Equal(t, HedgeStats{Calls: 1, Hedges: 1, HedgeWins: 1}, h.Stats())
+	assert.Eventually(t, canceled.Load, time.Second, time.Millisecond)
+}
+
+func TestHedge_AllFailed(t *testing.T) {
+	var calls atomic.Int32
+	var onErr error
+	h := NewHedge[string]().Delay(time.Hour).MaxHedges(2).OnError(func(result string, err error) { onErr = err })
+
+	// 失败后立即发起下一次请求，无需等待对冲延迟
+	_, err := h.Run(context.Background(), func(ctx context.Context) (string, error) {
+		return "", errors.New("failed " + string(rune('0'+calls.Add(1))))
+	})
+	assert.EqualError(t, err, "failed 3")
+	assert.Equal(t, err, onErr)
+	assert.Equal(t, int32(3), calls.Load())
+
+	_, err = h.Run(context.Background(), func(ctx context.Context) (string, error) {
+		panic("boom")
+	})
+	assert.ErrorContains(t, err, "boom")
+
+	_, err = h.Run(context.Background(), nil)
+	assert.ErrorIs(t, err, ErrFunIsNil)
+}
+
+func TestHedge_MaxInFlight(t *testing.T) {
+	h := NewHedge[int]().Delay(5 * time.Millisecond).MaxHedges(3).MaxInFlight(1)
+
+	var running, maxRunning atomic.Int32
+	_, err := h.Run(context.Background(), func(ctx context.Context) (int, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			if m := maxRunning.Load(); n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+			return 1, nil
+		}
+	})
+	assert.NoError(t, err)
+	// 首次请求 + 最多 1 个进行中的对冲请求
+	assert.Equal(t, int32(2), maxRunning.Load())
+	assert.Equal(t, int64(1), h.Stats().Hedges)
+}
+
+func TestHedge_MaxInFlightConcurrent(t *testing.T) {
+	// 并发预占全局名额不会超出上限
+	h := NewHedge[int]()
+	var acquired atomic.Int32
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if h.acquireInFlight(5) {
+				acquired.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(5), acquired.Load())
+	assert.Equal(t, int64(5), h.inFlight.Load())
+
+	// 并发 Run 共享对冲并发上限
+	const calls = 20
+	h = NewHedge[int]().Delay(time.Millisecond).MaxHedges(5).MaxInFlight(2)
+	var running, maxRunning atomic.Int32
+	for range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = h.Run(context.Background(), func(ctx context.Context) (int, error) {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					if m := maxRunning.Load(); n <= m || maxRunning.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				select {
+				case <-ctx.Done():
+					return 0, ctx.Err()
+				case <-time.After(30 * time.Millisecond):
+					return 1, nil
+				}
+			})
+		}()
+	}
+	wg.Wait()
+	// 每次调用的首次请求 + 最多 2 个进行中的对冲请求
+	assert.LessOrEqual(t, maxRunning.Load(), int32(calls+2))
+	// 被取消的对冲请求结束后归还名额
+	assert.Eventually(t, func() bool { return h.inFlight.Load() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestHedge_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := NewHedge[int]().Delay(5*time.Millisecond).MaxHedges(2).Run(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestHedge_AdaptiveDelay(t *testing.T) {
+	h := NewHedge[int]().Delay(time.Second).AdaptiveDelay(95)
+	assert.Equal(t, time.Second, h.GetDelay())
+
+	for i := range 200 {
+		h.recordLatency(time.Duration(i%100+1) * time.Millisecond)
+	}
+	delay := h.GetDelay()
+	assert.GreaterOrEqual(t, delay, 90*time.Millisecond)
+	assert.LessOrEqual(t, delay, 100*time.Millisecond)
+
+	// 非法百分位使用默认 p95
+	assert.Equal(t, defaultHedgeTarget, NewHedge[int]().AdaptiveDelay(120).percentile)
+}
@@ -72,6 +72,7 @@ type Retry struct {
 	errCallFun        ErrCallbackFunc     // 错误回调函数
 	successCallFun    SuccessCallbackFunc // 成功回调函数
 	conditionFunc     func(error) bool    // 重试条件函数
+	backoff           syncx.Backoff       // 退避策略，设置后替代间隔、退避倍数和抖动配置
 }
 
 // DoFun 定义执行函数的类型
@@ -184,6 +185,15 @@ func (r *Retry) SetConditionFunc(fn func(error) bool) *Retry {
 	})
 }
 
+// SetBackoff 设置退避策略，设置后 interval、maxInterval、backoffMultiplier 和抖动配置不再生效
+// 退避策略返回 syncx.BackoffStop 时停止重试(如 syncx.MaxElapsedBackoff 超出总耗时)
+func (r *Retry) SetBackoff(backoff syncx.Backoff) *Retry {
+	return syncx.WithLockReturnValue(&r.mu, func() *Retry {
+		r.backoff = backoff
+		return r
+	})
+}
+
 // GetCaller 获取调用者信息
 func (r *Retry) GetCaller() string {
 	return syncx.WithLockReturnValue(&r.mu, func() string {
@@ -254,6 +264,13 @@ func (r *Retry) GetConditionFunc() func(error) bool {
 	})
 }
 
+// GetBackoff 获取退避策略
+func (r *Retry) GetBackoff() syncx.Backoff {
+	return syncx.WithRLockReturnValue(&r.mu, func() syncx.Backoff {
+		return r.backoff
+	})
+}
+
 // GetContext 获取上下文
 func (r *Retry) GetContext() context.Context {
 	return syncx.WithRLockReturnValue(&r.mu, func() context.Context {
@@ -271,18 +288,42 @@ func (r *Retry) Do(fn DoFun) (err error) {
 		}, r.caller)
 		// 确保尝试次数为正数
 		r.attemptCount = mathx.IF(r.attemptCount < 1, 1, r.attemptCount)
-		// 默认抖动百分比 20%
-		jitterPercent := mathx.IF(r.jitterPercent == 0 && r.jitter, 0.2, r.jitterPercent)
-		return doRetryWithCondition(r.ctx, r.attemptCount, r.interval, r.maxInterval, r.backoffMultiplier, r.jitter, jitterPercent, fn, r.errCallFun, r.successCallFun, r.conditionFunc, r.caller)
+		return doRetryWithCondition(r.ctx, r.attemptCount, r.currentBackoff(), fn, r.errCallFun, r.successCallFun, r.conditionFunc, r.caller)
+	})
+}
+
+// currentBackoff 返回生效的退避策略，未设置时由间隔、退避倍数和抖动配置生成(调用方需持有锁)
+func (r *Retry) currentBackoff() syncx.Backoff {
+	if r.backoff != nil {
+		return r.backoff
+	}
+	// 默认抖动百分比 20%
+	jitterPercent := mathx.IF(r.jitterPercent == 0 && r.jitter, 0.2, r.jitterPercent)
+	return intervalBackoff(r.interval, r.maxInterval, r.backoffMultiplier, mathx.IF(r.jitter, jitterPercent, 0))
+}
+
+// intervalBackoff 按初始间隔、退避倍数生成退避策略，最大间隔只限制退避后的间隔
+// jitterPercent 大于 0 时在间隔上随机增加 0~jitterPercent 的抖动
+func intervalBackoff(interval, maxInterval time.Duration, backoffMultiplier, jitterPercent float64) syncx.Backoff {
+	return syncx.BackoffFunc(func(s syncx.BackoffState) time.Duration {
+		current := interval
+		if s.Attempt > 0 && backoffMultiplier > 1.0 {
+			current = syncx.ExponentialBackoff(interval, backoffMultiplier, maxInterval).Next(s)
+		}
+		if current > 0 && jitterPercent > 0 {
+			current += time.Duration(random.RandFloat(0, float64(current)*jitterPercent))
+		}
+		return current
 	})
 }
 
 // doRetryWithCondition 内部函数，定义了重试操作，执行指定次数的尝试
-func doRetryWithCondition(ctx context.Context, attemptCount int, interval, maxInterval time.Duration, backoffMultiplier float64, jitter bool, jitterPercent float64, fn DoFun, errCallFun ErrCallbackFunc, successCallFun SuccessCallbackFunc, conditionFunc func(error) bool, funcName ...string) (err error) {
+func doRetryWithCondition(ctx context.Context, attemptCount int, backoff syncx.Backoff, fn DoFun, errCallFun ErrCallbackFunc, successCallFun SuccessCallbackFunc, conditionFunc func(error) bool, funcName ...string) (err error) {
 	var (
 		fName           = mathx.IF(len(funcName) > 0, funcName[0], "") // 获取函数名称
 		nowAttemptCount int
-		currentInterval = interval // 当前重试间隔
+		start           = time.Now()
+		lastDelay       time.Duration // 上一次等待时间
 	)
 
 	for nowAttemptCount < attemptCount {
@@ -311,26 +352,15 @@ func doRetryWithCondition(ctx context.Context, attemptCount int, interval, maxIn
 				errCallFun(nowAttemptCount, attemptCount-nowAttemptCount, err, fName) // 调用错误回调函数
 			}
 
-			// 等待指定的间隔时间
-			if currentInterval > 0 {
-				waitTime := currentInterval
-
-				// 添加随机抖动
-				if jitter && jitterPercent > 0 {
-					jitterRange := float64(currentInterval) * jitterPercent
-					waitTime = currentInterval + time.Duration(random.RandFloat(0, jitterRange))
-				}
+			// 按退避策略等待
+			waitTime := backoff.Next(syncx.BackoffState{Attempt: nowAttemptCount - 1, Last: lastDelay, Elapsed: time.Since(start)})
+			if waitTime == syncx.BackoffStop {
+				return err // 退避策略要求停止重试
+			}
+			if waitTime > 0 {
 				time.Sleep(waitTime)
-
-				// 应用退避倍数
-				if backoffMultiplier > 1.0 {
-					currentInterval = time.Duration(float64(currentInterval) * backoffMultiplier)
-					// 限制最大间隔
-					if maxInterval > 0 && currentInterval > maxInterval {
-						currentInterval = maxInterval
-					}
-				}
 			}
+			lastDelay = waitTime
 		}
 	}
 
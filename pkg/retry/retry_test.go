@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/kamalyes/go-toolbox/pkg/syncx"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -852,3 +853,50 @@ func TestRetryContextTimeoutDuringExecution(t *testing.T) {
 	// 不应该完成所有10次尝试
 	assert.True(t, atomic.LoadInt32(&counter) < 10)
 }
+
+// 测试自定义退避策略
+func TestRetrySetBackoff(t *testing.T) {
+	var attempts []int
+	var calls int
+	err := NewRetry().
+		SetAttemptCount(5).
+		SetInterval(time.Hour). // 设置退避策略后不再生效
+		SetBackoff(syncx.BackoffFunc(func(s syncx.BackoffState) time.Duration {
+			attempts = append(attempts, s.Attempt)
+			if s.Attempt == 2 {
+				return syncx.BackoffStop
+			}
+			return time.Millisecond
+		})).
+		Do(func() error {
+			calls++
+			return errors.New("failed")
+		})
+
+	assert.Error(t, err)
+	assert.Equal(t, 3, calls)
+	assert.Equal(t, []int{0, 1, 2}, attempts)
+	assert.NotNil(t, NewRetry().SetBackoff(syncx.ConstantBackoff(time.Second)).GetBackoff())
+}
+
+// 测试最后一次尝试失败后仍按间隔等待，与引入退避策略前的耗时一致
+func TestRetryWaitAfterLastAttempt(t *testing.T) {
+	var attempts []int
+	start := time.Now()
+	err := NewRetry().
+		SetAttemptCount(3).
+		SetInterval(20 * time.Millisecond).
+		Do(func() error { return errors.New("failed") })
+	assert.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+
+	err = NewRetry().
+		SetAttemptCount(2).
+		SetBackoff(syncx.BackoffFunc(func(s syncx.BackoffState) time.Duration {
+			attempts = append(attempts, s.Attempt)
+			return 0
+		})).
+		Do(func() error { return errors.New("failed") })
+	assert.Error(t, err)
+	assert.Equal(t, []int{0, 1}, attempts)
+}
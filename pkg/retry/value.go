@@ -0,0 +1,213 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-29 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-29 10:00:00
+ * @FilePath: \go-toolbox\pkg\retry\value.go
+ * @Description: 带返回值的泛型重试，支持单次尝试超时、Retry-After 和重试预算
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/mathx"
+	"github.com/kamalyes/go-toolbox/pkg/syncx"
+)
+
+// ErrBudgetExhausted 重试预算耗尽
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// Policy 重试策略，零值表示只执行一次
+type Policy struct {
+	MaxAttempts       int                                               // 最大尝试次数(含首次)，小于 1 时按 1 处理
+	Interval          time.Duration                                     // 初始重试间隔
+	MaxInterval       time.Duration                                     // 最大重试间隔，0 表示不限制
+	BackoffMultiplier float64                                           // 退避倍数，大于 1 时生效
+	JitterPercent     float64                                           // 抖动百分比(0-1)，例如 0.2 表示在间隔上随机增加 0~20%
+	Backoff           syncx.Backoff                                     // 退避策略，设置后替代 Interval、MaxInterval、BackoffMultiplier 和 JitterPercent
+	AttemptTimeout    time.Duration                                     // 单次尝试超时，0 表示不限制
+	Condition         func(err error) bool                              // 重试条件，为 nil 时全部重试
+	Budget            *Budget                                           // 重试预算，为 nil 时不限制
+	OnRetry           func(attempt int, err error, delay time.Duration) // 每次重试前的回调，attempt 为刚失败的尝试序号
+}
+
+// retryAfter 可提供下次重试间隔的错误，如 errorx.RetryableError
+type retryAfter interface {
+	GetRetryAfter() time.Duration
+}
+
+// shouldRetry 可声明是否继续重试的错误，如 errorx.RetryableError
+type shouldRetry interface {
+	ShouldRetry() bool
+}
+
+// Policy 将 Retry 的配置转换为 Policy，便于与 DoValue 共用
+func (r *Retry) Policy() *Policy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	jitterPercent := 0.0
+	if r.jitter {
+		jitterPercent = mathx.IF(r.jitterPercent == 0, 0.2, r.jitterPercent)
+	}
+	return &Policy{
+		MaxAttempts:       r.attemptCount,
+		Interval:          r.interval,
+		MaxInterval:       r.maxInterval,
+		BackoffMultiplier: r.backoffMultiplier,
+		JitterPercent:     jitterPercent,
+		Condition:         r.conditionFunc,
+		Backoff:           r.backoff,
+	}
+}
+
+// DoValue 按策略执行 fn 并返回结果
+//   - 每次尝试使用独立的上下文，设置了 AttemptTimeout 时超时返回 ErrTimeout 并进入下一次尝试
+//   - 等待时间由 Backoff 计算，返回 syncx.BackoffStop 时停止重试
+//   - 错误实现了 GetRetryAfter() 时(如 errorx.RetryableError)，以其返回值作为下次间隔
+//   - 错误实现了 ShouldRetry() 且返回 false 时不再重试
+//   - 设置了 Budget 时，预算不足则停止重试并返回包装了 ErrBudgetExhausted 的错误
+//
+// 示例：
+//
+//	user, err := retry.DoValue(ctx, &retry.Policy{
+//	    MaxAttempts:    3,
+//	    Interval:       100 * time.Millisecond,
+//	    AttemptTimeout: time.Second,
+//	    Budget:         budget,
+//	}, func(ctx context.Context) (*User, error) {
+//	    return client.GetUser(ctx, id)
+//	})
+func DoValue[T any](ctx context.Context, policy *Policy, fn func(ctx context.Context) (T, error)) (result T, err error) {
+	if fn == nil {
+		return result, ErrFunIsNil
+	}
+	if policy == nil {
+		policy = &Policy{}
+	}
+	if policy.Budget != nil {
+		policy.Budget.Deposit()
+	}
+
+	attempts := mathx.IF(policy.MaxAttempts < 1, 1, policy.MaxAttempts)
+	backoff := policy.backoff()
+	start, lastDelay := time.Now(), time.Duration(0)
+	for attempt := 1; ; attempt++ {
+		if err = ctx.Err(); err != nil {
+			return result, err
+		}
+		if result, err = runAttempt(ctx, policy.AttemptTimeout, fn); err == nil {
+			return result, nil
+		}
+
+		if attempt >= attempts || !policy.retryable(err) {
+			return result, err
+		}
+		if policy.Budget != nil && !policy.Budget.Withdraw() {
+			return result, fmt.Errorf("%w: %w", ErrBudgetExhausted, err)
+		}
+
+		delay := retryDelay(err, backoff, syncx.BackoffState{Attempt: attempt - 1, Last: lastDelay, Elapsed: time.Since(start)})
+		if delay == syncx.BackoffStop {
+			return result, err
+		}
+		if policy.OnRetry != nil {
+			policy.OnRetry(attempt, err, delay)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return result, err
+		}
+		lastDelay = delay
+	}
+}
+
+// runAttempt 执行单次尝试，捕获 panic，设置了超时时不等待超时后的结果
+func runAttempt[T any](ctx context.Context, timeout time.Duration, fn func(ctx context.Context) (T, error)) (result T, err error) {
+	if timeout <= 0 {
+		defer func() {
+			if rec := recover(); rec != nil {
+				var zero T
+				result, err = zero, fmt.Errorf("%s: %v", ErrPanic, rec)
+			}
+		}()
+		return fn(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type resStruct struct {
+		result T
+		err    error
+	}
+	resultChan := make(chan resStruct, 1)
+	go func() {
+		defer func() {
+			if rec := recover(); rec != nil {
+				resultChan <- resStruct{err: fmt.Errorf("%s: %v", ErrPanic, rec)}
+			}
+		}()
+		r, e := fn(attemptCtx)
+		resultChan <- resStruct{r, e}
+	}()
+
+	select {
+	case res := <-resultChan:
+		return res.result, res.err
+	case <-attemptCtx.Done():
+		// 外部上下文取消时返回其错误，否则为单次尝试超时
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		return result, ErrTimeout
+	}
+}
+
+// retryable 判断错误是否可以重试
+func (p *Policy) retryable(err error) bool {
+	var sr shouldRetry
+	if errors.As(err, &sr) && !sr.ShouldRetry() {
+		return false
+	}
+	return p.Condition == nil || p.Condition(err)
+}
+
+// backoff 返回生效的退避策略
+func (p *Policy) backoff() syncx.Backoff {
+	if p.Backoff != nil {
+		return p.Backoff
+	}
+	return intervalBackoff(p.Interval, p.MaxInterval, p.BackoffMultiplier, p.JitterPercent)
+}
+
+// retryDelay 计算下次重试的等待时间，错误提供的 Retry-After 优先于退避策略
+func retryDelay(err error, backoff syncx.Backoff, state syncx.BackoffState) time.Duration {
+	var ra retryAfter
+	if errors.As(err, &ra) {
+		if after := ra.GetRetryAfter(); after > 0 {
+			return after
+		}
+	}
+	return backoff.Next(state)
+}
+
+// sleepContext 等待 d，期间上下文取消则立即返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
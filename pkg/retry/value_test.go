@@ -0,0 +1,200 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-29 10:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-29 10:00:00
+ * @FilePath: \go-toolbox\pkg\retry\value_test.go
+ * @Description: 泛型重试与重试预算测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kamalyes/go-toolbox/pkg/errorx"
+	"github.com/kamalyes/go-toolbox/pkg/syncx"
+	"github.com/stretchr/testify/assert"
+)
+
+var errTemporary = errors.New("temporary")
+
+func TestDoValue(t *testing.T) {
+	var calls int
+	var delays []time.Duration
+	result, err := DoValue(context.Background(), &Policy{
+		MaxAttempts:       4,
+		Interval:          time.Millisecond,
+		BackoffMultiplier: 2,
+		MaxInterval:       3 * time.Millisecond,
+		OnRetry:           func(attempt int, err error, delay time.Duration) { delays = append(delays, delay) },
+	}, func(ctx context.Context) (int, error) {
+		calls++
+		if calls < 4 {
+			return 0, errTemporary
+		}
+		return 42, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 42, result)
+	assert.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}, delays)
+
+	// 默认只执行一次
+	calls = 0
+	_, err = DoValue(context.Background(), nil, func(ctx context.Context) (string, error) {
+		calls++
+		return "", errTemporary
+	})
+	assert.ErrorIs(t, err, errTemporary)
+	assert.Equal(t, 1, calls)
+
+	_, err = DoValue[int](context.Background(), nil, nil)
+	assert.ErrorIs(t, err, ErrFunIsNil)
+}
+
+func TestDoValue_Backoff(t *testing.T) {
+	var calls int
+	_, err := DoValue(context.Background(), &Policy{
+		MaxAttempts: 10,
+		Backoff:     syncx.MaxElapsedBackoff(syncx.ConstantBackoff(5*time.Millisecond), 12*time.Millisecond),
+	}, func(ctx context.Context) (int, error) {
+		calls++
+		return 0, errTemporary
+	})
+	assert.ErrorIs(t, err, errTemporary)
+	assert.GreaterOrEqual(t, calls, 3)
+	assert.Less(t, calls, 10)
+}
+
+func TestDoValue_Condition(t *testing.T) {
+	var calls int
+	_, err := DoValue(context.Background(), &Policy{
+		MaxAttempts: 3,
+		Condition:   func(err error) bool { return !errors.Is(err, errTemporary) },
+	}, func(ctx context.Context) (int, error) {
+		calls++
+		return 0, errTemporary
+	})
+	assert.ErrorIs(t, err, errTemporary)
+	assert.Equal(t, 1, calls)
+
+	// RetryableError 声明不可重试
+	calls = 0
+	retryable := errorx.NewRetryableError("busy", 0, time.Millisecond)
+	_, err = DoValue(context.Background(), &Policy{MaxAttempts: 3}, func(ctx context.Context) (int, error) {
+		calls++
+		return 0, retryable
+	})
+	assert.ErrorIs(t, err, retryable)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDoValue_AttemptTimeout(t *testing.T) {
+	var calls atomic.Int32
+	result, err := DoValue(context.Background(), &Policy{
+		MaxAttempts:    2,
+		AttemptTimeout: 20 * time.Millisecond,
+	}, func(ctx context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			<-ctx.Done()
+			time.Sleep(50 * time.Millisecond) // 忽略上下文也不会阻塞重试
+			return "late", nil
+		}
+		return "ok", nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", result)
+
+	_, err = DoValue(context.Background(), &Policy{AttemptTimeout: 10 * time.Millisecond}, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	assert.ErrorIs(t, err, ErrTimeout)
+
+	_, err = DoValue(context.Background(), &Policy{AttemptTimeout: time.Second}, func(ctx context.Context) (int, error) {
+		panic("boom")
+	})
+	assert.ErrorContains(t, err, "boom")
+}
+
+func TestDoValue_RetryAfter(t *testing.T) {
+	var delays []time.Duration
+	retryable := errorx.NewRetryableError("rate limited", 3, 5*time.Millisecond)
+	_, err := DoValue(context.Background(), &Policy{
+		MaxAttempts: 2,
+		Interval:    time.Hour,
+		OnRetry:     func(attempt int, err error, delay time.Duration) { delays = append(delays, delay) },
+	}, func(ctx context.Context) (int, error) {
+		return 0, retryable
+	})
+	assert.ErrorIs(t, err, retryable)
+	assert.Equal(t, []time.Duration{5 * time.Millisecond}, delays)
+}
+
+func TestDoValue_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := DoValue(ctx, &Policy{MaxAttempts: 3, Interval: time.Hour}, func(ctx context.Context) (int, error) {
+		return 0, errTemporary
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestDoValue_Budget(t *testing.T) {
+	budget := NewBudget(0.25, 2)
+	policy := &Policy{MaxAttempts: 3, Budget: budget}
+
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = DoValue(context.Background(), policy, func(ctx context.Context) (int, error) {
+				calls.Add(1)
+				return 0, errTemporary
+			})
+		}()
+	}
+	wg.Wait()
+
+	// 8 次调用最多存入 2 个令牌，加上初始 2 个，重试不超过 4 次
+	assert.GreaterOrEqual(t, calls.Load(), int32(8+2))
+	assert.LessOrEqual(t, calls.Load(), int32(8+4))
+	assert.Less(t, budget.Tokens(), 1.0)
+
+	_, err := DoValue(context.Background(), policy, func(ctx context.Context) (int, error) {
+		return 0, errTemporary
+	})
+	assert.ErrorIs(t, err, ErrBudgetExhausted)
+	assert.ErrorIs(t, err, errTemporary)
+}
+
+func TestBudget(t *testing.T) {
+	budget := NewBudget(0.5, 1)
+	assert.True(t, budget.Withdraw())
+	assert.False(t, budget.Withdraw())
+
+	budget.Deposit()
+	assert.False(t, budget.Withdraw())
+	budget.Deposit()
+	budget.Deposit()
+	assert.Equal(t, 1.0, budget.Tokens()) // 不超过上限
+	assert.True(t, budget.Withdraw())
+}
+
+func TestRetry_Policy(t *testing.T) {
+	policy := NewRetry().SetAttemptCount(3).SetInterval(time.Millisecond).SetJitter(true).Policy()
+	assert.Equal(t, 3, policy.MaxAttempts)
+	assert.Equal(t, time.Millisecond, policy.Interval)
+	assert.Equal(t, 0.2, policy.JitterPercent)
+}
@@ -0,0 +1,145 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-29 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-29 14:00:00
+ * @FilePath: \go-toolbox\pkg\syncx\backoff.go
+ * @Description: 可插拔的退避策略，供 retry.Retry 与 Delayer 共用
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+
+package syncx
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// BackoffStop 退避策略返回该值表示停止重试
+const BackoffStop time.Duration = -1
+
+// BackoffState 计算退避时间所需的状态
+type BackoffState struct {
+	Attempt int           // 第几次等待，从 0 开始
+	Last    time.Duration // 上一次等待时间，首次为 0
+	Elapsed time.Duration // 自首次执行起经过的时间
+}
+
+// Backoff 退避策略，根据状态返回下一次等待时间，返回 BackoffStop 表示停止
+// 实现应当是无状态的，以便在多个协程之间共享
+type Backoff interface {
+	Next(state BackoffState) time.Duration
+}
+
+// BackoffFunc 函数形式的退避策略
+type BackoffFunc func(state BackoffState) time.Duration
+
+// Next 实现 Backoff 接口
+func (f BackoffFunc) Next(state BackoffState) time.Duration {
+	return f(state)
+}
+
+// ConstantBackoff 固定间隔
+func ConstantBackoff(interval time.Duration) Backoff {
+	return BackoffFunc(func(BackoffState) time.Duration {
+		return interval
+	})
+}
+
+// LinearBackoff 线性递增：initial + step × attempt，max 为 0 时不限制
+func LinearBackoff(initial, step, max time.Duration) Backoff {
+	return BackoffFunc(func(s BackoffState) time.Duration {
+		return capDuration(float64(initial)+float64(step)*float64(s.Attempt), max)
+	})
+}
+
+// ExponentialBackoff 指数递增：initial × multiplier^attempt，max 为 0 时不限制
+func ExponentialBackoff(initial time.Duration, multiplier float64, max time.Duration) Backoff {
+	return BackoffFunc(func(s BackoffState) time.Duration {
+		return capDuration(float64(initial)*math.Pow(multiplier, float64(s.Attempt)), max)
+	})
+}
+
+// FibonacciBackoff 斐波那契递增：initial × fib(attempt+1)，即 1, 1, 2, 3, 5...倍
+func FibonacciBackoff(initial, max time.Duration) Backoff {
+	return BackoffFunc(func(s BackoffState) time.Duration {
+		a, b := 1.0, 1.0
+		for range s.Attempt {
+			if a, b = b, a+b; float64(initial)*a > math.MaxInt64 {
+				break
+			}
+		}
+		return capDuration(float64(initial)*a, max)
+	})
+}
+
+// PolynomialBackoff 多项式递增：initial × (attempt+1)^degree
+func PolynomialBackoff(initial time.Duration, degree float64, max time.Duration) Backoff {
+	return BackoffFunc(func(s BackoffState) time.Duration {
+		return capDuration(float64(initial)*math.Pow(float64(s.Attempt+1), degree), max)
+	})
+}
+
+// DecorrelatedJitterBackoff 去相关抖动：在 [base, last×3] 内随机取值
+// 参见 AWS Architecture Blog《Exponential Backoff And Jitter》
+func DecorrelatedJitterBackoff(base, max time.Duration) Backoff {
+	return BackoffFunc(func(s BackoffState) time.Duration {
+		upper := math.Max(float64(s.Last)*3, float64(base))
+		return capDuration(float64(base)+rand.Float64()*(upper-float64(base)), max)
+	})
+}
+
+// FullJitterBackoff 完全抖动：在 [0, b] 内随机取值
+func FullJitterBackoff(b Backoff) Backoff {
+	return BackoffFunc(func(s BackoffState) time.Duration {
+		d := b.Next(s)
+		if d <= 0 {
+			return d
+		}
+		return time.Duration(rand.Int63n(int64(d) + 1))
+	})
+}
+
+// EqualJitterBackoff 均等抖动：在 [b/2, b] 内随机取值
+func EqualJitterBackoff(b Backoff) Backoff {
+	return BackoffFunc(func(s BackoffState) time.Duration {
+		d := b.Next(s)
+		if d <= 0 {
+			return d
+		}
+		half := d / 2
+		return half + time.Duration(rand.Int63n(int64(d-half)+1))
+	})
+}
+
+// MaxElapsedBackoff 限制总耗时，累计耗时达到 maxElapsed 后返回 BackoffStop
+// 等待时间会被截断，不会超出剩余时间
+func MaxElapsedBackoff(b Backoff, maxElapsed time.Duration) Backoff {
+	return BackoffFunc(func(s BackoffState) time.Duration {
+		remain := maxElapsed - s.Elapsed
+		if remain <= 0 {
+			return BackoffStop
+		}
+		d := b.Next(s)
+		if d < 0 {
+			return d
+		}
+		return min(d, remain)
+	})
+}
+
+// capDuration 将浮点时间转换为 Duration，超过 max(大于 0 时)或溢出时截断
+func capDuration(d float64, max time.Duration) time.Duration {
+	if d < 0 {
+		d = 0
+	}
+	if max > 0 && d > float64(max) {
+		return max
+	}
+	if d >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
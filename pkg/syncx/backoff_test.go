@@ -0,0 +1,79 @@
+/*
+ * @Author: kamalyes 501893067@qq.com
+ * @Date: 2025-12-29 14:00:00
+ * @LastEditors: kamalyes 501893067@qq.com
+ * @LastEditTime: 2025-12-29 14:00:00
+ * @FilePath: \go-toolbox\pkg\syncx\backoff_test.go
+ * @Description: 退避策略测试
+ *
+ * Copyright (c) 2025 by kamalyes, All Rights Reserved.
+ */
+package syncx
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// backoffSeq 依次计算前 n 次等待时间
+func backoffSeq(b Backoff, n int) []time.Duration {
+	seq := make([]time.Duration, 0, n)
+	var last time.Duration
+	for i := range n {
+		last = b.Next(BackoffState{Attempt: i, Last: last})
+		seq = append(seq, last)
+	}
+	return seq
+}
+
+func TestBackoff(t *testing.T) {
+	ms := time.Millisecond
+	tests := map[string]struct {
+		backoff  Backoff
+		expected []time.Duration
+	}{
+		"constant":    {ConstantBackoff(10 * ms), []time.Duration{10 * ms, 10 * ms, 10 * ms}},
+		"linear":      {LinearBackoff(10*ms, 5*ms, 18*ms), []time.Duration{10 * ms, 15 * ms, 18 * ms}},
+		"exponential": {ExponentialBackoff(10*ms, 2, 0), []time.Duration{10 * ms, 20 * ms, 40 * ms, 80 * ms}},
+		"fibonacci":   {FibonacciBackoff(10*ms, 45*ms), []time.Duration{10 * ms, 10 * ms, 20 * ms, 30 * ms, 45 * ms}},
+		"polynomial":  {PolynomialBackoff(10*ms, 2, 0), []time.Duration{10 * ms, 40 * ms, 90 * ms}},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, backoffSeq(tc.backoff, len(tc.expected)))
+		})
+	}
+
+	// 溢出时截断而不是变为负数
+	assert.Equal(t, time.Duration(math.MaxInt64), ExponentialBackoff(time.Second, 10, 0).Next(BackoffState{Attempt: 100}))
+	assert.Equal(t, time.Duration(math.MaxInt64), FibonacciBackoff(time.Second, 0).Next(BackoffState{Attempt: 1000}))
+}
+
+func TestBackoff_Jitter(t *testing.T) {
+	base := ConstantBackoff(100 * time.Millisecond)
+	for range 100 {
+		full := FullJitterBackoff(base).Next(BackoffState{})
+		assert.True(t, full >= 0 && full <= 100*time.Millisecond, full)
+
+		equal := EqualJitterBackoff(base).Next(BackoffState{})
+		assert.True(t, equal >= 50*time.Millisecond && equal <= 100*time.Millisecond, equal)
+
+		decorrelated := DecorrelatedJitterBackoff(10*time.Millisecond, time.Second).Next(BackoffState{Last: 100 * time.Millisecond})
+		assert.True(t, decorrelated >= 10*time.Millisecond && decorrelated <= 300*time.Millisecond, decorrelated)
+	}
+
+	// 首次等待时间为 base，且不超过 max
+	assert.Equal(t, 10*time.Millisecond, DecorrelatedJitterBackoff(10*time.Millisecond, 0).Next(BackoffState{}))
+	assert.Equal(t, 20*time.Millisecond, DecorrelatedJitterBackoff(10*time.Millisecond, 20*time.Millisecond).Next(BackoffState{Last: time.Hour}))
+	assert.Equal(t, BackoffStop, FullJitterBackoff(BackoffFunc(func(BackoffState) time.Duration { return BackoffStop })).Next(BackoffState{}))
+}
+
+func TestMaxElapsedBackoff(t *testing.T) {
+	b := MaxElapsedBackoff(ConstantBackoff(100*time.Millisecond), time.Second)
+	assert.Equal(t, 100*time.Millisecond, b.Next(BackoffState{Elapsed: 500 * time.Millisecond}))
+	assert.Equal(t, 50*time.Millisecond, b.Next(BackoffState{Elapsed: 950 * time.Millisecond}))
+	assert.Equal(t, BackoffStop, b.Next(BackoffState{Elapsed: time.Second}))
+}
@@ -34,6 +34,8 @@ const (
 	RandomDelayStrategy
 	// CustomDelayStrategy 自定义延迟策略
 	CustomDelayStrategy
+	// BackoffDelayStrategy 使用 Backoff 计算延迟
+	BackoffDelayStrategy
 )
 
 // DelayFunc 自定义延迟函数类型
@@ -120,6 +122,9 @@ type Delayer[T any] struct {
 	randomBase      float64       // 随机基数
 	maxDelay        time.Duration // 最大延迟时间
 	multiplier      float64       // 指数策略的倍数
+	backoff         Backoff       // 退避策略
+	backoffLast     time.Duration // 退避策略上一次的延迟
+	backoffStart    time.Time     // 退避策略首次计算的时间
 
 	// 执行控制
 	ctx            context.Context    // 上下文
@@ -247,6 +252,13 @@ func (d *Delayer[T]) WithCustomDelay(delayFunc DelayFunc) *Delayer[T] {
 	return d
 }
 
+// WithBackoff 设置退避策略，Backoff 返回 BackoffStop 时停止后续执行
+func (d *Delayer[T]) WithBackoff(backoff Backoff) *Delayer[T] {
+	d.backoff = backoff
+	d.strategy = BackoffDelayStrategy
+	return d
+}
+
 // WithRandomBase 设置随机基数（用于随机延迟策略）
 func (d *Delayer[T]) WithRandomBase(base float64) *Delayer[T] {
 	if base <= 0 {
@@ -422,6 +434,25 @@ func (d *Delayer[T]) calculateDelay(attempt int) time.Duration {
 		}
 		return d.delay
 
+	case BackoffDelayStrategy:
+		if d.backoff == nil {
+			return d.delay
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if d.backoffStart.IsZero() {
+			d.backoffStart = time.Now()
+		}
+		delay := d.backoff.Next(BackoffState{Attempt: attempt, Last: d.backoffLast, Elapsed: time.Since(d.backoffStart)})
+		if delay == BackoffStop {
+			return delay
+		}
+		if d.maxDelay > 0 && delay > d.maxDelay {
+			delay = d.maxDelay
+		}
+		d.backoffLast = delay
+		return delay
+
 	default:
 		return d.delay
 	}
@@ -521,6 +552,9 @@ func (d *Delayer[T]) executeSequentially() error {
 		// 延迟执行
 		if d.delay > 0 || d.strategy != FixedDelayStrategy {
 			delay := d.calculateDelay(i)
+			if delay == BackoffStop {
+				break
+			}
 			if delay > 0 {
 				timer := time.NewTimer(delay)
 				d.timers = append(d.timers, timer)
@@ -596,6 +630,10 @@ func (d *Delayer[T]) executeConcurrently() error {
 			// 延迟执行
 			if d.delay > 0 || d.strategy != FixedDelayStrategy {
 				delay := d.calculateDelay(index)
+				if delay == BackoffStop {
+					atomic.AddInt64(&d.stats.SkippedCount, 1)
+					return
+				}
 				if delay > 0 {
 					timer := time.NewTimer(delay)
 					select {
@@ -506,3 +506,41 @@ func BenchmarkChannelOperationsAtomic(b *testing.B) {
 
 	delayer.Close()
 }
+
+// TestDelayerWithBackoff 测试退避策略及停止
+func TestDelayerWithBackoff(t *testing.T) {
+	var states []BackoffState
+	var executed int
+	delayer := NewDelayer[int]().
+		WithTimes(5).
+		WithBackoff(BackoffFunc(func(s BackoffState) time.Duration {
+			states = append(states, s)
+			if s.Attempt >= 3 {
+				return BackoffStop
+			}
+			return time.Duration(s.Attempt+1) * time.Millisecond
+		})).
+		WithTaskFunc(func(ctx *ExecutionContext) (int, error) {
+			executed++
+			return ctx.Index, nil
+		})
+
+	assert.NoError(t, delayer.Execute())
+	assert.Equal(t, 3, executed)
+	assert.Equal(t, []int{0, 1, 2}, delayer.GetResults())
+	assert.Len(t, states, 4)
+	assert.Equal(t, 2*time.Millisecond, states[2].Last)
+	assert.Greater(t, states[3].Elapsed, time.Duration(0))
+}
+
+// TestDelayerWithBackoffConcurrent 测试并发执行时退避策略停止后跳过剩余任务
+func TestDelayerWithBackoffConcurrent(t *testing.T) {
+	delayer := NewDelayer[int]().
+		WithTimes(4).
+		WithConcurrent(true).
+		WithBackoff(MaxElapsedBackoff(ConstantBackoff(time.Millisecond), 0)).
+		WithSimpleFunction(func() {})
+
+	assert.NoError(t, delayer.Execute())
+	assert.Equal(t, int64(4), delayer.GetStats().SkippedCount)
+}